@@ -2,21 +2,137 @@ package auth
 
 import (
 	"errors"
+	"fmt"
 	"os"
 
 	"github.com/spf13/viper"
+
+	"github.com/shipyard/shipyard-cli/config"
 )
 
-// APIToken tries to read a token for the Shipyard API
-// from the environment variable or loaded config (in that order).
+// APIToken tries to read a token for the Shipyard API from the environment variable,
+// the config file or the credential store (in that order), for the active profile.
+// The config file keeps tokens saved before the credential store was selected,
+// until 'shipyard auth migrate' moves them.
 func APIToken() (string, error) {
 	if token := os.Getenv("SHIPYARD_API_TOKEN"); token != "" {
 		return token, nil
 	}
+	account := TokenAccount(config.ActiveProfile())
+	if token, err := (configStore{}).Get(account); err == nil {
+		return token, nil
+	}
 
-	token := viper.GetString("api_token")
-	if token == "" {
-		return "", errors.New("token is missing, set the 'SHIPYARD_API_TOKEN' environment variable or 'api_token' config value")
+	store, err := DefaultStore()
+	if err != nil {
+		return "", err
+	}
+	token, err := store.Get(account)
+	if errors.Is(err, ErrNotFound) {
+		if profile := config.ActiveProfile(); profile != "" {
+			return "", fmt.Errorf("no token is saved for profile %s, run 'shipyard login' or set the 'SHIPYARD_API_TOKEN' environment variable", profile)
+		}
+		return "", errors.New("token is missing, run 'shipyard login' or set the 'SHIPYARD_API_TOKEN' environment variable")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the token from %s: %w", store.Name(), err)
 	}
 	return token, nil
 }
+
+// CurrentAccount returns the account of the token of the active profile.
+func CurrentAccount() string {
+	if profile := config.ActiveProfile(); profile != "" {
+		return ProfileAccount(profile)
+	}
+	return DefaultAccount
+}
+
+// TokenAccount returns the account the token of a profile is read from. A built-in profile
+// without a token of its own uses that of the default profile, which is where tokens saved
+// before profiles existed are, so that 'shipyard mode prod' keeps working with them.
+func TokenAccount(profile string) string {
+	if profile == "" || profile == config.DefaultProfile {
+		return DefaultAccount
+	}
+	account := ProfileAccount(profile)
+	if _, builtIn := config.ModeURLs[profile]; builtIn {
+		if _, ok := AccountToken(account); !ok {
+			return DefaultAccount
+		}
+	}
+	return account
+}
+
+// ProfileToken returns the token saved for a profile, from the config file or the credential store.
+func ProfileToken(profile string) (string, bool) {
+	return AccountToken(ProfileAccount(profile))
+}
+
+// AccountToken returns the token saved for an account, from the config file or the credential store.
+func AccountToken(account string) (string, bool) {
+	if token, err := (configStore{}).Get(account); err == nil {
+		return token, true
+	}
+	store, err := DefaultStore()
+	if err != nil {
+		return "", false
+	}
+	token, err := store.Get(account)
+	return token, err == nil
+}
+
+// SaveToken saves the token of a profile, or of the active profile if none is given, in the credential store.
+// A token left in the config file by an earlier version is removed from it, so that it does not take precedence.
+// It returns the store the token went to.
+func SaveToken(token, profile string) (Store, error) {
+	store, err := DefaultStore()
+	if err != nil {
+		return nil, err
+	}
+	account := CurrentAccount()
+	if profile != "" {
+		account = ProfileAccount(profile)
+	}
+	if err := store.Set(account, token); err != nil {
+		return nil, fmt.Errorf("failed to save the token in %s: %w", store.Name(), err)
+	}
+	if !IsPlaintext(store) {
+		if _, err := (configStore{}).Get(account); err == nil {
+			if err := (configStore{}).Delete(account); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return store, nil
+}
+
+// DeleteToken removes the token of an account from the config file and from the credential store.
+// It reports whether there was a token to remove.
+func DeleteToken(account string) (bool, error) {
+	found := false
+	// viper also sees SHIPYARD_API_TOKEN as 'api_token', so look at the config file itself.
+	if key := configKey(account); viper.InConfig(key) && viper.GetString(key) != "" {
+		if err := (configStore{}).Delete(account); err != nil {
+			return false, err
+		}
+		found = true
+	}
+
+	store, err := DefaultStore()
+	if err != nil {
+		return found, err
+	}
+	if IsPlaintext(store) {
+		return found, nil
+	}
+	if _, err := store.Get(account); errors.Is(err, ErrNotFound) {
+		return found, nil
+	} else if err != nil {
+		return found, fmt.Errorf("failed to read the token from %s: %w", store.Name(), err)
+	}
+	if err := store.Delete(account); err != nil {
+		return found, fmt.Errorf("failed to remove the token from %s: %w", store.Name(), err)
+	}
+	return true, nil
+}
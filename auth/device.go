@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/shipyard/shipyard-cli/pkg/types"
+	"github.com/shipyard/shipyard-cli/version"
+)
+
+// Errors the API answers a device token request with, as in RFC 8628.
+const (
+	deviceAuthorizationPending = "authorization_pending"
+	deviceSlowDown             = "slow_down"
+	deviceExpiredToken         = "expired_token"
+	deviceAccessDenied         = "access_denied"
+)
+
+// defaultDeviceInterval and defaultDeviceExpiry apply when the API does not give them.
+const (
+	defaultDeviceInterval = 5 * time.Second
+	defaultDeviceExpiry   = 15 * time.Minute
+)
+
+// DeviceCode is what the user enters on another device to approve the login.
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	// ExpiresIn and Interval are in seconds.
+	ExpiresIn int `json:"expires_in"`
+	Interval  int `json:"interval"`
+}
+
+// RequestDeviceCode starts a device login with the API at apiURL.
+// No token is needed, since getting one is the point.
+func RequestDeviceCode(ctx context.Context, apiURL string) (*DeviceCode, error) {
+	var resp struct {
+		Data struct {
+			Attributes DeviceCode `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := postDevice(ctx, apiURL+"/cli/device-code", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to start the device login: %w", err)
+	}
+	code := resp.Data.Attributes
+	if code.DeviceCode == "" || code.UserCode == "" || code.VerificationURI == "" {
+		return nil, errors.New("failed to start the device login: incomplete response from the API")
+	}
+	return &code, nil
+}
+
+// PollDeviceToken asks the API for the token at the interval it sets, until the user approves
+// or denies the login, the code expires or ctx is done.
+func PollDeviceToken(ctx context.Context, apiURL string, code *DeviceCode) (string, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDeviceInterval
+	}
+	expiry := time.Duration(code.ExpiresIn) * time.Second
+	if expiry <= 0 {
+		expiry = defaultDeviceExpiry
+	}
+	ctx, cancel := context.WithTimeout(ctx, expiry)
+	defer cancel()
+
+	body := map[string]string{"device_code": code.DeviceCode}
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return "", errors.New("the code expired before the login was approved")
+			}
+			return "", errors.New("login canceled")
+		case <-time.After(interval):
+		}
+
+		var resp struct {
+			Data struct {
+				Attributes struct {
+					Token string `json:"token"`
+				} `json:"attributes"`
+			} `json:"data"`
+		}
+		err := postDevice(ctx, apiURL+"/cli/device-token", body, &resp)
+		if err == nil {
+			if resp.Data.Attributes.Token == "" {
+				return "", errors.New("no token received from Shipyard")
+			}
+			return resp.Data.Attributes.Token, nil
+		}
+		if ctx.Err() != nil {
+			// Handled at the top of the loop.
+			continue
+		}
+		var apiErr deviceError
+		if !errors.As(err, &apiErr) {
+			return "", err
+		}
+		switch string(apiErr) {
+		case deviceAuthorizationPending:
+		case deviceSlowDown:
+			interval += 5 * time.Second
+		case deviceExpiredToken:
+			return "", errors.New("the code expired before the login was approved")
+		case deviceAccessDenied:
+			return "", errors.New("the login was denied")
+		default:
+			return "", err
+		}
+	}
+}
+
+// deviceError is the title of the first error of an error response.
+type deviceError string
+
+func (e deviceError) Error() string {
+	return string(e)
+}
+
+// postDevice sends a request of the device login, which unlike other requests carries no token.
+func postDevice(ctx context.Context, uri string, body, v any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("shipyard-cli-%s-%s-%s", version.Version, runtime.GOOS, runtime.GOARCH))
+
+	log.Println("URI", uri)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending API request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	b, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if title := types.ErrorFromResponse(b); title != "" {
+			return deviceError(title)
+		}
+		return errors.New(strings.TrimSpace(string(b)))
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// passphraseEnv holds the passphrase of the encrypted credentials file.
+const passphraseEnv = "SHIPYARD_CREDENTIALS_PASSPHRASE"
+
+// pbkdf2Iterations follows the OWASP recommendation for PBKDF2-HMAC-SHA256.
+const pbkdf2Iterations = 600_000
+
+// fileStore keeps the tokens in a file encrypted with AES-GCM,
+// with a key derived from SHIPYARD_CREDENTIALS_PASSPHRASE.
+type fileStore struct {
+	path       string
+	passphrase string
+}
+
+// encryptedFile is what the credentials file contains. A new salt and nonce are used on every write.
+type encryptedFile struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func newFileStore(path string) (*fileStore, error) {
+	passphrase := os.Getenv(passphraseEnv)
+	if passphrase == "" {
+		return nil, fmt.Errorf("the encrypted credential store needs a passphrase in %s", passphraseEnv)
+	}
+	return &fileStore{path: path, passphrase: passphrase}, nil
+}
+
+func (s *fileStore) Get(account string) (string, error) {
+	tokens, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	token, ok := tokens[account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return token, nil
+}
+
+func (s *fileStore) Set(account, token string) error {
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+	tokens[account] = token
+	return s.write(tokens)
+}
+
+func (s *fileStore) Delete(account string) error {
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := tokens[account]; !ok {
+		return nil
+	}
+	delete(tokens, account)
+	return s.write(tokens)
+}
+
+func (s *fileStore) Name() string {
+	return "the encrypted file " + s.path
+}
+
+func (s *fileStore) read() (map[string]string, error) {
+	tokens := make(map[string]string)
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f encryptedFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	gcm, err := s.cipher(f.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s, check %s", s.path, passphraseEnv)
+	}
+	if err := json.Unmarshal(plain, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.path, err)
+	}
+	return tokens, nil
+}
+
+func (s *fileStore) write(tokens map[string]string) error {
+	plain, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	f := encryptedFile{Salt: make([]byte, 16)}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return err
+	}
+	gcm, err := s.cipher(f.Salt)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Ciphertext = gcm.Seal(nil, f.Nonce, plain, nil)
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, b, 0o600)
+}
+
+func (s *fileStore) cipher(salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, s.passphrase, salt, pbkdf2Iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
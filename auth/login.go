@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// LoginURL returns the page of the Shipyard app, served next to the API at apiURL,
+// that issues a CLI token and redirects the browser to callbackURL with it and the state.
+func LoginURL(apiURL, callbackURL, state string) (string, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid API URL %q", apiURL)
+	}
+	login := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/api/me/user-token/cli"}
+	login.RawQuery = url.Values{"callbackUrl": {callbackURL}, "state": {state}}.Encode()
+	return login.String(), nil
+}
+
+// CallbackServer listens on the loopback interface for the browser to come back with a token.
+// Requests without the random state it was created with are turned away.
+type CallbackServer struct {
+	listener net.Listener
+	server   *http.Server
+	state    string
+	once     sync.Once
+	result   chan callbackResult
+}
+
+type callbackResult struct {
+	token string
+	err   error
+}
+
+// NewCallbackServer starts listening on a free port of 127.0.0.1.
+func NewCallbackServer() (*CallbackServer, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("error creating a local callback server: %w", err)
+	}
+
+	s := &CallbackServer{
+		listener: listener,
+		state:    hex.EncodeToString(b),
+		result:   make(chan callbackResult, 1),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.callback)
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 3 * time.Second}
+
+	go func() {
+		log.Printf("Started a local callback server on %s.", listener.Addr())
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.finish(callbackResult{err: err})
+		}
+	}()
+	return s, nil
+}
+
+// URL is where the browser is sent back to.
+func (s *CallbackServer) URL() string {
+	return "http://" + s.listener.Addr().String()
+}
+
+// State is the value the callback must carry back.
+func (s *CallbackServer) State() string {
+	return s.state
+}
+
+// Wait returns the token received by the callback.
+func (s *CallbackServer) Wait(ctx context.Context) (string, error) {
+	select {
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", errors.New("authentication timeout")
+		}
+		return "", errors.New("login canceled")
+	case r := <-s.result:
+		return r.token, r.err
+	}
+}
+
+// Close stops the server.
+func (s *CallbackServer) Close() error {
+	return s.server.Close()
+}
+
+func (s *CallbackServer) callback(w http.ResponseWriter, r *http.Request) {
+	state := r.URL.Query().Get("state")
+	if subtle.ConstantTimeCompare([]byte(state), []byte(s.state)) != 1 {
+		// Another page may be probing the port: ignore it and keep waiting for the real callback.
+		log.Println("Ignored a login callback with a wrong state.")
+		http.Error(w, "Invalid login request.", http.StatusBadRequest)
+		return
+	}
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "No token received from Shipyard.", http.StatusBadRequest)
+		s.finish(callbackResult{err: errors.New("no token received from Shipyard")})
+		return
+	}
+	_, _ = fmt.Fprintln(w, "Authentication succeeded. You may close this browser tab.")
+	s.finish(callbackResult{token: token})
+}
+
+// finish keeps the first result: the callback is only used once.
+func (s *CallbackServer) finish(r callbackResult) {
+	s.once.Do(func() {
+		s.result <- r
+	})
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoginURL(t *testing.T) {
+	tests := []struct {
+		apiURL string
+		want   string
+	}{
+		{
+			apiURL: "https://shipyard.build/api/v1",
+			want:   "https://shipyard.build/api/me/user-token/cli?callbackUrl=http%3A%2F%2F127.0.0.1%3A4000&state=abc",
+		},
+		{
+			apiURL: "http://localhost:8080/api/v1",
+			want:   "http://localhost:8080/api/me/user-token/cli?callbackUrl=http%3A%2F%2F127.0.0.1%3A4000&state=abc",
+		},
+	}
+	for _, test := range tests {
+		got, err := LoginURL(test.apiURL, "http://127.0.0.1:4000", "abc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("%s: expected %s, but got %s", test.apiURL, test.want, got)
+		}
+	}
+	if _, err := LoginURL("localhost", "http://127.0.0.1:4000", "abc"); err == nil {
+		t.Error("expected an error for an API URL without a scheme")
+	}
+}
+
+func TestCallbackServer(t *testing.T) {
+	s, err := NewCallbackServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if !strings.HasPrefix(s.URL(), "http://127.0.0.1:") {
+		t.Errorf("expected the server to listen on the loopback interface, but got %s", s.URL())
+	}
+
+	get := func(query url.Values) int {
+		resp, err := http.Get(s.URL() + "/?" + query.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := get(url.Values{"token": {"stolen"}}); code != http.StatusBadRequest {
+		t.Errorf("expected a callback without the state to be rejected, but got %d", code)
+	}
+	if code := get(url.Values{"token": {"stolen"}, "state": {"guess"}}); code != http.StatusBadRequest {
+		t.Errorf("expected a callback with a wrong state to be rejected, but got %d", code)
+	}
+	if code := get(url.Values{"token": {"secret"}, "state": {s.State()}}); code != http.StatusOK {
+		t.Errorf("expected the callback to succeed, but got %d", code)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	token, err := s.Wait(ctx)
+	if err != nil || token != "secret" {
+		t.Errorf("expected the token of the valid callback, but got %q, %v", token, err)
+	}
+}
+
+func TestPollDeviceToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string
+		token     string
+		err       string
+	}{
+		{
+			name:      "approved",
+			responses: []string{`authorization_pending`, `{"data":{"attributes":{"token":"secret"}}}`},
+			token:     "secret",
+		},
+		{
+			name:      "denied",
+			responses: []string{`access_denied`},
+			err:       "the login was denied",
+		},
+		{
+			name:      "expired",
+			responses: []string{`expired_token`},
+			err:       "the code expired before the login was approved",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			polls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				resp := test.responses[min(polls, len(test.responses)-1)]
+				polls++
+				if !strings.HasPrefix(resp, "{") {
+					w.WriteHeader(http.StatusBadRequest)
+					resp = `{"errors":[{"status":400,"title":"` + resp + `"}]}`
+				}
+				_, _ = w.Write([]byte(resp))
+			}))
+			defer server.Close()
+
+			code := &DeviceCode{DeviceCode: "device", Interval: 1, ExpiresIn: 10}
+			token, err := PollDeviceToken(context.Background(), server.URL, code)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("expected error %q, but got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil || token != test.token {
+				t.Errorf("expected token %q, but got %q, %v", test.token, token, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/shipyard/shipyard-cli/config"
+)
+
+// MigrateConfigTokens moves the plaintext tokens of the config file at path to store:
+// 'api_token' and the 'auth_token' of every profile. The profiles themselves stay.
+// The config file is only changed once every token is saved in store.
+// It returns the accounts that were moved.
+func MigrateConfigTokens(path string, store Store) ([]string, error) {
+	var moved []string
+	err := config.Edit(path, func(cfg *config.Config) error {
+		if cfg.Token != "" {
+			if err := store.Set(DefaultAccount, cfg.Token); err != nil {
+				return fmt.Errorf("failed to save the token in %s: %w", store.Name(), err)
+			}
+			moved = append(moved, DefaultAccount)
+			cfg.Token = ""
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(cfg.Profiles)) {
+			p := cfg.Profiles[name]
+			if p.AuthToken == "" {
+				continue
+			}
+			if err := store.Set(ProfileAccount(name), p.AuthToken); err != nil {
+				return fmt.Errorf("failed to save the token of profile %s in %s: %w", name, store.Name(), err)
+			}
+			moved = append(moved, ProfileAccount(name))
+			p.AuthToken = ""
+			cfg.Profiles[name] = p
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
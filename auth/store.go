@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+	"github.com/zalando/go-keyring"
+
+	"github.com/shipyard/shipyard-cli/config"
+)
+
+// Credential store kinds, set with the 'credential_store' config value.
+const (
+	// StoreAuto uses the system keyring, then the encrypted file if a passphrase is set,
+	// then the config file.
+	StoreAuto    = "auto"
+	StoreKeyring = "keyring"
+	StoreFile    = "file"
+	StoreConfig  = "config"
+)
+
+// keyringService is the name the tokens are saved under in the system keyring.
+const keyringService = "shipyard-cli"
+
+// ErrNotFound is returned by a Store that has no token for an account.
+var ErrNotFound = errors.New("no token found")
+
+// Store saves API tokens by account: DefaultAccount for the token in use,
+// or ProfileAccount(name) for the token of a profile.
+type Store interface {
+	Get(account string) (string, error)
+	Set(account, token string) error
+	Delete(account string) error
+	// Name describes where the tokens are kept, e.g. "the system keyring".
+	Name() string
+}
+
+// DefaultAccount holds the token used by API requests.
+const DefaultAccount = "default"
+
+// ProfileAccount returns the account of the token saved for a profile.
+func ProfileAccount(profile string) string {
+	return "profile:" + profile
+}
+
+// OpenStore returns the store of the given kind. StoreAuto picks the first one that works:
+// the system keyring, the encrypted file when SHIPYARD_CREDENTIALS_PASSPHRASE is set,
+// or the config file.
+func OpenStore(kind string) (Store, error) {
+	switch kind {
+	case StoreKeyring:
+		return keyringStore{}, nil
+	case StoreFile:
+		return newFileStore(credentialsPath())
+	case StoreConfig:
+		return configStore{}, nil
+	case StoreAuto, "":
+		if keyringAvailable() {
+			return keyringStore{}, nil
+		}
+		if os.Getenv(passphraseEnv) != "" {
+			return newFileStore(credentialsPath())
+		}
+		return configStore{}, nil
+	default:
+		return nil, fmt.Errorf("unknown credential store %q, use one of %s, %s, %s or %s", kind, StoreAuto, StoreKeyring, StoreFile, StoreConfig)
+	}
+}
+
+// DefaultStore returns the store selected by the 'credential_store' config value.
+func DefaultStore() (Store, error) {
+	return OpenStore(viper.GetString("credential_store"))
+}
+
+// IsPlaintext reports whether store keeps the tokens in plaintext in the config file.
+func IsPlaintext(store Store) bool {
+	_, ok := store.(configStore)
+	return ok
+}
+
+// StoreKind returns the kind of store, e.g. StoreKeyring, which is what StoreAuto resolved to.
+func StoreKind(store Store) string {
+	switch store.(type) {
+	case keyringStore:
+		return StoreKeyring
+	case *fileStore:
+		return StoreFile
+	default:
+		return StoreConfig
+	}
+}
+
+// keyringAvailable reports whether the system keyring answers, e.g. Secret Service on Linux.
+// It is only asked once per run.
+var keyringAvailable = sync.OnceValue(func() bool {
+	_, err := keyring.Get(keyringService, DefaultAccount)
+	return err == nil || errors.Is(err, keyring.ErrNotFound)
+})
+
+type keyringStore struct{}
+
+func (keyringStore) Get(account string) (string, error) {
+	token, err := keyring.Get(keyringService, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return token, err
+}
+
+func (keyringStore) Set(account, token string) error {
+	return keyring.Set(keyringService, account, token)
+}
+
+func (keyringStore) Delete(account string) error {
+	err := keyring.Delete(keyringService, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (keyringStore) Name() string {
+	return "the system keyring"
+}
+
+// configStore keeps the tokens in plaintext in the config file:
+// 'api_token' for the default account and 'profiles.<name>.auth_token' for profiles.
+type configStore struct{}
+
+func (configStore) Get(account string) (string, error) {
+	token := viper.GetString(configKey(account))
+	if token == "" {
+		return "", ErrNotFound
+	}
+	return token, nil
+}
+
+func (configStore) Set(account, token string) error {
+	viper.Set(configKey(account), token)
+	return config.Edit(viper.ConfigFileUsed(), func(cfg *config.Config) error {
+		if profile, ok := profileName(account); ok {
+			cfg.UpdateProfile(profile, func(p *config.Profile) { p.AuthToken = token })
+		} else {
+			cfg.Token = token
+		}
+		return nil
+	})
+}
+
+func (configStore) Delete(account string) error {
+	viper.Set(configKey(account), "")
+	if viper.ConfigFileUsed() == "" {
+		return nil
+	}
+	return config.Edit(viper.ConfigFileUsed(), func(cfg *config.Config) error {
+		if profile, ok := profileName(account); ok {
+			// The profile itself stays, only its token goes.
+			if p, ok := cfg.Profiles[profile]; ok {
+				p.AuthToken = ""
+				cfg.Profiles[profile] = p
+			}
+		} else {
+			cfg.Token = ""
+		}
+		return nil
+	})
+}
+
+func (configStore) Name() string {
+	return "the config file"
+}
+
+// configKey returns the config key that holds the token of an account.
+func configKey(account string) string {
+	if profile, ok := profileName(account); ok {
+		return "profiles." + profile + ".auth_token"
+	}
+	return "api_token"
+}
+
+func profileName(account string) (string, bool) {
+	return strings.CutPrefix(account, "profile:")
+}
+
+// credentialsPath returns the encrypted file, next to the config file in use.
+func credentialsPath() string {
+	if cfg := viper.ConfigFileUsed(); cfg != "" {
+		return filepath.Join(filepath.Dir(cfg), "credentials")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".shipyard", "credentials")
+}
@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/viper"
+	"github.com/zalando/go-keyring"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	t.Setenv(passphraseEnv, "correct horse")
+
+	s, err := newFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(DefaultAccount); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected no token in a missing file, but got %v", err)
+	}
+	if err := s.Set(DefaultAccount, "secret-token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(ProfileAccount("qa"), "qa-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "secret-token") {
+		t.Error("expected the file to be encrypted")
+	}
+
+	if got, err := s.Get(DefaultAccount); err != nil || got != "secret-token" {
+		t.Errorf("expected secret-token, but got %q, %v", got, err)
+	}
+	if err := s.Delete(ProfileAccount("qa")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ProfileAccount("qa")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the deleted token to be gone, but got %v", err)
+	}
+
+	wrong := &fileStore{path: path, passphrase: "battery staple"}
+	if _, err := wrong.Get(DefaultAccount); err == nil {
+		t.Error("expected a wrong passphrase to fail")
+	}
+}
+
+func TestMigrateConfigTokens(t *testing.T) {
+	keyring.MockInit()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := `api_token: main-token
+org: acme
+profiles:
+  qa:
+    auth_token: qa-token
+  local: {}
+`
+	if err := os.WriteFile(path, []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := MigrateConfigTokens(path, keyringStore{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{DefaultAccount, ProfileAccount("qa")}; !cmp.Equal(moved, want) {
+		t.Error(cmp.Diff(want, moved))
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `org: acme
+profiles:
+  qa: {}
+  local: {}
+`
+	if diff := cmp.Diff(want, string(b)); diff != "" {
+		t.Error(diff)
+	}
+	for account, token := range map[string]string{DefaultAccount: "main-token", ProfileAccount("qa"): "qa-token"} {
+		if got, err := (keyringStore{}).Get(account); err != nil || got != token {
+			t.Errorf("expected %s in the keyring for %s, but got %q, %v", token, account, got, err)
+		}
+	}
+}
+
+func TestStoreKind(t *testing.T) {
+	t.Setenv(passphraseEnv, "correct horse")
+	for _, kind := range []string{StoreKeyring, StoreFile, StoreConfig} {
+		store, err := OpenStore(kind)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := StoreKind(store); got != kind {
+			t.Errorf("expected the %s store to be of kind %s, but got %s", store.Name(), kind, got)
+		}
+	}
+}
+
+func TestAPIToken(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("SHIPYARD_API_TOKEN", "")
+	viper.Set("credential_store", StoreKeyring)
+	viper.Set("api_token", "")
+	defer viper.Set("credential_store", nil)
+	defer viper.Set("api_token", nil)
+
+	if _, err := APIToken(); err == nil {
+		t.Fatal("expected an error without a token")
+	}
+	if _, err := SaveToken("from-keyring", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveToken("qa-token", "qa"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := APIToken(); err != nil || got != "from-keyring" {
+		t.Errorf("expected the token from the keyring, but got %q, %v", got, err)
+	}
+	if got, ok := ProfileToken("qa"); !ok || got != "qa-token" {
+		t.Errorf("expected the token of profile qa, but got %q, %t", got, ok)
+	}
+
+	viper.Set("profile", "qa")
+	if got, err := APIToken(); err != nil || got != "qa-token" {
+		t.Errorf("expected the token of the active profile, but got %q, %v", got, err)
+	}
+	viper.Set("profile", "local")
+	if got, err := APIToken(); err != nil || got != "from-keyring" {
+		t.Errorf("expected a built-in profile without a token to use the default one, but got %q, %v", got, err)
+	}
+	viper.Set("profile", "onprem")
+	if _, err := APIToken(); err == nil {
+		t.Error("expected an error for a profile without a token")
+	}
+	viper.Set("profile", nil)
+
+	viper.Set("api_token", "from-config")
+	if got, _ := APIToken(); got != "from-config" {
+		t.Errorf("expected a token left in the config to come first, but got %q", got)
+	}
+	t.Setenv("SHIPYARD_API_TOKEN", "from-env")
+	if got, _ := APIToken(); got != "from-env" {
+		t.Errorf("expected the environment variable to come first, but got %q", got)
+	}
+}
+
+func TestDeleteToken(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("SHIPYARD_API_TOKEN", "")
+	viper.Set("credential_store", StoreKeyring)
+	defer viper.Set("credential_store", nil)
+	defer viper.Set("api_token", nil)
+
+	if _, err := SaveToken("from-keyring", ""); err != nil {
+		t.Fatal(err)
+	}
+	// A token left in the config file by an earlier version goes as well.
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("api_token: from-config\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	viper.SetConfigFile(path)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	defer viper.Reset()
+
+	found, err := DeleteToken(DefaultAccount)
+	if err != nil || !found {
+		t.Fatalf("expected the token to be removed, but got %t, %v", found, err)
+	}
+	if got, err := APIToken(); err == nil {
+		t.Errorf("expected no token left, but got %q", got)
+	}
+
+	found, err = DeleteToken(DefaultAccount)
+	if err != nil || found {
+		t.Errorf("expected nothing to remove, but got %t, %v", found, err)
+	}
+}
@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/shipyard/shipyard-cli/config"
+	"github.com/shipyard/shipyard-cli/pkg/display"
+	"github.com/shipyard/shipyard-cli/pkg/types"
+)
+
+// Alias names are stored as config keys, which viper lowercases and splits on dots.
+var aliasName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+func NewAliasCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alias",
+		Short: "Manage short names for environments",
+		Long: `Aliases are short names for environment IDs, stored in the config.
+An alias can be used anywhere an environment ID is accepted, as an argument or with --env.`,
+		Example: `  # Name environment 8f3c1a as checkout-pr
+  shipyard alias set checkout-pr 8f3c1a
+
+  # Use the alias instead of the ID
+  shipyard get services --env checkout-pr
+  shipyard restart environment checkout-pr
+
+  # List and remove aliases
+  shipyard alias list
+  shipyard alias rm checkout-pr`,
+	}
+
+	cmd.AddCommand(newAliasSetCmd())
+	cmd.AddCommand(newAliasListCmd())
+	cmd.AddCommand(newAliasRemoveCmd())
+
+	return cmd
+}
+
+func newAliasSetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "set [alias] [environment ID]",
+		Short:        "Create or update an alias for an environment",
+		Example:      `  shipyard alias set checkout-pr 8f3c1a`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setAlias(args[0], args[1])
+		},
+	}
+
+	return cmd
+}
+
+func newAliasListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "list",
+		Aliases:      []string{"ls"},
+		Short:        "List the environment aliases",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listAliases()
+		},
+	}
+
+	return cmd
+}
+
+func newAliasRemoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "rm [alias]",
+		Aliases:      []string{"remove", "delete"},
+		Short:        "Remove an environment alias",
+		Example:      `  shipyard alias rm checkout-pr`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return aliasNames(), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return removeAlias(args[0])
+		},
+	}
+
+	return cmd
+}
+
+func setAlias(name, id string) error {
+	name = strings.ToLower(name)
+	if !aliasName.MatchString(name) {
+		return fmt.Errorf("invalid alias %q: use letters, digits, '-' and '_', starting with a letter or digit", name)
+	}
+	if id == "" {
+		return errors.New("environment ID not provided")
+	}
+
+	err := config.Edit(viper.ConfigFileUsed(), func(cfg *config.Config) error {
+		if cfg.Aliases == nil {
+			cfg.Aliases = make(map[string]string)
+		}
+		cfg.Aliases[name] = id
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return display.PrintResource(display.ActionResource(
+		fmt.Sprintf("Alias %s now points to environment %s.", name, id),
+		aliasResult(name, id, "set"),
+	))
+}
+
+func removeAlias(name string) error {
+	name = strings.ToLower(name)
+	var id string
+
+	err := config.Edit(viper.ConfigFileUsed(), func(cfg *config.Config) error {
+		value, ok := cfg.Aliases[name]
+		if !ok {
+			return fmt.Errorf("alias %q not found", name)
+		}
+		id = value
+		delete(cfg.Aliases, name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return display.PrintResource(display.ActionResource(
+		fmt.Sprintf("Alias %s removed.", name),
+		aliasResult(name, id, "removed"),
+	))
+}
+
+type aliasEntry struct {
+	Alias         string `json:"alias"`
+	EnvironmentID string `json:"environment_id"`
+}
+
+func listAliases() error {
+	aliases := viper.GetStringMapString("aliases")
+	names := aliasNames()
+
+	entries := make([]aliasEntry, 0, len(names))
+	rows := make([][]string, 0, len(names))
+	for _, name := range names {
+		entries = append(entries, aliasEntry{Alias: name, EnvironmentID: aliases[name]})
+		rows = append(rows, []string{name, display.FormatClickableUUID(aliases[name])})
+	}
+
+	res := display.Resource{
+		Object: struct {
+			Data []aliasEntry `json:"data"`
+		}{Data: entries},
+		Columns: []string{"Alias", "Environment"},
+		Rows:    rows,
+		Names:   names,
+	}
+	if len(names) == 0 {
+		res.Message = "No aliases found. Create one with 'shipyard alias set [alias] [environment ID]'."
+	}
+	return display.PrintResource(res)
+}
+
+// aliasNames returns the configured alias names in alphabetical order.
+func aliasNames() []string {
+	aliases := viper.GetStringMapString("aliases")
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func aliasResult(name, id, result string) types.ActionResult {
+	return types.ActionResult{ID: id, Action: "alias " + name, Result: result}
+}
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/shipyard/shipyard-cli/auth"
+	"github.com/shipyard/shipyard-cli/config"
+	"github.com/shipyard/shipyard-cli/pkg/display"
+)
+
+func NewAuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage where API tokens are stored",
+		Long: `API tokens are saved in a credential store, selected by the 'credential_store' config value:
+
+  auto     the system keyring, or the encrypted file when SHIPYARD_CREDENTIALS_PASSPHRASE is set,
+           or else the config file (default)
+  keyring  the system keyring: Keychain on macOS, Credential Manager on Windows, Secret Service on Linux
+  file     a file next to the config, encrypted with SHIPYARD_CREDENTIALS_PASSPHRASE
+  config   the config file, in plaintext`,
+		Example: `  # Move the tokens saved in the config file to the system keyring
+  shipyard auth migrate
+
+  # Move them to an encrypted file instead
+  SHIPYARD_CREDENTIALS_PASSPHRASE=... shipyard auth migrate --to file`,
+	}
+
+	cmd.AddCommand(newAuthMigrateCmd())
+
+	return cmd
+}
+
+func newAuthMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "migrate",
+		Short:        "Move the tokens in the config file to a credential store",
+		Long:         `Move 'api_token' and the token of every profile out of the config file, into the system keyring or an encrypted file.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			_ = viper.BindPFlag("to", cmd.Flags().Lookup("to"))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return migrateTokens(viper.GetString("to"))
+		},
+	}
+
+	cmd.Flags().String("to", auth.StoreAuto, "Credential store to move the tokens to: auto, keyring or file")
+
+	return cmd
+}
+
+func migrateTokens(kind string) error {
+	if kind == auth.StoreConfig {
+		return errors.New("the tokens are already in the config file, choose another store with --to")
+	}
+	store, err := auth.OpenStore(kind)
+	if err != nil {
+		return err
+	}
+	if auth.IsPlaintext(store) {
+		return errors.New("no secure credential store is available: the system keyring does not answer and SHIPYARD_CREDENTIALS_PASSPHRASE is not set")
+	}
+
+	moved, err := auth.MigrateConfigTokens(viper.ConfigFileUsed(), store)
+	if err != nil {
+		return err
+	}
+	// Later commands must read the tokens from the store they were moved to, even if
+	// auto would pick another one then, e.g. without the passphrase of the encrypted file.
+	if err := config.Edit(viper.ConfigFileUsed(), func(cfg *config.Config) error {
+		cfg.CredentialStore = auth.StoreKind(store)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if len(moved) == 0 {
+		display.Println("No tokens found in the config file.")
+		return nil
+	}
+	display.Println(fmt.Sprintf("Moved %d token(s) to %s.", len(moved), store.Name()))
+	return nil
+}
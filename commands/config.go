@@ -0,0 +1,372 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/shipyard/shipyard-cli/config"
+	"github.com/shipyard/shipyard-cli/pkg/completion"
+	"github.com/shipyard/shipyard-cli/pkg/display"
+)
+
+func NewConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and change the configuration",
+		Long: `The configuration is read from, in order of precedence:
+
+  flags                 e.g. --org
+  environment variables e.g. SHIPYARD_ORG
+  .shipyard.yaml        the project config, found in the working directory or a parent directory
+  the active profile    see 'shipyard profile'
+  the user config       $HOME/.shipyard/config.yaml, or the file given with --config
+
+The project config can set org, repo_name, aliases, service (the default service of exec, logs and
+port-forward) and upload_ignore (patterns of files to leave out of volume uploads).
+
+'shipyard config get', 'set' and 'unset' work on any key of the user config, listed by
+'shipyard config list'. Tokens are saved with 'shipyard login' or 'shipyard set token' instead.`,
+		Example: `  # Change the port of 'shipyard mcp serve'
+  shipyard config set mcp.port 9090
+
+  # Change the org of a profile
+  shipyard config set profiles.staging.org acme
+
+  # Find the line of a mistake in the config file
+  shipyard config validate`,
+	}
+
+	cmd.AddCommand(newConfigViewCmd())
+	cmd.AddCommand(newConfigGetCmd())
+	cmd.AddCommand(newConfigSetCmd())
+	cmd.AddCommand(newConfigUnsetCmd())
+	cmd.AddCommand(newConfigListCmd())
+	cmd.AddCommand(newConfigValidateCmd())
+
+	return cmd
+}
+
+func newConfigViewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "view",
+		Short: "Show the configuration in use",
+		Long:  `Show the configuration values in use. Tokens are masked.`,
+		Example: `  # Show which file each value comes from
+  shipyard config view --show-origin`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			_ = viper.BindPFlag("show-origin", cmd.Flags().Lookup("show-origin"))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return viewConfig(viper.GetBool("show-origin"))
+		},
+	}
+
+	cmd.Flags().Bool("show-origin", false, "Show the file, profile, environment variable or flag each value comes from")
+
+	return cmd
+}
+
+func viewConfig(showOrigin bool) error {
+	settings := config.Settings(flagChanged)
+
+	columns := []string{"Key", "Value"}
+	if showOrigin {
+		columns = append(columns, "Origin")
+	}
+	rows := make([][]string, 0, len(settings))
+	names := make([]string, 0, len(settings))
+	for i, s := range settings {
+		row := []string{s.Key, formatSetting(s.Value)}
+		if showOrigin {
+			row = append(row, s.Origin)
+		} else {
+			settings[i].Origin = ""
+		}
+		rows = append(rows, row)
+		names = append(names, s.Key)
+	}
+
+	res := display.Resource{
+		Object:  settings,
+		Columns: columns,
+		Rows:    rows,
+		Names:   names,
+	}
+	if len(settings) == 0 {
+		res.Message = "No configuration values are set."
+	}
+	return display.PrintResource(res)
+}
+
+func formatSetting(v any) string {
+	if list, ok := v.([]string); ok {
+		return strings.Join(list, ",")
+	}
+	if list, ok := v.([]any); ok {
+		items := make([]string, 0, len(list))
+		for _, item := range list {
+			items = append(items, fmt.Sprint(item))
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+func newConfigGetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get [key]",
+		Short: "Print a configuration value",
+		Long: `Print the value of a key in use, after flags, environment variables, the project config
+and the active profile are applied. Tokens are masked.`,
+		Example: `  shipyard config get org
+  shipyard config get profiles.staging.api_url`,
+		Args:              cobra.ExactArgs(1),
+		SilenceUsage:      true,
+		ValidArgsFunction: completion.ConfigKeyArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return getConfig(args[0])
+		},
+	}
+
+	return cmd
+}
+
+func getConfig(name string) error {
+	key, err := config.LookupKey(name)
+	if err != nil {
+		return err
+	}
+	value := configValue(key)
+	if value == "" {
+		return fmt.Errorf("%s is not set", key.Name)
+	}
+	display.Println(value)
+	return nil
+}
+
+// configValue returns the value of a key in use, or its default, formatted for display.
+func configValue(key config.Key) string {
+	value := viper.Get(key.Name)
+	if value == nil {
+		return key.Default
+	}
+	s := formatSetting(value)
+	if key.Secret && s != "" {
+		return "********"
+	}
+	return s
+}
+
+func newConfigSetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set [key] [value]",
+		Short: "Set a value in the config file",
+		Long: `Set a key in the user config file. The value is checked against the type of the key:
+true or false for a bool, a whole number for an int and comma-separated values for a list.`,
+		Example: `  shipyard config set verbose true
+  shipyard config set upload_ignore node_modules,.git
+  shipyard config set aliases.web 8f3c1a`,
+		Args:              cobra.ExactArgs(2),
+		SilenceUsage:      true,
+		ValidArgsFunction: completion.ConfigKeyArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setConfig(args[0], args[1])
+		},
+	}
+
+	return cmd
+}
+
+func setConfig(name, input string) error {
+	key, err := config.LookupKey(name)
+	if err != nil {
+		return err
+	}
+	if key.Credential {
+		return fmt.Errorf("%s holds a token, save it with 'shipyard login' or 'shipyard set token'", key.Name)
+	}
+	value, err := key.Parse(input)
+	if err != nil {
+		return err
+	}
+	if key.Name == "profile" {
+		if _, ok := config.LoadProfile(input); !ok && input != config.DefaultProfile {
+			return fmt.Errorf("profile %q not found, add it with 'shipyard profile add %s --api-url ...'", input, input)
+		}
+	}
+
+	path := viper.ConfigFileUsed()
+	f, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	if err := f.Set(key.Name, value); err != nil {
+		return err
+	}
+	if err := f.Save(); err != nil {
+		return err
+	}
+	shown := formatSetting(value)
+	if key.Secret {
+		shown = "********"
+	}
+	display.Println(fmt.Sprintf("Set %s to %s in %s.", key.Name, shown, path))
+	return nil
+}
+
+func newConfigUnsetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "unset [key]",
+		Short:             "Remove a value from the config file",
+		Example:           `  shipyard config unset mcp.port`,
+		Args:              cobra.ExactArgs(1),
+		SilenceUsage:      true,
+		ValidArgsFunction: completion.ConfigKeyArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return unsetConfig(args[0])
+		},
+	}
+
+	return cmd
+}
+
+func unsetConfig(name string) error {
+	key, err := config.LookupKey(name)
+	if err != nil {
+		return err
+	}
+	if key.Credential {
+		return fmt.Errorf("%s holds a token, remove it with 'shipyard logout'", key.Name)
+	}
+
+	path := viper.ConfigFileUsed()
+	f, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	found, err := f.Unset(key.Name)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%s is not set in %s", key.Name, path)
+	}
+	if err := f.Save(); err != nil {
+		return err
+	}
+	display.Println(fmt.Sprintf("Unset %s in %s.", key.Name, path))
+	return nil
+}
+
+func newConfigListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the configuration keys",
+		Long: `List every key 'shipyard config set' accepts, with its type and the value in use.
+Keys with <name> hold one value per profile or alias.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listConfig()
+		},
+	}
+
+	return cmd
+}
+
+type configEntry struct {
+	config.Key
+	Value string `json:"value,omitempty"`
+}
+
+func listConfig() error {
+	var entries []configEntry
+	for _, k := range config.Keys() {
+		prefix, rest, ok := strings.Cut(k.Name, "<name>")
+		if !ok {
+			entries = append(entries, configEntry{Key: k, Value: configValue(k)})
+			continue
+		}
+		names := slices.Sorted(maps.Keys(viper.GetStringMap(strings.TrimSuffix(prefix, "."))))
+		var found bool
+		for _, name := range names {
+			entry, err := config.LookupKey(prefix + name + rest)
+			if err != nil || viper.Get(entry.Name) == nil {
+				continue
+			}
+			entries = append(entries, configEntry{Key: entry, Value: configValue(entry)})
+			found = true
+		}
+		if !found {
+			entries = append(entries, configEntry{Key: k})
+		}
+	}
+
+	rows := make([][]string, 0, len(entries))
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		typ := e.Type
+		if len(e.Values) > 0 {
+			typ = strings.Join(e.Values, "|")
+		}
+		rows = append(rows, []string{e.Name, typ, e.Value})
+		names = append(names, e.Name)
+	}
+	return display.PrintResource(display.Resource{
+		Object:  entries,
+		Columns: []string{"Key", "Type", "Value"},
+		Rows:    rows,
+		Names:   names,
+	})
+}
+
+func newConfigValidateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate [file]",
+		Short: "Check a config file for mistakes",
+		Long: `Check the user config file, or the given one, for YAML syntax errors, unknown keys and values
+of the wrong type. Every mistake is reported with its line number.`,
+		Example: `  shipyard config validate
+  shipyard config validate ~/backup/config.yaml`,
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		// It has to run when the config file cannot be parsed, to tell where the mistake is.
+		Annotations: map[string]string{allowBrokenConfig: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := viper.ConfigFileUsed()
+			if len(args) > 0 {
+				path = args[0]
+			}
+			return validateConfig(path)
+		},
+	}
+
+	return cmd
+}
+
+func validateConfig(path string) error {
+	if path == "" {
+		return errors.New("no config file is in use")
+	}
+	problems, err := config.ValidateFile(path)
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		display.Println(fmt.Sprintf("%s is valid.", path))
+		return nil
+	}
+	for _, p := range problems {
+		display.Println(fmt.Sprintf("%s: %s", path, p))
+	}
+	return fmt.Errorf("found %d problem(s) in %s", len(problems), path)
+}
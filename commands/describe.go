@@ -0,0 +1,26 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/shipyard/shipyard-cli/commands/env"
+	"github.com/shipyard/shipyard-cli/constants"
+	"github.com/shipyard/shipyard-cli/pkg/client"
+)
+
+func NewDescribeCmd(c client.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "describe",
+		GroupID: constants.GroupEnvironments,
+		Short:   "Show detailed information about a resource",
+		Example: `  # Describe environment ID 12345
+  shipyard describe environment 12345
+
+  # Describe the environment of the current git repo and branch
+  shipyard describe env`,
+	}
+
+	cmd.AddCommand(env.NewDescribeEnvironmentCmd(c))
+
+	return cmd
+}
@@ -0,0 +1,300 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/shipyard/shipyard-cli/pkg/client"
+	"github.com/shipyard/shipyard-cli/pkg/display"
+	"github.com/shipyard/shipyard-cli/pkg/resolve"
+	"github.com/shipyard/shipyard-cli/pkg/services/environment"
+	"github.com/shipyard/shipyard-cli/pkg/types"
+)
+
+const defaultBulkConcurrency = 4
+
+var errBulkWithID = errors.New("an environment ID cannot be combined with the --name, --repo-name, --branch or --pull-request-number filters")
+
+// bulkAction describes an environment action that can be run against
+// every environment matched by a set of list filters.
+type bulkAction struct {
+	// verb is used in prompts, e.g. "stop".
+	verb string
+	// done is used in results, e.g. "stopped".
+	done string
+	// deleted selects deleted environments, which is what revive operates on.
+	deleted bool
+	// confirm asks before acting on an environment inferred from the git checkout.
+	confirm bool
+	run     func(ctx context.Context, s *environment.EnvironmentManager, id string) error
+}
+
+var (
+	stopAction = bulkAction{verb: "stop", done: "stopped", confirm: true, run: func(ctx context.Context, s *environment.EnvironmentManager, id string) error {
+		return s.Stop(ctx, id)
+	}}
+	restartAction = bulkAction{verb: "restart", done: "queued for a restart", run: func(ctx context.Context, s *environment.EnvironmentManager, id string) error {
+		return s.Restart(ctx, id)
+	}}
+	rebuildAction = bulkAction{verb: "rebuild", done: "queued for a rebuild", confirm: true, run: func(ctx context.Context, s *environment.EnvironmentManager, id string) error {
+		return s.Rebuild(ctx, id)
+	}}
+	cancelAction = bulkAction{verb: "cancel", done: "canceled", run: func(ctx context.Context, s *environment.EnvironmentManager, id string) error {
+		return s.Cancel(ctx, id)
+	}}
+	reviveAction = bulkAction{verb: "revive", done: "revived", deleted: true, confirm: true, run: func(ctx context.Context, s *environment.EnvironmentManager, id string) error {
+		return s.Revive(ctx, id)
+	}}
+)
+
+// printResult prints the outcome of the action on a single environment.
+func (a bulkAction) printResult(message, id string) error {
+	return display.PrintResource(display.ActionResource(message, types.ActionResult{ID: id, Action: a.verb, Result: a.done}))
+}
+
+// addBulkFlags registers the flags that select environments for a bulk action.
+// The filters mirror the ones accepted by 'get environments'.
+func addBulkFlags(cmd *cobra.Command) {
+	cmd.Flags().String("name", "", "Select environments by name of the application")
+	cmd.Flags().String("repo-name", "", "Select environments by repo name")
+	cmd.Flags().String("branch", "", "Select environments by branch name")
+	cmd.Flags().String("pull-request-number", "", "Select environments by pull request number")
+	cmd.Flags().Bool("all-pages", false, "Select matching environments from every page instead of only the first one")
+	cmd.Flags().Bool("dry-run", false, "List the selected environments without changing them")
+	cmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
+	cmd.Flags().Int("concurrency", defaultBulkConcurrency, "Maximum number of environments processed at the same time")
+}
+
+// bindBulkFlags binds the bulk flags in PreRun, since the same flag names
+// are bound to sibling commands as well.
+func bindBulkFlags(cmd *cobra.Command) {
+	for _, name := range []string{"name", "repo-name", "branch", "pull-request-number", "all-pages", "dry-run", "yes", "concurrency"} {
+		_ = viper.BindPFlag(name, cmd.Flags().Lookup(name))
+	}
+}
+
+// hasBulkFilters reports whether any of the environment filters was provided.
+func hasBulkFilters() bool {
+	for _, name := range []string{"name", "repo-name", "branch", "pull-request-number"} {
+		if viper.GetString(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// runEnvironmentAction dispatches a single environment action either to the
+// given ID handler or, when filters are set, to the bulk handler.
+// The ID may be an alias. Without an ID or filters, the environment is resolved
+// from the git checkout.
+func runEnvironmentAction(ctx context.Context, c client.Client, args []string, action bulkAction, byID func(context.Context, client.Client, string) error) error {
+	switch {
+	case len(args) > 0 && hasBulkFilters():
+		return errBulkWithID
+	case hasBulkFilters():
+		return runBulkAction(ctx, c, action)
+	default:
+		var id string
+		if len(args) > 0 {
+			id = args[0]
+		}
+		resolved, err := resolve.New(c).WithDeleted(action.deleted).EnvironmentID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if id == "" && action.confirm {
+			ok, err := confirmInferred(action, resolved)
+			if err != nil || !ok {
+				return err
+			}
+		}
+		return byID(ctx, c, resolved)
+	}
+}
+
+// confirmInferred asks before acting on the environment inferred from the git checkout,
+// which the resolver has just named.
+func confirmInferred(action bulkAction, id string) (bool, error) {
+	return confirmAction(action, fmt.Sprintf("%s environment %s? [y/N] ", display.Capitalize(action.verb), id))
+}
+
+// confirmAction asks the user to confirm an action, unless --yes is set. When the user cannot
+// answer, with --no-input or without a terminal, it fails rather than wait for or guess an answer.
+func confirmAction(action bulkAction, prompt string) (bool, error) {
+	if viper.GetBool("yes") {
+		return true, nil
+	}
+	if !resolve.Interactive() {
+		return false, fmt.Errorf("cannot ask for a confirmation without a terminal or with --no-input, pass --yes to %s environments", action.verb)
+	}
+	ok, err := display.Confirm(os.Stdin, prompt)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		display.Println("Aborted.")
+	}
+	return ok, nil
+}
+
+type bulkResult struct {
+	env types.Environment
+	err error
+}
+
+func runBulkAction(ctx context.Context, c client.Client, action bulkAction) error {
+	filter := client.EnvironmentFilter{
+		Name:              viper.GetString("name"),
+		RepoName:          viper.GetString("repo-name"),
+		Branch:            viper.GetString("branch"),
+		PullRequestNumber: viper.GetString("pull-request-number"),
+		Deleted:           action.deleted,
+	}
+
+	spinner := display.NewSpinner("Fetching info please standby...")
+	spinner.Start()
+	envs, truncated, err := matchEnvironments(ctx, c, filter, viper.GetBool("all-pages"))
+	spinner.Stop()
+	if err != nil {
+		return err
+	}
+
+	structured := display.IsStructuredOutput()
+	if len(envs) == 0 {
+		if structured {
+			return display.PrintResource(environmentsResource(nil, types.RespManyEnvs{Data: []types.Environment{}}))
+		}
+		display.Println("No environments matched the filters.")
+		return nil
+	}
+
+	matched := environmentsResource(envs, types.RespManyEnvs{Data: envs})
+	if truncated {
+		matched.Footer = "More environments match on the next pages. Pass --all-pages to select them as well."
+	}
+	if viper.GetBool("dry-run") {
+		dryRun := fmt.Sprintf("Dry run: %d environment(s) would be affected by %s.", len(envs), action.verb)
+		if matched.Footer != "" {
+			dryRun = matched.Footer + "\n" + dryRun
+		}
+		matched.Footer = dryRun
+		return display.PrintResource(matched)
+	}
+
+	// Structured output only carries the results, so that it stays parseable.
+	if !structured {
+		if err := display.PrintResource(matched); err != nil {
+			return err
+		}
+	}
+
+	if structured && !viper.GetBool("yes") {
+		return fmt.Errorf("pass --yes to %s environments with -o %s, the confirmation prompt would mix with the output", action.verb, display.OutputFormat())
+	}
+	if ok, err := confirmAction(action, fmt.Sprintf("%s %d environment(s)? [y/N] ", display.Capitalize(action.verb), len(envs))); err != nil || !ok {
+		return err
+	}
+
+	results := runBulkPool(ctx, environment.NewEnvironmentManager(c), action, envs, viper.GetInt("concurrency"))
+
+	var failed int
+	res := display.Resource{
+		Columns: []string{"App", "UUID", "Result"},
+		Names:   make([]string, 0, len(results)),
+	}
+	object := types.ActionResultsResponse{Data: make([]types.ActionResult, 0, len(results))}
+	for i := range results {
+		result := types.ActionResult{ID: results[i].env.ID, Action: action.verb, Result: action.done}
+		status := color.New(color.FgGreen).Sprint(action.done)
+		if results[i].err != nil {
+			failed++
+			result.Result = "failed"
+			result.Error = results[i].err.Error()
+			status = color.New(color.FgRed).Sprint(result.Error)
+		}
+		res.Rows = append(res.Rows, []string{
+			display.FormatColoredAppName(results[i].env.Attributes.Name),
+			display.FormatClickableUUID(results[i].env.ID),
+			status,
+		})
+		res.Names = append(res.Names, result.ID)
+		object.Data = append(object.Data, result)
+	}
+	res.Object = object
+	if err := display.PrintResource(res); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to %s %d of %d environment(s)", action.verb, failed, len(results))
+	}
+	return nil
+}
+
+// matchEnvironments lists the environments that match the filter, following the
+// pages if allPages is set. It reports whether more matching environments were
+// left out because only the first page was requested.
+func matchEnvironments(ctx context.Context, c client.Client, filter client.EnvironmentFilter, allPages bool) ([]types.Environment, bool, error) {
+	if !allPages {
+		resp, err := environment.NewEnvironmentManager(c).List(ctx, environment.ListRequest{
+			Name:              filter.Name,
+			RepoName:          filter.RepoName,
+			Branch:            filter.Branch,
+			PullRequestNumber: filter.PullRequestNumber,
+			Deleted:           filter.Deleted,
+			Page:              1,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		return resp.Environments, resp.HasNext, nil
+	}
+
+	seen := make(map[string]bool)
+	var envs []types.Environment
+	for env, err := range c.ListEnvironments(ctx, filter) {
+		if err != nil {
+			return nil, false, err
+		}
+		if seen[env.ID] {
+			continue
+		}
+		seen[env.ID] = true
+		envs = append(envs, env)
+	}
+	return envs, false, nil
+}
+
+// runBulkPool runs the action against every environment using at most
+// concurrency workers. Results are returned in the order of envs.
+func runBulkPool(ctx context.Context, svc *environment.EnvironmentManager, action bulkAction, envs []types.Environment, concurrency int) []bulkResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]bulkResult, len(envs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(envs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = bulkResult{env: envs[i], err: action.run(ctx, svc, envs[i].ID)}
+			}
+		}()
+	}
+	for i := range envs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
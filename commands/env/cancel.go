@@ -1,11 +1,12 @@
 package env
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/shipyard/shipyard-cli/constants"
 	"github.com/shipyard/shipyard-cli/pkg/client"
-	"github.com/shipyard/shipyard-cli/pkg/display"
+	"github.com/shipyard/shipyard-cli/pkg/completion"
 	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
 	"github.com/spf13/cobra"
 )
@@ -33,28 +34,33 @@ func newCancelEnvironmentCmd(c client.Client) *cobra.Command {
 		Short:        "Cancel an environment's latest build",
 		Long:         `This command cancels the environment's latest build. You can ONLY cancel a build if it is currently in the building phase.`,
 		Example: `  # Cancel the current build for environment ID 12345
-  shipyard cancel environment 12345`,
+  shipyard cancel environment 12345
+
+  # Cancel the builds of every environment of the main branch
+  shipyard cancel environment --branch main`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			bindBulkFlags(cmd)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) > 0 {
-				return cancelEnvironmentByID(c, args[0])
-			}
-			return errNoEnvironment
+			return runEnvironmentAction(cmd.Context(), c, args, cancelAction, cancelEnvironmentByID)
 		},
+		ValidArgsFunction: completion.New(c).EnvironmentArg,
 	}
 
+	addBulkFlags(cmd)
+
 	return cmd
 }
 
-func cancelEnvironmentByID(c client.Client, id string) error {
+func cancelEnvironmentByID(ctx context.Context, c client.Client, id string) error {
 	params := make(map[string]string)
 	if org := c.OrgLookupFn(); org != "" {
 		params["org"] = org
 	}
-	_, err := c.Requester.Do(http.MethodPost, uri.CreateResourceURI("cancel", "environment", id, "", params), "application/json", nil)
+	_, err := c.Requester.Do(ctx, http.MethodPost, uri.CreateResourceURI("cancel", "environment", id, "", params), "application/json", nil)
 	if err != nil {
 		return err
 	}
 
-	display.Println("Environment canceled.")
-	return nil
+	return cancelAction.printResult("Environment canceled.", id)
 }
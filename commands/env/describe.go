@@ -0,0 +1,201 @@
+package env
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/shipyard/shipyard-cli/pkg/client"
+	"github.com/shipyard/shipyard-cli/pkg/completion"
+	"github.com/shipyard/shipyard-cli/pkg/display"
+	"github.com/shipyard/shipyard-cli/pkg/k8s"
+	"github.com/shipyard/shipyard-cli/pkg/resolve"
+	"github.com/shipyard/shipyard-cli/pkg/types"
+)
+
+// podsTimeout bounds the pod lookup, so that an unreachable cluster does not hold up the rest of the description.
+const podsTimeout = 15 * time.Second
+
+func NewDescribeEnvironmentCmd(c client.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "environment [environment ID]",
+		Aliases: []string{"env"},
+		Short:   "Show an environment's projects, services and pod health",
+		Long: `Shows every project of an environment with its commit, the services with their ports and URLs,
+whether the environment is stopped or retired and when it was last visited.
+The pod of each service is looked up in the environment's cluster, which reports its phase,
+restart count and last termination reason. Pass --skip-pods to leave the cluster out.`,
+		Example: `  # Describe environment ID 12345:
+  shipyard describe environment 12345
+
+  # Describe the environment of the current git repo and branch:
+  shipyard describe environment
+
+  # Describe environment ID 12345 without contacting its cluster:
+  shipyard describe environment 12345 --skip-pods
+
+  # Get the restart counts of the pods in environment ID 12345:
+  shipyard describe environment 12345 -o jsonpath='{range .data.pods[*]}{.service}={.restarts}{"\n"}{end}'`,
+		SilenceUsage: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			_ = viper.BindPFlag("json", cmd.Flags().Lookup("json"))
+			_ = viper.BindPFlag("skip-pods", cmd.Flags().Lookup("skip-pods"))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var id string
+			if len(args) > 0 {
+				id = args[0]
+			}
+			id, err := resolve.EnvironmentID(cmd.Context(), c, id)
+			if err != nil {
+				return err
+			}
+			return handleDescribeEnvironment(cmd.Context(), c, id)
+		},
+		ValidArgsFunction: completion.New(c).EnvironmentArg,
+	}
+
+	cmd.Flags().Bool("skip-pods", false, "Do not look up the pods in the environment's cluster")
+	cmd.Flags().Bool("json", false, "JSON output, same as -o json")
+
+	return cmd
+}
+
+// environmentDescription is an environment along with the health of its pods.
+type environmentDescription struct {
+	types.Environment
+	Pods []k8s.PodStatus `json:"pods,omitempty"`
+}
+
+func handleDescribeEnvironment(ctx context.Context, c client.Client, id string) error {
+	spinner := display.NewSpinner("Fetching info please standby...")
+	spinner.Start()
+	r, err := c.EnvByID(ctx, id)
+	if err != nil {
+		spinner.Stop()
+		return err
+	}
+
+	desc := environmentDescription{Environment: r.Data}
+	attrs := r.Data.Attributes
+	if !viper.GetBool("skip-pods") && !attrs.Stopped && !attrs.Retired && len(attrs.Services) > 0 {
+		desc.Pods, err = podStatuses(ctx, c, id, attrs.Services)
+		if err != nil {
+			// The rest of the description is still useful without the cluster.
+			_, _ = fmt.Fprintf(os.Stderr, "Pod health is unavailable: %v\n", err)
+		}
+	}
+	spinner.Stop()
+
+	return display.PrintResource(describeResource(desc))
+}
+
+func podStatuses(ctx context.Context, c client.Client, id string, services []types.Service) ([]k8s.PodStatus, error) {
+	k, err := k8s.NewConfig(ctx, c, id)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, podsTimeout)
+	defer cancel()
+	return k.PodStatuses(ctx, services)
+}
+
+// describeResource lays out the description as sections in the table formats,
+// and as one row per service in csv.
+func describeResource(desc environmentDescription) display.Resource {
+	attrs := desc.Attributes
+
+	var b bytes.Buffer
+	fields := [][2]string{
+		{"Name", attrs.Name},
+		{"UUID", display.FormatClickableUUID(desc.ID)},
+		{"URL", display.FormatClickableURL(attrs.URL)},
+		{"Ready", display.FormatReadyStatus(attrs.Ready)},
+		{"Stopped", yesNo(attrs.Stopped)},
+		{"Retired", yesNo(attrs.Retired)},
+		{"Last visit", formatSinceLastVisit(attrs.SinceLastVisit)},
+	}
+	for _, f := range fields {
+		_, _ = fmt.Fprintf(&b, "%-12s%s\n", f[0]+":", f[1])
+	}
+
+	projects := make([][]string, 0, len(attrs.Projects))
+	for _, p := range attrs.Projects {
+		pr := ""
+		if p.PullRequestNumber != 0 {
+			pr = strconv.Itoa(p.PullRequestNumber)
+		}
+		projects = append(projects, []string{p.RepoName, p.RepoOwner, p.Branch, pr, shortCommit(p.CommitHash)})
+	}
+	b.WriteString("\nProjects:\n")
+	display.RenderTable(&b, []string{"Repo", "Owner", "Branch", "PR#", "Commit"}, projects)
+
+	columns := []string{"Service", "Ports", "URL"}
+	podColumns := []string{"Pod", "Phase", "Restarts", "Last Termination"}
+	pods := make(map[string]k8s.PodStatus, len(desc.Pods))
+	for _, p := range desc.Pods {
+		pods[p.Service] = p
+	}
+
+	rows := make([][]string, 0, len(attrs.Services))
+	for _, svc := range attrs.Services {
+		row := []string{svc.Name, strings.Join(svc.Ports, ","), display.FormatClickableURL(svc.URL), "", "", "", ""}
+		if p, ok := pods[svc.Name]; ok {
+			row = append(row[:len(columns)], p.Pod, p.Phase, strconv.Itoa(int(p.Restarts)), p.LastTermination)
+		}
+		rows = append(rows, row)
+	}
+	columns = append(columns, podColumns...)
+	b.WriteString("Services:\n")
+	if desc.Pods != nil {
+		display.RenderTable(&b, columns, rows)
+	} else {
+		// Without pods, the table leaves out their empty columns.
+		display.RenderTable(&b, columns[:len(columns)-len(podColumns)], rows)
+	}
+
+	return display.Resource{
+		Object: struct {
+			Data environmentDescription `json:"data"`
+		}{Data: desc},
+		Columns: columns,
+		Rows:    rows,
+		Names:   []string{desc.ID},
+		Message: strings.TrimRight(b.String(), "\n"),
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
+
+// formatSinceLastVisit rounds the time since the last visit down to its largest unit.
+func formatSinceLastVisit(minutes *int) string {
+	switch {
+	case minutes == nil:
+		return "Never"
+	case *minutes < 60:
+		return fmt.Sprintf("%dm ago", *minutes)
+	case *minutes < 24*60:
+		return fmt.Sprintf("%dh ago", *minutes/60)
+	default:
+		return fmt.Sprintf("%dd ago", *minutes/(24*60))
+	}
+}
+
+func shortCommit(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
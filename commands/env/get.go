@@ -1,25 +1,26 @@
 package env
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/fatih/color"
+	"github.com/shipyard/shipyard-cli/config"
 	"github.com/shipyard/shipyard-cli/pkg/client"
 	"github.com/shipyard/shipyard-cli/pkg/completion"
 	"github.com/shipyard/shipyard-cli/pkg/display"
 	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
+	"github.com/shipyard/shipyard-cli/pkg/resolve"
 	"github.com/shipyard/shipyard-cli/pkg/types"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-var errNoEnvironment = errors.New("environment ID argument not provided")
-
 func NewGetEnvironmentCmd(c client.Client) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "environment [environment ID]",
@@ -28,25 +29,52 @@ func NewGetEnvironmentCmd(c client.Client) *cobra.Command {
 		Example: `  # Get all the details for environment ID 12345:
   shipyard get environment 12345
 
+  # Get the environment of the current git repo and branch:
+  shipyard get environment
+
   # Get all the details for environment ID 12345 in JSON format:
-  shipyard get environment 12345 --json`,
+  shipyard get environment 12345 -o json
+
+  # Get the URL of environment ID 12345:
+  shipyard get environment 12345 -o jsonpath='{.data.attributes.url}'
+
+  # Follow environment ID 12345 while it rebuilds:
+  shipyard get environment 12345 --watch`,
 		SilenceUsage: true,
 		// Due to an issue in viper, bind the 'json' flag in PreRun for each command that uses
 		// a flag name already bound to a sibling command.
 		// See https://github.com/spf13/viper/issues/233#issuecomment-386791444
 		PreRun: func(cmd *cobra.Command, args []string) {
 			_ = viper.BindPFlag("json", cmd.Flags().Lookup("json"))
+			_ = viper.BindPFlag("watch", cmd.Flags().Lookup("watch"))
+			_ = viper.BindPFlag("interval", cmd.Flags().Lookup("interval"))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			var id string
 			if len(args) > 0 {
-				return handleGetEnvironmentByID(c, args[0])
+				id = args[0]
 			}
-			return errNoEnvironment
+			id, err := resolve.EnvironmentID(cmd.Context(), c, id)
+			if err != nil {
+				return err
+			}
+			if viper.GetBool("watch") {
+				return watchEnvironments(cmd.Context(), cmd.CommandPath()+" "+id, func(ctx context.Context) ([]types.Environment, error) {
+					r, err := getEnvironment(ctx, c, id)
+					if err != nil {
+						return nil, err
+					}
+					return []types.Environment{r.Data}, nil
+				})
+			}
+			return handleGetEnvironmentByID(cmd.Context(), c, id)
 		},
-		ValidArgsFunction: completion.New(c).EnvironmentUUIDs,
+		ValidArgsFunction: completion.New(c).EnvironmentArg,
 	}
 
-	cmd.Flags().Bool("json", false, "JSON output")
+	cmd.Flags().Bool("json", false, "JSON output, same as -o json")
+	cmd.Flags().Bool("watch", false, "Keep polling and show the changes")
+	cmd.Flags().Duration("interval", 5*time.Second, "Time between polls with --watch")
 
 	return cmd
 }
@@ -61,13 +89,22 @@ func NewGetAllEnvironmentsCmd(c client.Client) *cobra.Command {
   shipyard get environments
 
   # Get all the details in JSON format:
-  shipyard get environments --json
+  shipyard get environments -o json
+
+  # Get the IDs of the environments only:
+  shipyard get environments -o name
 
   # Get all the environments for a specific repo and branch:
   shipyard get environments --repo-name flask-backend --branch main
 
   # Get all the environments based on specific PR:
   shipyard get environments --pull-request-number 1
+
+  # Get the environments from every page as a single JSON array:
+  shipyard get environments --all -o json
+
+  # Follow the environments of a branch, polling every 10 seconds:
+  shipyard get environments --branch main --watch --interval 10s
   `,
 		PreRun: func(cmd *cobra.Command, args []string) {
 			_ = viper.BindPFlag("name", cmd.Flags().Lookup("name"))
@@ -78,10 +115,28 @@ func NewGetAllEnvironmentsCmd(c client.Client) *cobra.Command {
 			_ = viper.BindPFlag("deleted", cmd.Flags().Lookup("deleted"))
 			_ = viper.BindPFlag("page", cmd.Flags().Lookup("page"))
 			_ = viper.BindPFlag("page-size", cmd.Flags().Lookup("page-size"))
+			_ = viper.BindPFlag("all", cmd.Flags().Lookup("all"))
 			_ = viper.BindPFlag("json", cmd.Flags().Lookup("json"))
+			_ = viper.BindPFlag("watch", cmd.Flags().Lookup("watch"))
+			_ = viper.BindPFlag("interval", cmd.Flags().Lookup("interval"))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return handleGetAllEnvironments(c)
+			if viper.GetBool("watch") {
+				return watchEnvironments(cmd.Context(), cmd.CommandPath(), func(ctx context.Context) ([]types.Environment, error) {
+					if viper.GetBool("all") {
+						return listEveryEnvironment(ctx, c)
+					}
+					r, err := getEnvironmentsPage(ctx, c)
+					if err != nil {
+						return nil, err
+					}
+					return r.Data, nil
+				})
+			}
+			if viper.GetBool("all") {
+				return handleGetEveryEnvironment(cmd.Context(), c)
+			}
+			return handleGetAllEnvironments(cmd.Context(), c)
 		},
 	}
 
@@ -93,81 +148,74 @@ func NewGetAllEnvironmentsCmd(c client.Client) *cobra.Command {
 	cmd.Flags().Bool("deleted", false, "Filter by deleted status (default false)")
 	cmd.Flags().Int("page", 1, "Page number requested")
 	cmd.Flags().Int("page-size", 20, "Page size requested")
-	cmd.Flags().Bool("json", false, "JSON output")
+	cmd.Flags().Bool("all", false, "Fetch every page instead of a single one")
+	cmd.Flags().Bool("json", false, "JSON output, same as -o json")
+	cmd.Flags().Bool("watch", false, "Keep polling and show the changes")
+	cmd.Flags().Duration("interval", 5*time.Second, "Time between polls with --watch")
+	cmd.MarkFlagsMutuallyExclusive("all", "page")
 
 	return cmd
 }
 
-//nolint:gocyclo // refactor?
-func handleGetAllEnvironments(c client.Client) error {
-	params := make(map[string]string)
-
-	if name := viper.GetString("name"); name != "" {
-		params["name"] = name
-	}
-	if orgName := viper.GetString("org-name"); orgName != "" {
-		params["org_name"] = orgName
-	}
-	if repoName := viper.GetString("repo-name"); repoName != "" {
-		params["repo_name"] = repoName
-	}
-	if branch := viper.GetString("branch"); branch != "" {
-		params["branch"] = branch
-	}
-	if pullRequestNumber := viper.GetString("pull-request-number"); pullRequestNumber != "" {
-		params["pull_request_number"] = pullRequestNumber
-	}
-	if deleted := viper.GetBool("deleted"); deleted {
-		params["deleted"] = "true"
+// handleGetEveryEnvironment follows the pages until the last one and prints
+// all matching environments in a single table or a single JSON array.
+func handleGetEveryEnvironment(ctx context.Context, c client.Client) error {
+	spinner := display.NewSpinner("Fetching info please standby...")
+	spinner.Start()
+	envs, err := listEveryEnvironment(ctx, c)
+	spinner.Stop()
+	if err != nil {
+		return err
 	}
-	if page := viper.GetInt("page"); page != 0 {
-		params["page"] = strconv.Itoa(page)
+
+	// Every page together is a plain array, without the links of a single page
+	res := environmentsResource(envs, envs)
+	if len(envs) == 0 {
+		res.Message = "No environments found in the org."
 	}
-	if pageSize := viper.GetInt("page-size"); pageSize != 0 {
-		params["page_size"] = strconv.Itoa(pageSize)
+	return display.PrintResource(res)
+}
+
+// listEveryEnvironment returns the environments matching the filter flags from every page.
+func listEveryEnvironment(ctx context.Context, c client.Client) ([]types.Environment, error) {
+	filter := client.EnvironmentFilter{
+		Name:              viper.GetString("name"),
+		OrgName:           viper.GetString("org-name"),
+		RepoName:          config.RepoName(),
+		Branch:            viper.GetString("branch"),
+		PullRequestNumber: viper.GetString("pull-request-number"),
+		Deleted:           viper.GetBool("deleted"),
+		PageSize:          viper.GetInt("page-size"),
 	}
-	if org := c.OrgLookupFn(); org != "" {
-		params["org"] = org
+
+	envs := []types.Environment{}
+	for env, err := range c.ListEnvironments(ctx, filter) {
+		if err != nil {
+			return nil, err
+		}
+		envs = append(envs, env)
 	}
+	return envs, nil
+}
 
+func handleGetAllEnvironments(ctx context.Context, c client.Client) error {
 	// Start spinner
 	spinner := display.NewSpinner("Fetching info please standby...")
 	spinner.Start()
 
-	body, err := c.Requester.Do(http.MethodGet, uri.CreateResourceURI("", "environment", "", "", params), "application/json", nil)
-	
+	r, err := getEnvironmentsPage(ctx, c)
+
 	// Stop spinner immediately after API call
 	spinner.Stop()
-	
-	if err != nil {
-		return err
-	}
-
-	if viper.GetBool("json") {
-		display.Println(body)
-		return nil
-	}
 
-	r, err := types.UnmarshalManyEnvs(body)
 	if err != nil {
 		return err
 	}
-	if len(r.Data) == 0 {
-		display.Println("No environments found in the org.")
-		return nil
-	}
-
-	// Detect duplicate UUIDs and generate colors
-	duplicateUUIDs := display.GetDuplicateUUIDs(r.Data)
-	duplicateColors := display.GenerateDuplicateColors(duplicateUUIDs)
 
-	var data [][]string
-	for i := range r.Data {
-		i := i
-		data = append(data, display.FormattedEnvironmentWithDuplicateColors(&r.Data[i], duplicateColors)...)
+	res := environmentsResource(r.Data, r)
+	if len(r.Data) == 0 {
+		res.Message = "No environments found in the org."
 	}
-	columns := []string{"App", "UUID", "Ready", "Repo", "PR#", "URL"}
-	display.RenderTable(os.Stdout, columns, data)
 	if r.Links.Next != "" {
 		nextPage := r.Links.NextPage()
 		cmd := " shipyard get environments --page " + strconv.Itoa(nextPage)
@@ -179,7 +227,7 @@ func handleGetAllEnvironments(c client.Client) error {
 		if orgName := viper.GetString("org-name"); orgName != "" {
 			cmd += " --org-name \"" + orgName + "\""
 		}
-		if repoName := viper.GetString("repo-name"); repoName != "" {
+		if repoName := config.RepoName(); repoName != "" {
 			cmd += " --repo-name \"" + repoName + "\""
 		}
 		if branch := viper.GetString("branch"); branch != "" {
@@ -194,49 +242,111 @@ func handleGetAllEnvironments(c client.Client) error {
 		if pageSize := viper.GetInt("page-size"); pageSize != 0 && pageSize != 20 {
 			cmd += " --page-size " + strconv.Itoa(pageSize)
 		}
-		if viper.GetBool("json") {
-			cmd += " --json"
+		if display.OutputFormat() == display.OutputWide {
+			cmd += " -o wide"
 		}
 		cmd += " "
 
 		styledCmd := color.New(color.FgHiWhite, color.BgBlue).Sprint(cmd)
-		display.Println(fmt.Sprintf("Table is truncated, fetch the next page %d. %s", nextPage, styledCmd))
+		res.Footer = fmt.Sprintf("Table is truncated, fetch the next page %d. %s\nPass --all to fetch every page at once.", nextPage, styledCmd)
 	}
-	return nil
+	return display.PrintResource(res)
 }
 
-func handleGetEnvironmentByID(c client.Client, id string) error {
+// getEnvironmentsPage fetches the page of environments selected by the filter and page flags.
+//
+//nolint:gocyclo // refactor?
+func getEnvironmentsPage(ctx context.Context, c client.Client) (*types.RespManyEnvs, error) {
 	params := make(map[string]string)
+
+	if name := viper.GetString("name"); name != "" {
+		params["name"] = name
+	}
+	if orgName := viper.GetString("org-name"); orgName != "" {
+		params["org_name"] = orgName
+	}
+	if repoName := config.RepoName(); repoName != "" {
+		params["repo_name"] = repoName
+	}
+	if branch := viper.GetString("branch"); branch != "" {
+		params["branch"] = branch
+	}
+	if pullRequestNumber := viper.GetString("pull-request-number"); pullRequestNumber != "" {
+		params["pull_request_number"] = pullRequestNumber
+	}
+	if deleted := viper.GetBool("deleted"); deleted {
+		params["deleted"] = "true"
+	}
+	if page := viper.GetInt("page"); page != 0 {
+		params["page"] = strconv.Itoa(page)
+	}
+	if pageSize := viper.GetInt("page-size"); pageSize != 0 {
+		params["page_size"] = strconv.Itoa(pageSize)
+	}
 	if org := c.OrgLookupFn(); org != "" {
 		params["org"] = org
 	}
 
+	body, err := c.Requester.Do(ctx, http.MethodGet, uri.CreateResourceURI("", "environment", "", "", params), "application/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	return types.UnmarshalManyEnvs(body)
+}
+
+func handleGetEnvironmentByID(ctx context.Context, c client.Client, id string) error {
 	// Start spinner
 	spinner := display.NewSpinner("Fetching info please standby...")
 	spinner.Start()
 
-	body, err := c.Requester.Do(http.MethodGet, uri.CreateResourceURI("", "environment", id, "", params), "application/json", nil)
-	
+	r, err := getEnvironment(ctx, c, id)
+
 	// Stop spinner immediately after API call
 	spinner.Stop()
-	
+
 	if err != nil {
 		return err
 	}
+	return display.PrintResource(environmentsResource([]types.Environment{r.Data}, r))
+}
 
-	if viper.GetBool("json") {
-		display.Println(body)
-		return nil
+func getEnvironment(ctx context.Context, c client.Client, id string) (*types.Response, error) {
+	params := make(map[string]string)
+	if org := c.OrgLookupFn(); org != "" {
+		params["org"] = org
 	}
 
-	r, err := types.UnmarshalEnv(body)
+	body, err := c.Requester.Do(ctx, http.MethodGet, uri.CreateResourceURI("", "environment", id, "", params), "application/json", nil)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return types.UnmarshalEnv(body)
+}
+
+// environmentsResource lays out environments for every output format.
+// Environments with several projects take up one table row per project.
+func environmentsResource(envs []types.Environment, object any) display.Resource {
+	duplicateColors := display.GenerateDuplicateColors(display.GetDuplicateUUIDs(envs))
+
+	var rows [][]string
+	names := make([]string, 0, len(envs))
+	for i := range envs {
+		services := make([]string, 0, len(envs[i].Attributes.Services))
+		for _, svc := range envs[i].Attributes.Services {
+			services = append(services, svc.Name)
+		}
+		formatted := display.FormattedEnvironmentWithDuplicateColors(&envs[i], duplicateColors)
+		for j, p := range envs[i].Attributes.Projects {
+			rows = append(rows, append(formatted[j], p.Branch, strings.Join(services, ",")))
+		}
+		names = append(names, envs[i].ID)
 	}
 
-	var data [][]string
-	data = append(data, display.FormattedEnvironment(&r.Data)...)
-	columns := []string{"App", "UUID", "Ready", "Repo", "PR#", "URL"}
-	display.RenderTable(os.Stdout, columns, data)
-	return nil
+	return display.Resource{
+		Object:      object,
+		Columns:     []string{"App", "UUID", "Ready", "Repo", "PR#", "URL"},
+		WideColumns: []string{"Branch", "Services"},
+		Rows:        rows,
+		Names:       names,
+	}
 }
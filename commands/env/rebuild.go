@@ -1,11 +1,12 @@
 package env
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/shipyard/shipyard-cli/constants"
 	"github.com/shipyard/shipyard-cli/pkg/client"
-	"github.com/shipyard/shipyard-cli/pkg/display"
+	"github.com/shipyard/shipyard-cli/pkg/completion"
 	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
 	"github.com/spf13/cobra"
 )
@@ -34,30 +35,35 @@ func newRebuildEnvironmentCmd(c client.Client) *cobra.Command {
 		Long: `This command rebuilds an environment. You can only rebuild a non-deleted environment.
 Rebuild will automatically fetch the latest commit for the branch/PR.`,
 		Example: `  # Rebuild environment ID 12345
-  shipyard rebuild environment 12345`,
+  shipyard rebuild environment 12345
+
+  # Rebuild every environment of pull request 42
+  shipyard rebuild environment --pull-request-number 42`,
 		SilenceUsage: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			bindBulkFlags(cmd)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) > 0 {
-				return rebuildEnvironmentByID(c, args[0])
-			}
-			return errNoEnvironment
+			return runEnvironmentAction(cmd.Context(), c, args, rebuildAction, rebuildEnvironmentByID)
 		},
+		ValidArgsFunction: completion.New(c).EnvironmentArg,
 	}
 
+	addBulkFlags(cmd)
+
 	return cmd
 }
 
-func rebuildEnvironmentByID(c client.Client, id string) error {
+func rebuildEnvironmentByID(ctx context.Context, c client.Client, id string) error {
 	params := make(map[string]string)
 	if org := c.OrgLookupFn(); org != "" {
 		params["org"] = org
 	}
 
-	_, err := c.Requester.Do(http.MethodPost, uri.CreateResourceURI("rebuild", "environment", id, "", params), "application/json", nil)
+	_, err := c.Requester.Do(ctx, http.MethodPost, uri.CreateResourceURI("rebuild", "environment", id, "", params), "application/json", nil)
 	if err != nil {
 		return err
 	}
 
-	display.Println("Environment queued for a rebuild.")
-	return nil
+	return rebuildAction.printResult("Environment queued for a rebuild.", id)
 }
@@ -1,11 +1,12 @@
 package env
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/shipyard/shipyard-cli/constants"
 	"github.com/shipyard/shipyard-cli/pkg/client"
-	"github.com/shipyard/shipyard-cli/pkg/display"
+	"github.com/shipyard/shipyard-cli/pkg/completion"
 	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
 	"github.com/spf13/cobra"
 )
@@ -32,29 +33,34 @@ func newRestartEnvironmentCmd(c client.Client) *cobra.Command {
 		SilenceUsage: true,
 		Short:        "Restart a stopped environment",
 		Example: `  # Restart environment ID 12345
-  shipyard restart environment 12345`,
+  shipyard restart environment 12345
+
+  # Restart every environment of the flask-backend repo without a prompt
+  shipyard restart environment --repo-name flask-backend --yes`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			bindBulkFlags(cmd)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) > 0 {
-				return restartEnvironmentByID(c, args[0])
-			}
-			return errNoEnvironment
+			return runEnvironmentAction(cmd.Context(), c, args, restartAction, restartEnvironmentByID)
 		},
+		ValidArgsFunction: completion.New(c).EnvironmentArg,
 	}
 
+	addBulkFlags(cmd)
+
 	return cmd
 }
 
-func restartEnvironmentByID(c client.Client, id string) error {
+func restartEnvironmentByID(ctx context.Context, c client.Client, id string) error {
 	params := make(map[string]string)
 	if org := c.OrgLookupFn(); org != "" {
 		params["org"] = org
 	}
 
-	_, err := c.Requester.Do(http.MethodPost, uri.CreateResourceURI("restart", "environment", id, "", params), "application/json", nil)
+	_, err := c.Requester.Do(ctx, http.MethodPost, uri.CreateResourceURI("restart", "environment", id, "", params), "application/json", nil)
 	if err != nil {
 		return err
 	}
 
-	display.Println("Environment queued for a restart.")
-	return nil
+	return restartAction.printResult("Environment queued for a restart.", id)
 }
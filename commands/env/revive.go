@@ -1,11 +1,11 @@
 package env
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/shipyard/shipyard-cli/constants"
 	"github.com/shipyard/shipyard-cli/pkg/client"
-	"github.com/shipyard/shipyard-cli/pkg/display"
 	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
 	"github.com/spf13/cobra"
 )
@@ -33,30 +33,34 @@ func newReviveEnvironmentCmd(c client.Client) *cobra.Command {
 		Use:     "environment [environment ID]",
 		Short:   "Revive a deleted environment",
 		Example: `  # Revive environment ID 12345
-  shipyard revive environment 12345`,
+  shipyard revive environment 12345
+
+  # Revive every deleted environment of the flask-backend repo
+  shipyard revive environment --repo-name flask-backend`,
 		SilenceUsage: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			bindBulkFlags(cmd)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) > 0 {
-				return reviveEnvironmentByID(c, args[0])
-			}
-			return errNoEnvironment
+			return runEnvironmentAction(cmd.Context(), c, args, reviveAction, reviveEnvironmentByID)
 		},
 	}
 
+	addBulkFlags(cmd)
+
 	return cmd
 }
 
-func reviveEnvironmentByID(c client.Client, id string) error {
+func reviveEnvironmentByID(ctx context.Context, c client.Client, id string) error {
 	params := make(map[string]string)
 	if org := c.OrgLookupFn(); org != "" {
 		params["org"] = org
 	}
 
-	_, err := c.Requester.Do(http.MethodPost, uri.CreateResourceURI("revive", "environment", id, "", params), "application/json", nil)
+	_, err := c.Requester.Do(ctx, http.MethodPost, uri.CreateResourceURI("revive", "environment", id, "", params), "application/json", nil)
 	if err != nil {
 		return err
 	}
 
-	display.Println("Environment revived.")
-	return nil
+	return reviveAction.printResult("Environment revived.", id)
 }
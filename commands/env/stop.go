@@ -1,11 +1,12 @@
 package env
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/shipyard/shipyard-cli/constants"
 	"github.com/shipyard/shipyard-cli/pkg/client"
-	"github.com/shipyard/shipyard-cli/pkg/display"
+	"github.com/shipyard/shipyard-cli/pkg/completion"
 	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
 	"github.com/spf13/cobra"
 )
@@ -32,30 +33,38 @@ func newStopEnvironmentCmd(c client.Client) *cobra.Command {
 		Short:   "Stop a running environment",
 		Long:    `This command stops a running environment. You can ONLY stop an environment if it is currently running.`,
 		Example: `  # Stop environment ID 12345
-  shipyard stop environment 12345`,
+  shipyard stop environment 12345
+
+  # Stop every environment of the flask-backend repo
+  shipyard stop environment --repo-name flask-backend
+
+  # Preview which environments of the main branch would be stopped, across all pages
+  shipyard stop environment --branch main --all-pages --dry-run`,
 		SilenceUsage: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			bindBulkFlags(cmd)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) > 0 {
-				return stopEnvironmentByID(c, args[0])
-			}
-			return errNoEnvironment
+			return runEnvironmentAction(cmd.Context(), c, args, stopAction, stopEnvironmentByID)
 		},
+		ValidArgsFunction: completion.New(c).EnvironmentArg,
 	}
 
+	addBulkFlags(cmd)
+
 	return cmd
 }
 
-func stopEnvironmentByID(c client.Client, id string) error {
+func stopEnvironmentByID(ctx context.Context, c client.Client, id string) error {
 	params := make(map[string]string)
 	if org := c.OrgLookupFn(); org != "" {
 		params["org"] = org
 	}
 
-	_, err := c.Requester.Do(http.MethodPost, uri.CreateResourceURI("stop", "environment", id, "", params), "application/json", nil)
+	_, err := c.Requester.Do(ctx, http.MethodPost, uri.CreateResourceURI("stop", "environment", id, "", params), "application/json", nil)
 	if err != nil {
 		return err
 	}
 
-	display.Println("Environment stopped.")
-	return nil
+	return stopAction.printResult("Environment stopped.", id)
 }
@@ -1,11 +1,14 @@
 package env
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/pkg/browser"
 	"github.com/shipyard/shipyard-cli/constants"
 	"github.com/shipyard/shipyard-cli/pkg/client"
+	"github.com/shipyard/shipyard-cli/pkg/completion"
+	"github.com/shipyard/shipyard-cli/pkg/resolve"
 	"github.com/spf13/cobra"
 )
 
@@ -17,20 +20,29 @@ func NewVisitCmd(c client.Client) *cobra.Command {
 		Short:        "Visit an environment's latest build",
 		Long:         `This command opens a web browser to let users visit a given environment`,
 		Example: `  # Visit the current build for environment ID 12345
-  shipyard visit 12345`,
+  shipyard visit 12345
+
+  # Visit the environment of the current git repo and branch
+  shipyard visit`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			var id string
 			if len(args) > 0 {
-				return visitEnvironment(c, args[0])
+				id = args[0]
+			}
+			id, err := resolve.EnvironmentID(cmd.Context(), c, id)
+			if err != nil {
+				return err
 			}
-			return errNoEnvironment
+			return visitEnvironment(cmd.Context(), c, id)
 		},
+		ValidArgsFunction: completion.New(c).EnvironmentArg,
 	}
 
 	return cmd
 }
 
-func visitEnvironment(c client.Client, id string) error {
-	e, err := c.EnvByID(id)
+func visitEnvironment(ctx context.Context, c client.Client, id string) error {
+	e, err := c.EnvByID(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,41 @@
+package env
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/viper"
+
+	"github.com/shipyard/shipyard-cli/pkg/display"
+	"github.com/shipyard/shipyard-cli/pkg/types"
+)
+
+// watchEnvironments polls the environments that list returns every --interval.
+// Rows are highlighted when an environment becomes ready, stops or gets retired.
+func watchEnvironments(ctx context.Context, title string, list func(context.Context) ([]types.Environment, error)) error {
+	return display.Watch(ctx, title, viper.GetDuration("interval"), func(ctx context.Context) (display.WatchFrame, error) {
+		envs, err := list(ctx)
+		if err != nil {
+			return display.WatchFrame{}, err
+		}
+
+		res := environmentsResource(envs, types.RespManyEnvs{Data: envs})
+		if len(envs) == 0 {
+			res.Message = "No environments found in the org."
+		}
+		frame := display.WatchFrame{Resource: res, Items: make([]display.WatchItem, 0, len(envs))}
+		for i := range envs {
+			attrs := envs[i].Attributes
+			frame.Items = append(frame.Items, display.WatchItem{
+				ID:     envs[i].ID,
+				State:  fmt.Sprintf("ready=%t stopped=%t retired=%t", attrs.Ready, attrs.Stopped, attrs.Retired),
+				Object: envs[i],
+			})
+			// environmentsResource makes a row for every project.
+			for range attrs.Projects {
+				frame.RowIDs = append(frame.RowIDs, envs[i].ID)
+			}
+		}
+		return frame, nil
+	})
+}
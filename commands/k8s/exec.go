@@ -1,6 +1,7 @@
 package k8s
 
 import (
+	"context"
 	"errors"
 
 	"github.com/spf13/cobra"
@@ -9,6 +10,7 @@ import (
 	"github.com/shipyard/shipyard-cli/constants"
 	"github.com/shipyard/shipyard-cli/pkg/client"
 	"github.com/shipyard/shipyard-cli/pkg/k8s"
+	"github.com/shipyard/shipyard-cli/pkg/resolve"
 )
 
 func NewExecCmd(c client.Client) *cobra.Command {
@@ -29,32 +31,33 @@ shipyard exec --env 123 --service web -- bash`,
 			_ = viper.BindPFlag("env", cmd.Flags().Lookup("env"))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return handleExecCmd(c, args)
+			return handleExecCmd(cmd.Context(), c, args)
 		},
 	}
 
-	cmd.Flags().String("service", "", "Service name")
-	_ = cmd.MarkFlagRequired("service")
+	cmd.Flags().String("service", "", "Service name, the 'service' config value or picked interactively if omitted")
 
-	cmd.Flags().String("env", "", "Environment ID")
-	_ = cmd.MarkFlagRequired("env")
+	cmd.Flags().String("env", "", "Environment ID, inferred from the git checkout or picked interactively if omitted")
 
 	return cmd
 }
 
-func handleExecCmd(c client.Client, args []string) error {
+func handleExecCmd(ctx context.Context, c client.Client, args []string) error {
 	if len(args) == 0 {
 		return errors.New("no command arguments provided")
 	}
 
 	serviceName := viper.GetString("service")
-	id := viper.GetString("env")
-	svc, err := c.FindService(serviceName, id)
+	id, err := resolve.EnvironmentID(ctx, c, viper.GetString("env"))
+	if err != nil {
+		return err
+	}
+	svc, err := resolve.Service(ctx, c, id, serviceName)
 	if err != nil {
 		return err
 	}
 
-	k, err := k8s.New(c, id, svc)
+	k, err := k8s.New(ctx, c, id, svc)
 	if err != nil {
 		return err
 	}
@@ -1,12 +1,15 @@
 package k8s
 
 import (
+	"context"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/shipyard/shipyard-cli/constants"
 	"github.com/shipyard/shipyard-cli/pkg/client"
 	"github.com/shipyard/shipyard-cli/pkg/k8s"
+	"github.com/shipyard/shipyard-cli/pkg/resolve"
 )
 
 func NewLogsCmd(c client.Client) *cobra.Command {
@@ -31,15 +34,13 @@ func NewLogsCmd(c client.Client) *cobra.Command {
 			_ = viper.BindPFlag("tail", cmd.Flags().Lookup("tail"))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return handleLogsCmd(c)
+			return handleLogsCmd(cmd.Context(), c)
 		},
 	}
 
-	cmd.Flags().String("service", "", "Service name")
-	_ = cmd.MarkFlagRequired("service")
+	cmd.Flags().String("service", "", "Service name, the 'service' config value or picked interactively if omitted")
 
-	cmd.Flags().String("env", "", "Environment ID")
-	_ = cmd.MarkFlagRequired("env")
+	cmd.Flags().String("env", "", "Environment ID, inferred from the git checkout or picked interactively if omitted")
 
 	cmd.Flags().BoolP("follow", "f", false, "Follow the log output")
 	cmd.Flags().Int64("tail", 3000, "Number of lines from the end of the logs to show")
@@ -47,16 +48,19 @@ func NewLogsCmd(c client.Client) *cobra.Command {
 	return cmd
 }
 
-func handleLogsCmd(c client.Client) error {
+func handleLogsCmd(ctx context.Context, c client.Client) error {
 	serviceName := viper.GetString("service")
-	id := viper.GetString("env")
+	id, err := resolve.EnvironmentID(ctx, c, viper.GetString("env"))
+	if err != nil {
+		return err
+	}
 
-	svc, err := c.FindService(serviceName, id)
+	svc, err := resolve.Service(ctx, c, id, serviceName)
 	if err != nil {
 		return err
 	}
 
-	k, err := k8s.New(c, id, svc)
+	k, err := k8s.New(ctx, c, id, svc)
 	if err != nil {
 		return err
 	}
@@ -64,5 +68,5 @@ func handleLogsCmd(c client.Client) error {
 	follow := viper.GetBool("follow")
 	tail := viper.GetInt64("tail")
 
-	return k.Logs(follow, tail)
+	return k.Logs(ctx, follow, tail)
 }
@@ -1,12 +1,15 @@
 package k8s
 
 import (
+	"context"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/shipyard/shipyard-cli/constants"
 	"github.com/shipyard/shipyard-cli/pkg/client"
 	"github.com/shipyard/shipyard-cli/pkg/k8s"
+	"github.com/shipyard/shipyard-cli/pkg/resolve"
 )
 
 func NewPortForwardCmd(c client.Client) *cobra.Command {
@@ -27,33 +30,34 @@ func NewPortForwardCmd(c client.Client) *cobra.Command {
 			_ = viper.BindPFlag("env", cmd.Flags().Lookup("env"))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return handlePortForwardCmd(c)
+			return handlePortForwardCmd(cmd.Context(), c)
 		},
 	}
 
 	cmd.Flags().StringSlice("ports", nil, "Ports (for example, 3000:80)")
 	_ = cmd.MarkFlagRequired("ports")
 
-	cmd.Flags().String("service", "", "Service name")
-	_ = cmd.MarkFlagRequired("service")
+	cmd.Flags().String("service", "", "Service name, the 'service' config value or picked interactively if omitted")
 
-	cmd.Flags().String("env", "", "Environment ID")
-	_ = cmd.MarkFlagRequired("env")
+	cmd.Flags().String("env", "", "Environment ID, inferred from the git checkout or picked interactively if omitted")
 
 	return cmd
 }
 
-func handlePortForwardCmd(c client.Client) error {
-	id := viper.GetString("env")
+func handlePortForwardCmd(ctx context.Context, c client.Client) error {
+	id, err := resolve.EnvironmentID(ctx, c, viper.GetString("env"))
+	if err != nil {
+		return err
+	}
 	serviceName := viper.GetString("service")
 	ports := viper.GetStringSlice("ports")
 
-	s, err := c.FindService(serviceName, id)
+	s, err := resolve.Service(ctx, c, id, serviceName)
 	if err != nil {
 		return err
 	}
 
-	k, err := k8s.New(c, id, s)
+	k, err := k8s.New(ctx, c, id, s)
 	if err != nil {
 		return err
 	}
@@ -1,90 +1,153 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
-	"net"
-	"net/http"
-	"strconv"
+	"os"
+	"os/signal"
 	"time"
 
 	"github.com/pkg/browser"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/shipyard/shipyard-cli/auth"
+	"github.com/shipyard/shipyard-cli/pkg/client"
 	"github.com/shipyard/shipyard-cli/pkg/display"
+	"github.com/shipyard/shipyard-cli/pkg/requests"
+	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
+	"github.com/shipyard/shipyard-cli/pkg/types"
 )
 
-func NewLoginCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:          "login",
-		Short:        "Log in to the CLI",
-		Long:         "This command opens a web browser, prompts you to log in to Shipyard, and saves a new API token in your local config file",
+// loginTimeout is how long the browser has to come back with a token.
+const loginTimeout = 5 * time.Minute
+
+func NewLoginCmd(c client.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Log in to the CLI",
+		Long: `This command opens a web browser, prompts you to log in to Shipyard, and saves a new API token. A saved token that the API no longer accepts is replaced.
+
+The browser is sent back to a server the CLI runs on 127.0.0.1, so it must run on the same machine.
+On a remote machine or in a container, use --device: it shows a code to enter in a browser anywhere.
+The login page is served by the Shipyard instance of the current mode (see 'shipyard mode').`,
+		Example: `  shipyard login
+
+  # Print the login URL instead of opening a browser
+  shipyard login --no-browser
+
+  # Log in from a machine without a browser
+  shipyard login --device`,
+		Args:         cobra.NoArgs,
 		SilenceUsage: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			_ = viper.BindPFlag("no-browser", cmd.Flags().Lookup("no-browser"))
+			_ = viper.BindPFlag("device", cmd.Flags().Lookup("device"))
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return login()
+			return login(cmd.Context(), c, viper.GetBool("no-browser"), viper.GetBool("device"))
 		},
 	}
+
+	cmd.Flags().Bool("no-browser", false, "Print the login URL instead of opening a web browser")
+	cmd.Flags().Bool("device", false, "Log in by entering a code in a browser on another device")
+	cmd.MarkFlagsMutuallyExclusive("no-browser", "device")
+
+	return cmd
 }
 
-func login() error {
+func login(ctx context.Context, c client.Client, noBrowser, device bool) error {
 	if _, err := auth.APIToken(); err == nil {
-		display.Println("You are already logged in.")
-		return nil
+		user, err := c.CurrentUser(ctx)
+		switch {
+		case err == nil:
+			display.Println(fmt.Sprintf("You are already logged in%s.", loggedInAs(user)))
+			return nil
+		case requests.IsUnauthorized(err):
+			// A new token could not take the place of the environment variable.
+			if os.Getenv("SHIPYARD_API_TOKEN") != "" {
+				return errors.New("the token in SHIPYARD_API_TOKEN is not valid, unset it or replace it")
+			}
+			display.Println("The saved token is no longer valid, logging in again.")
+		default:
+			return fmt.Errorf("failed to check the saved token: %w", err)
+		}
 	}
 
-	tokenChan := make(chan string)
-	errChan := make(chan error)
-	mux := http.NewServeMux()
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		t := r.URL.Query().Get("token")
-		if t == "" {
-			errChan <- fmt.Errorf("no token received from Shipyard")
-			return
-		}
-		tokenChan <- t
-		_, _ = fmt.Fprintln(w, "Authentication succeeded. You may close this browser tab.")
-	})
-	mux.Handle("/", handler)
+	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt)
+	defer cancel()
 
-	//nolint:gosec //local only
-	listener, err := net.Listen("tcp", ":0")
+	var token string
+	var err error
+	if device {
+		token, err = deviceLogin(ctx)
+	} else {
+		token, err = browserLogin(ctx, noBrowser)
+	}
 	if err != nil {
-		return fmt.Errorf("error creating a local callback server: %w", err)
+		return fmt.Errorf("login error: %w", err)
 	}
-	_ = listener.Close()
-	port := listener.Addr().(*net.TCPAddr).Port
-	server := &http.Server{
-		Addr:              net.JoinHostPort("localhost", strconv.Itoa(port)),
-		Handler:           mux,
-		ReadHeaderTimeout: 3 * time.Second,
+	if err := SetToken(token); err != nil {
+		return err
 	}
+	display.Println("Login succeeded!")
+	return nil
+}
 
-	go func() {
-		log.Printf("Trying to start a local callback server on %s.", server.Addr)
-		if err := server.ListenAndServe(); err != nil {
-			display.Fail(err)
-			errChan <- err
-			return
-		}
-		log.Println("Shutting down the local server.")
-	}()
+// browserLogin opens the login page and waits for the browser to come back with a token.
+func browserLogin(ctx context.Context, noBrowser bool) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, loginTimeout)
+	defer cancel()
 
-	display.Println("Opening the default web browser...")
-	backendURL := fmt.Sprintf("https://shipyard.build/api/me/user-token/cli?callbackUrl=http://%s", server.Addr)
-	if err := browser.OpenURL(backendURL); err != nil {
-		return err
+	callback, err := auth.NewCallbackServer()
+	if err != nil {
+		return "", err
 	}
-	select {
-	case <-time.After(time.Minute):
-		return fmt.Errorf("authentication timeout")
-	case err := <-errChan:
-		return fmt.Errorf("login error: %w", err)
-	case t := <-tokenChan:
-		if err := SetToken(t, ""); err != nil {
-			return err
+	defer func() { _ = callback.Close() }()
+
+	loginURL, err := auth.LoginURL(uri.BaseURL(), callback.URL(), callback.State())
+	if err != nil {
+		return "", err
+	}
+	if noBrowser {
+		display.Println("Open this URL in a web browser on this machine to log in:")
+		display.Println(loginURL)
+	} else {
+		display.Println("Opening the default web browser...")
+		if err := browser.OpenURL(loginURL); err != nil {
+			display.Println("Could not open a web browser, open this URL to log in:")
+			display.Println(loginURL)
 		}
-		display.Println("Login succeeded!")
 	}
-	return nil
+
+	return callback.Wait(ctx)
+}
+
+// deviceLogin shows a code for the user to enter in a browser, on any device, and waits for the approval.
+// The code expires when the API says it does.
+func deviceLogin(ctx context.Context) (string, error) {
+	code, err := auth.RequestDeviceCode(ctx, uri.BaseURL())
+	if err != nil {
+		return "", err
+	}
+	display.Println(fmt.Sprintf("To log in, open %s in a browser and enter the code %s", code.VerificationURI, code.UserCode))
+	if code.VerificationURIComplete != "" {
+		display.Println(fmt.Sprintf("or open %s", code.VerificationURIComplete))
+	}
+	display.Println("Waiting for the login to be approved...")
+	return auth.PollDeviceToken(ctx, uri.BaseURL(), code)
+}
+
+// loggedInAs names the user of a token, if it belongs to one rather than to an org.
+func loggedInAs(user *types.UserAttributes) string {
+	switch {
+	case user.Email != "":
+		return " as " + user.Email
+	case user.Name != "":
+		return " as " + user.Name
+	case user.DefaultOrg != "":
+		return " with a token of the " + user.DefaultOrg + " org"
+	}
+	return ""
 }
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/shipyard/shipyard-cli/auth"
+	"github.com/shipyard/shipyard-cli/pkg/display"
+	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
+)
+
+func NewLogoutCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "Log out of the CLI",
+		Long: `Remove the API token of the active profile from the config file and the credential store.
+The tokens of other profiles are kept.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return logout()
+		},
+	}
+}
+
+func logout() error {
+	loggedIn, err := auth.DeleteToken(auth.CurrentAccount())
+	if err != nil {
+		return err
+	}
+
+	if loggedIn {
+		display.Println(fmt.Sprintf("Logged out of %s.", uri.BaseURL()))
+	} else {
+		display.Println("You are not logged in.")
+	}
+	if os.Getenv("SHIPYARD_API_TOKEN") != "" {
+		_, _ = fmt.Fprintln(os.Stderr, "SHIPYARD_API_TOKEN is still set, so its token keeps being used until you unset it.")
+	}
+	return nil
+}
@@ -3,11 +3,13 @@ package commands
 import (
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/shipyard/shipyard-cli/pkg/client"
 	"github.com/shipyard/shipyard-cli/pkg/mcp/server"
@@ -35,7 +37,15 @@ to interact with Shipyard environments through standardized tools.`,
 		Example: `  # Start MCP server with stdio transport (default)
   shipyard mcp serve
 
+  # Serve a team over Streamable HTTP on http://0.0.0.0:8080/mcp,
+  # with the legacy SSE endpoint on /sse for older clients
+  shipyard mcp serve --transport http --host 0.0.0.0 --port 8080
 `,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			_ = viper.BindPFlag("mcp.transport", cmd.Flags().Lookup("transport"))
+			_ = viper.BindPFlag("mcp.host", cmd.Flags().Lookup("host"))
+			_ = viper.BindPFlag("mcp.port", cmd.Flags().Lookup("port"))
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// The --org flag is intentionally not supported for MCP commands because:
 			// 1. MCP servers are typically long-running processes that shouldn't change org context
@@ -48,9 +58,22 @@ to interact with Shipyard environments through standardized tools.`,
 		},
 	}
 
+	cmd.Flags().String("transport", "stdio", "Transport: stdio, or http to serve several clients")
+	cmd.Flags().String("host", "127.0.0.1", "Address the http transport listens on")
+	cmd.Flags().Int("port", 8080, "Port the http transport listens on")
+
 	return cmd
 }
 
+// isLoopback reports whether a host only takes connections from this machine.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 // runMCPServe starts the MCP server
 func runMCPServe(c client.Client) error {
 	// Load MCP server configuration
@@ -63,6 +86,13 @@ func runMCPServe(c client.Client) error {
 	if err := mcpServer.Start(); err != nil {
 		return fmt.Errorf("failed to start MCP server: %w", err)
 	}
+	if config.Transport == "http" {
+		// Stdout is left alone, like with the stdio transport.
+		_, _ = fmt.Fprintf(os.Stderr, "MCP server listening on http://%s/mcp\n", mcpServer.Addr())
+		if !config.Auth.Enabled() && !isLoopback(config.Host) {
+			_, _ = fmt.Fprintln(os.Stderr, "Warning: no bearer tokens are set in mcp.auth, anyone who reaches the server can act with your Shipyard token")
+		}
+	}
 
 	// Setup graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -1,6 +1,7 @@
 package org
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strings"
@@ -26,11 +27,11 @@ Note that this command requires a user-level access token.`,
 			_ = viper.BindPFlag("json", cmd.Flags().Lookup("json"))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return getAllOrgs(c)
+			return getAllOrgs(cmd.Context(), c)
 		},
 	}
 
-	cmd.Flags().Bool("json", false, "JSON output")
+	cmd.Flags().Bool("json", false, "JSON output, same as -o json")
 
 	return cmd
 }
@@ -56,31 +57,41 @@ func getCurrentOrg() error {
 	if org == "" {
 		return errors.New("no org is found in the config")
 	}
-	display.Println(org)
-	return nil
+	return display.PrintResource(display.Resource{
+		Object: struct {
+			Org string `json:"org"`
+		}{Org: org},
+		Columns: []string{"Name"},
+		Rows:    [][]string{{org}},
+		Names:   []string{org},
+		Message: org,
+	})
 }
 
-func getAllOrgs(c client.Client) error {
-	body, err := c.Requester.Do(http.MethodGet, uri.CreateResourceURI("", "org", "", "", nil), "application/json", nil)
+func getAllOrgs(ctx context.Context, c client.Client) error {
+	body, err := c.Requester.Do(ctx, http.MethodGet, uri.CreateResourceURI("", "org", "", "", nil), "application/json", nil)
 	if err != nil {
 		return err
 	}
 
-	if viper.GetBool("json") {
-		display.Println(body)
-		return nil
-	}
-
 	orgs, err := types.UnmarshalOrgs(body)
 	if err != nil {
 		return err
 	}
 
 	names := make([]string, 0, len(orgs.Data))
+	rows := make([][]string, 0, len(orgs.Data))
 	for _, item := range orgs.Data {
 		names = append(names, item.Attributes.Name)
+		rows = append(rows, []string{item.Attributes.Name})
 	}
 
-	display.Println(strings.Join(names, "\n"))
-	return nil
+	return display.PrintResource(display.Resource{
+		Object:  orgs,
+		Columns: []string{"Name"},
+		Rows:    rows,
+		Names:   names,
+		// Org names have always been listed one per line rather than in a table.
+		Message: strings.Join(names, "\n"),
+	})
 }
@@ -0,0 +1,334 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/shipyard/shipyard-cli/auth"
+	"github.com/shipyard/shipyard-cli/config"
+	"github.com/shipyard/shipyard-cli/pkg/completion"
+	"github.com/shipyard/shipyard-cli/pkg/display"
+)
+
+func NewProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage profiles of API URL, token and org",
+		Long: `A profile holds an API URL, a token, a default org and the verbose setting.
+The settings at the top level of the config make up the default profile.
+The local, qa and prod profiles are built in, and can be changed by adding them.
+
+The active profile is selected with 'shipyard profile use', and can be overridden for one command
+with --profile or the SHIPYARD_PROFILE environment variable. 'shipyard login', 'shipyard logout'
+and 'shipyard set token' work on the token of the active profile.`,
+		Example: `  # Add a profile for a staging API and log in to it
+  shipyard profile add staging --api-url https://staging.example.com/api/v1 --org acme
+  shipyard profile use staging
+  shipyard login
+
+  # Run a command with another profile
+  shipyard --profile prod get environments
+
+  # Go back to the default profile
+  shipyard profile use default`,
+	}
+
+	cmd.AddCommand(newProfileListCmd())
+	cmd.AddCommand(newProfileUseCmd())
+	cmd.AddCommand(newProfileAddCmd())
+	cmd.AddCommand(newProfileRemoveCmd())
+	cmd.AddCommand(newProfileShowCmd())
+
+	return cmd
+}
+
+func newProfileListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "list",
+		Aliases:      []string{"ls"},
+		Short:        "List the profiles",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listProfiles()
+		},
+	}
+
+	return cmd
+}
+
+func newProfileUseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "use [profile]",
+		Short:             "Switch to a profile",
+		Example:           `  shipyard profile use qa`,
+		Args:              cobra.ExactArgs(1),
+		SilenceUsage:      true,
+		ValidArgsFunction: completion.ProfileArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return useProfile(strings.ToLower(args[0]))
+		},
+	}
+
+	return cmd
+}
+
+func newProfileAddCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add [profile]",
+		Short: "Add a profile",
+		Long: `Add a profile with the API URL it talks to. The API URL can be left out for the built-in
+local, qa and prod profiles. Save its token with 'shipyard login' once the profile is in use.`,
+		Example:      `  shipyard profile add staging --api-url https://staging.example.com/api/v1 --org acme`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			_ = viper.BindPFlag("api-url", cmd.Flags().Lookup("api-url"))
+			_ = viper.BindPFlag("profile-org", cmd.Flags().Lookup("org"))
+			_ = viper.BindPFlag("profile-verbose", cmd.Flags().Lookup("verbose"))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return addProfile(strings.ToLower(args[0]), config.Profile{
+				APIURL:  viper.GetString("api-url"),
+				Org:     viper.GetString("profile-org"),
+				Verbose: viper.GetBool("profile-verbose"),
+			})
+		},
+	}
+
+	cmd.Flags().String("api-url", "", "API URL of the profile, e.g. https://shipyard.example.com/api/v1")
+	cmd.Flags().String("org", "", "Default org of the profile")
+	cmd.Flags().Bool("verbose", false, "Turn on verbose output for the profile")
+
+	return cmd
+}
+
+func newProfileRemoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "rm [profile]",
+		Aliases:           []string{"remove", "delete"},
+		Short:             "Remove a profile and its token",
+		Example:           `  shipyard profile rm staging`,
+		Args:              cobra.ExactArgs(1),
+		SilenceUsage:      true,
+		ValidArgsFunction: completion.ProfileArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return removeProfile(strings.ToLower(args[0]))
+		},
+	}
+
+	return cmd
+}
+
+func newProfileShowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "show [profile]",
+		Short:             "Show the settings of a profile, the active one by default",
+		Example:           `  shipyard profile show qa`,
+		Args:              cobra.MaximumNArgs(1),
+		SilenceUsage:      true,
+		ValidArgsFunction: completion.ProfileArg,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := activeProfileName()
+			if len(args) > 0 {
+				name = strings.ToLower(args[0])
+			}
+			return showProfile(name)
+		},
+	}
+
+	return cmd
+}
+
+type profileInfo struct {
+	Name    string `json:"name"`
+	APIURL  string `json:"api_url"`
+	Org     string `json:"org,omitempty"`
+	Verbose bool   `json:"verbose"`
+	Active  bool   `json:"active"`
+}
+
+// activeProfileName returns the name of the active profile, including the default one.
+func activeProfileName() string {
+	if name := config.ActiveProfile(); name != "" {
+		return name
+	}
+	return config.DefaultProfile
+}
+
+func lookupProfile(name string) (profileInfo, bool) {
+	p, ok := config.TopLevel(), true
+	if name != config.DefaultProfile {
+		p, ok = config.LoadProfile(name)
+	}
+	return profileInfo{
+		Name:    name,
+		APIURL:  p.APIURL,
+		Org:     p.Org,
+		Verbose: p.Verbose,
+		Active:  name == activeProfileName(),
+	}, ok
+}
+
+func listProfiles() error {
+	names := append([]string{config.DefaultProfile}, config.ProfileNames()...)
+	profiles := make([]profileInfo, 0, len(names))
+	rows := make([][]string, 0, len(names))
+	for _, name := range names {
+		p, _ := lookupProfile(name)
+		active := ""
+		if p.Active {
+			active = "*"
+		}
+		profiles = append(profiles, p)
+		rows = append(rows, []string{active, p.Name, p.APIURL, p.Org})
+	}
+
+	return display.PrintResource(display.Resource{
+		Object:  profiles,
+		Columns: []string{"Active", "Name", "API URL", "Org"},
+		Rows:    rows,
+		Names:   names,
+	})
+}
+
+func showProfile(name string) error {
+	p, ok := lookupProfile(name)
+	if !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+
+	account := auth.TokenAccount(name)
+	token := "not saved, run 'shipyard login' with the profile in use"
+	if p.Active && os.Getenv("SHIPYARD_API_TOKEN") != "" {
+		token = "from SHIPYARD_API_TOKEN"
+	} else if _, ok := auth.AccountToken(account); ok {
+		token = "saved"
+	}
+
+	var b bytes.Buffer
+	fields := [][2]string{
+		{"Name", p.Name},
+		{"API URL", p.APIURL},
+		{"Org", p.Org},
+		{"Verbose", yesNo(p.Verbose)},
+		{"Token", token},
+		{"Active", yesNo(p.Active)},
+	}
+	for _, f := range fields {
+		if f[1] == "" {
+			continue
+		}
+		_, _ = fmt.Fprintf(&b, "%-9s%s\n", f[0]+":", f[1])
+	}
+
+	return display.PrintResource(display.Resource{
+		Object:  p,
+		Columns: []string{"Name", "API URL", "Org", "Verbose", "Active"},
+		Rows:    [][]string{{p.Name, p.APIURL, p.Org, yesNo(p.Verbose), yesNo(p.Active)}},
+		Names:   []string{p.Name},
+		Message: strings.TrimSuffix(b.String(), "\n"),
+	})
+}
+
+// useProfile makes a profile the active one in the config.
+func useProfile(name string) error {
+	if name != config.DefaultProfile {
+		if _, ok := config.LoadProfile(name); !ok {
+			return fmt.Errorf("profile %q not found, add it with 'shipyard profile add %s --api-url ...'", name, name)
+		}
+	}
+
+	err := config.Edit(viper.ConfigFileUsed(), func(cfg *config.Config) error {
+		if name == config.DefaultProfile {
+			cfg.Profile = ""
+		} else {
+			cfg.Profile = name
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	viper.Set("profile", name)
+
+	display.Println(fmt.Sprintf("Switched to profile %s.", name))
+	if _, ok := auth.AccountToken(auth.TokenAccount(config.ActiveProfile())); !ok {
+		display.Println("No token is saved for it yet, log in with 'shipyard login' or save one with 'shipyard set token'.")
+	}
+	return nil
+}
+
+func addProfile(name string, p config.Profile) error {
+	if err := config.ValidateProfileName(name); err != nil {
+		return err
+	}
+	if _, ok := viper.GetStringMap("profiles")[name]; ok {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+	if p.APIURL == "" {
+		if _, ok := config.ModeURLs[name]; !ok {
+			return errors.New("the API URL of the profile is missing, pass it with --api-url")
+		}
+	} else if u, err := url.Parse(p.APIURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid API URL %q, it should look like https://shipyard.example.com/api/v1", p.APIURL)
+	}
+
+	err := config.Edit(viper.ConfigFileUsed(), func(cfg *config.Config) error {
+		cfg.UpdateProfile(name, func(profile *config.Profile) {
+			profile.APIURL = p.APIURL
+			profile.Org = p.Org
+			profile.Verbose = p.Verbose
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	display.Println(fmt.Sprintf("Profile %s added. Switch to it with 'shipyard profile use %s', then log in with 'shipyard login'.", name, name))
+	return nil
+}
+
+// removeProfile removes a profile from the config, along with its token.
+// The default profile is in use again if it was the active one.
+func removeProfile(name string) error {
+	if _, ok := viper.GetStringMap("profiles")[name]; !ok {
+		if name == config.DefaultProfile || slices.Contains(config.ProfileNames(), name) {
+			return fmt.Errorf("profile %q is built in and cannot be removed", name)
+		}
+		return fmt.Errorf("profile %q not found", name)
+	}
+
+	if _, err := auth.DeleteToken(auth.ProfileAccount(name)); err != nil {
+		return err
+	}
+	err := config.Edit(viper.ConfigFileUsed(), func(cfg *config.Config) error {
+		delete(cfg.Profiles, name)
+		if cfg.Profile == name {
+			cfg.Profile = ""
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	display.Println(fmt.Sprintf("Profile %s removed.", name))
+	return nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
@@ -21,6 +21,8 @@ import (
 	"github.com/shipyard/shipyard-cli/constants"
 	"github.com/shipyard/shipyard-cli/logging"
 	"github.com/shipyard/shipyard-cli/pkg/client"
+	"github.com/shipyard/shipyard-cli/pkg/completion"
+	"github.com/shipyard/shipyard-cli/pkg/display"
 	"github.com/shipyard/shipyard-cli/pkg/requests"
 	"github.com/shipyard/shipyard-cli/version"
 )
@@ -31,18 +33,30 @@ var rootCmd = &cobra.Command{
 	Long:          `A tool to manage Ephemeral Environments on the Shipyard platform`,
 	Version:       fmt.Sprintf("%s (Git Commit %s)", version.Version, version.GitCommit),
 	SilenceErrors: true,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if configErr != nil && cmd.Annotations[allowBrokenConfig] == "" {
+			fail("Init", configErr)
+		}
 		logging.Register()
 		log.Println("Git commit:", version.GitCommit)
 		log.Println("Current config file:", viper.ConfigFileUsed())
+
+		// Reject an unknown output format before the command takes any action.
+		_, err := display.NewPrinter(viper.GetString("output"))
+		return err
 	},
 }
 
 var (
-	cfgFile        string
-	errConfigParse = errors.New("failed to parse the config file, check YAML for syntax errors")
+	cfgFile string
+	// configErr is why the config file could not be parsed. Commands fail with it,
+	// unless they have the allowBrokenConfig annotation.
+	configErr error
 )
 
+// allowBrokenConfig is the annotation of the commands that run with a config file that cannot be parsed.
+const allowBrokenConfig = "allow-broken-config"
+
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
@@ -65,6 +79,18 @@ func init() {
 	rootCmd.PersistentFlags().String("org", "", "Org of environment (default org if unspecified)")
 	_ = viper.BindPFlag("org", rootCmd.PersistentFlags().Lookup("org"))
 
+	rootCmd.PersistentFlags().String("profile", "", "Profile to use instead of the one selected with 'shipyard profile use'")
+	_ = viper.BindPFlag("profile", rootCmd.PersistentFlags().Lookup("profile"))
+
+	rootCmd.PersistentFlags().Bool("no-input", false, "Never prompt, fail when an environment or service is missing instead")
+	_ = viper.BindPFlag("no-input", rootCmd.PersistentFlags().Lookup("no-input"))
+
+	rootCmd.PersistentFlags().StringP("output", "o", "", "Output format: "+strings.Join(display.OutputFormats, "|")+" (default table)")
+	_ = viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output"))
+	_ = rootCmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return display.OutputFormats, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	})
+
 	setupCommands()
 }
 
@@ -78,11 +104,18 @@ func setupCommands() {
 	// Create separate client for MCP with "mcp" user agent
 	mcpRequester := requests.NewWithUserAgent("mcp")
 	mcpClient := client.New(mcpRequester, orgLookupFn)
-	rootCmd.AddCommand(NewLoginCmd())
+	rootCmd.AddCommand(NewLoginCmd(c))
+	rootCmd.AddCommand(NewLogoutCmd())
+	rootCmd.AddCommand(NewWhoAmICmd(c))
+	rootCmd.AddCommand(NewAuthCmd())
 	rootCmd.AddCommand(NewGetCmd(c))
-	rootCmd.AddCommand(NewSetCmd())
+	rootCmd.AddCommand(NewDescribeCmd(c))
+	rootCmd.AddCommand(NewSetCmd(c))
 	rootCmd.AddCommand(NewUpdateCmd())
 	rootCmd.AddCommand(NewModeCmd())
+	rootCmd.AddCommand(NewProfileCmd())
+	rootCmd.AddCommand(NewConfigCmd())
+	rootCmd.AddCommand(NewAliasCmd())
 	rootCmd.AddCommand(volumes.NewResetCmd(c))
 	rootCmd.AddCommand(volumes.NewCreateCmd(c))
 	rootCmd.AddCommand(volumes.NewUploadCmd(c))
@@ -95,20 +128,54 @@ func setupCommands() {
 	rootCmd.AddCommand(env.NewReviveCmd(c))
 	rootCmd.AddCommand(env.NewStopCmd(c))
 	rootCmd.AddCommand(env.NewVisitCmd(c))
+	rootCmd.AddCommand(NewUICmd(c))
 
 	rootCmd.AddCommand(k8s.NewExecCmd(c))
 	rootCmd.AddCommand(k8s.NewLogsCmd(c))
 	rootCmd.AddCommand(k8s.NewPortForwardCmd(c))
 	rootCmd.AddCommand(telepresence.NewTelepresenceCmd(c))
 	rootCmd.AddCommand(NewMCPCmd(mcpClient))
+
+	completion.New(c).RegisterFlags(rootCmd)
 }
 
 func initConfig() {
+	readConfig()
+	if configErr != nil {
+		// The command fails with it in PersistentPreRunE, unless it checks the config file.
+		return
+	}
+
+	// A .shipyard.yaml in the working directory or above carries the defaults of a project.
+	if wd, err := os.Getwd(); err == nil {
+		if _, err := config.MergeProjectFile(wd); err != nil {
+			fail("Init", err)
+		}
+	}
+
+	// The active profile overrides the user config values, but not flags, environment variables
+	// and the project config.
+	err := config.ApplyProfile(func(key string) bool {
+		return flagChanged(key) || config.EnvSet(key) || config.InProject(key)
+	})
+	if err != nil {
+		fail("Init", err)
+	}
+}
+
+// flagChanged reports whether a global flag bound to a config key is set.
+func flagChanged(key string) bool {
+	f := rootCmd.PersistentFlags().Lookup(key)
+	return f != nil && f.Changed
+}
+
+func readConfig() {
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
 		if err := viper.ReadInConfig(); err != nil {
 			if errors.As(err, &viper.ConfigParseError{}) {
-				fail("Init", errConfigParse)
+				configErr = parseError(cfgFile)
+				return
 			}
 			fail("Init", err)
 		}
@@ -130,16 +197,30 @@ func initConfig() {
 			if err := config.CreateDefaultConfig(home); err != nil {
 				fail("Init", err)
 			}
-			_, _ = fmt.Fprintln(os.Stdout, "Creating a default config.yaml in $HOME/.shipyard")
+			// Stderr keeps the notice out of piped output and shell completions.
+			_, _ = fmt.Fprintln(os.Stderr, "Creating a default config.yaml in $HOME/.shipyard")
 			return
 		} else if errors.As(err, &viper.ConfigParseError{}) {
-			fail("Init", errConfigParse)
+			configErr = parseError(viper.ConfigFileUsed())
 		} else {
 			fail("Init", err)
 		}
 	}
 }
 
+// parseError describes the first syntax error of the config file at path.
+func parseError(path string) error {
+	problems, err := config.ValidateFile(path)
+	if err != nil {
+		return err
+	}
+	msg := "check YAML for syntax errors"
+	if len(problems) > 0 {
+		msg = problems[0].String()
+	}
+	return fmt.Errorf("failed to parse the config file %s: %s", path, msg)
+}
+
 func fail(kind string, err error) {
 	red := color.New(color.FgHiRed)
 	_, _ = red.Fprintf(os.Stderr, "%s error: %s\n", kind, err)
@@ -1,14 +1,17 @@
 package services
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"time"
 
 	"github.com/shipyard/shipyard-cli/pkg/client"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/shipyard/shipyard-cli/pkg/display"
+	"github.com/shipyard/shipyard-cli/pkg/resolve"
+	"github.com/shipyard/shipyard-cli/pkg/types"
 )
 
 func NewGetServicesCmd(c client.Client) *cobra.Command {
@@ -16,30 +19,42 @@ func NewGetServicesCmd(c client.Client) *cobra.Command {
 		Use:   "services",
 		Short: "Get services in an environment",
 		Example: `  # Get all services for environment ID 12345
-  shipyard get services --env 12345`,
+  shipyard get services --env 12345
+
+  # Follow the services of environment ID 12345 while it rebuilds
+  shipyard get services --env 12345 --watch`,
 		SilenceUsage: true,
 		PreRun: func(cmd *cobra.Command, args []string) {
 			_ = viper.BindPFlag("env", cmd.Flags().Lookup("env"))
+			_ = viper.BindPFlag("watch", cmd.Flags().Lookup("watch"))
+			_ = viper.BindPFlag("interval", cmd.Flags().Lookup("interval"))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return handleGetServicesCmd(c)
+			if viper.GetBool("watch") {
+				return watchServices(cmd.Context(), c, cmd.CommandPath())
+			}
+			return handleGetServicesCmd(cmd.Context(), c)
 		},
 	}
 
-	cmd.Flags().String("env", "", "environment ID")
-	_ = cmd.MarkFlagRequired("env")
+	cmd.Flags().String("env", "", "environment ID, inferred from the git checkout or picked interactively if omitted")
+	cmd.Flags().Bool("watch", false, "Keep polling and show the changes")
+	cmd.Flags().Duration("interval", 5*time.Second, "Time between polls with --watch")
 
 	return cmd
 }
 
-func handleGetServicesCmd(c client.Client) error {
-	id := viper.GetString("env")
+func handleGetServicesCmd(ctx context.Context, c client.Client) error {
+	id, err := resolve.EnvironmentID(ctx, c, viper.GetString("env"))
+	if err != nil {
+		return err
+	}
 	
 	// Start spinner
 	spinner := display.NewSpinner("Fetching info please standby...")
 	spinner.Start()
 	
-	svcs, err := c.AllServices(id)
+	svcs, err := c.AllServices(ctx, id)
 	
 	// Stop spinner immediately after API call
 	spinner.Stop()
@@ -48,7 +63,39 @@ func handleGetServicesCmd(c client.Client) error {
 		return fmt.Errorf("failed to get services for environment %s: %w", id, err)
 	}
 
+	return display.PrintResource(servicesResource(svcs))
+}
+
+// watchServices polls the services of the environment every --interval.
+// Rows are highlighted when a service's ports or URL change.
+func watchServices(ctx context.Context, c client.Client, title string) error {
+	id, err := resolve.EnvironmentID(ctx, c, viper.GetString("env"))
+	if err != nil {
+		return err
+	}
+
+	return display.Watch(ctx, title+" --env "+id, viper.GetDuration("interval"), func(ctx context.Context) (display.WatchFrame, error) {
+		svcs, err := c.AllServices(ctx, id)
+		if err != nil {
+			return display.WatchFrame{}, fmt.Errorf("failed to get services for environment %s: %w", id, err)
+		}
+
+		frame := display.WatchFrame{Resource: servicesResource(svcs)}
+		for _, s := range svcs {
+			frame.Items = append(frame.Items, display.WatchItem{
+				ID:     s.Name,
+				State:  fmt.Sprintf("%v %s", s.Ports, s.URL),
+				Object: s,
+			})
+			frame.RowIDs = append(frame.RowIDs, s.Name)
+		}
+		return frame, nil
+	})
+}
+
+func servicesResource(svcs []types.Service) display.Resource {
 	var data [][]string
+	names := make([]string, 0, len(svcs))
 	for _, s := range svcs {
 		var ports string
 		if len(s.Ports) > 0 {
@@ -59,10 +106,16 @@ func handleGetServicesCmd(c client.Client) error {
 			display.FormatColoredAppName(s.Name),
 			ports,
 			display.FormatClickableURL(s.URL),
+			s.SanitizedName,
 		})
+		names = append(names, s.Name)
 	}
 
-	columns := []string{"Services", "Ports", "URL"}
-	display.RenderTable(os.Stdout, columns, data)
-	return nil
+	return display.Resource{
+		Object:      types.ServicesResponse{Data: svcs},
+		Columns:     []string{"Services", "Ports", "URL"},
+		WideColumns: []string{"Sanitized Name"},
+		Rows:        data,
+		Names:       names,
+	}
 }
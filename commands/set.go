@@ -6,24 +6,27 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
+	"github.com/shipyard/shipyard-cli/auth"
+	"github.com/shipyard/shipyard-cli/config"
+	"github.com/shipyard/shipyard-cli/pkg/client"
+	"github.com/shipyard/shipyard-cli/pkg/completion"
 	"github.com/shipyard/shipyard-cli/pkg/display"
 )
 
-func NewSetCmd() *cobra.Command {
+func NewSetCmd(c client.Client) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "set",
 		Short: "Set a value in the default local config",
 	}
 
-	cmd.AddCommand(NewSetOrgCmd())
+	cmd.AddCommand(NewSetOrgCmd(c))
 	cmd.AddCommand(NewSetTokenCmd())
 
 	return cmd
 }
 
-func NewSetOrgCmd() *cobra.Command {
+func NewSetOrgCmd(c client.Client) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:          "org",
 		Aliases:      []string{"organization"},
@@ -36,44 +39,42 @@ func NewSetOrgCmd() *cobra.Command {
 			}
 			return setOrg(args[0])
 		},
+		ValidArgsFunction: completion.New(c).OrgArg,
 	}
 
 	return cmd
 }
 
 func NewSetTokenCmd() *cobra.Command {
-	var profile string
-
 	cmd := &cobra.Command{
-		Use:          "token",
-		Short:        "Set the API token in the config",
-		Long:         `Set the API token in the config by providing an argument, or interactively by running the command without arguments`,
+		Use:   "token",
+		Short: "Save the API token",
+		Long: `Save the API token by providing an argument, or interactively by running the command without arguments.
+
+The token goes to the credential store selected by the 'credential_store' config value:
+the system keyring by default, or the config file where there is none.
+It is saved for the active profile, or the one given with --profile.`,
 		SilenceUsage: true,
-		Example:      `  shipyard set token <token>`,
+		Example: `  shipyard set token <token>
+
+  # Save the token of the qa profile
+  shipyard set token --profile qa`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
-				return setTokenInteractively(os.Stdin, profile)
+				return setTokenInteractively(os.Stdin)
 			}
-			return SetToken(args[0], profile)
+			return SetToken(args[0])
 		},
 	}
 
-	cmd.Flags().StringVar(&profile, "profile", "", "Profile name to save token under (hidden)")
-	_ = cmd.Flags().MarkHidden("profile")
-
 	return cmd
 }
 
 func setOrg(name string) error {
-	viper.Set("org", name)
-	err := viper.MergeInConfig()
-	if err != nil {
-		return err
-	}
-	return viper.WriteConfig()
+	return config.SaveOrg(name)
 }
 
-func setTokenInteractively(r io.Reader, profile string) error {
+func setTokenInteractively(r io.Reader) error {
 	display.Print("Your API token: ")
 
 	var token string
@@ -82,37 +83,15 @@ func setTokenInteractively(r io.Reader, profile string) error {
 		return err
 	}
 
-	return SetToken(token, profile)
+	return SetToken(token)
 }
 
-func SetToken(token string, profile string) error {
-	viper.Set("api_token", token)
-
-	// If a profile is specified, save the token under that profile as well
-	if profile != "" {
-		profiles := viper.GetStringMap("profiles")
-		if profiles == nil {
-			profiles = make(map[string]interface{})
-		}
-
-		// Create or update the profile with the auth_token
-		profileData := make(map[string]interface{})
-		if existingProfile, exists := profiles[profile]; exists {
-			if profileMap, ok := existingProfile.(map[string]interface{}); ok {
-				profileData = profileMap
-			}
-		}
-		profileData["auth_token"] = token
-		profiles[profile] = profileData
-
-		viper.Set("profiles", profiles)
-	}
-
-	// TODO: find a better way to not persist the value of verbose globally.
-	viper.Set("verbose", false)
-	err := viper.MergeInConfig()
+// SetToken saves the token of the active profile in the credential store.
+func SetToken(token string) error {
+	store, err := auth.SaveToken(token, "")
 	if err != nil {
 		return err
 	}
-	return viper.WriteConfig()
+	display.Println(fmt.Sprintf("Token saved in %s.", store.Name()))
+	return nil
 }
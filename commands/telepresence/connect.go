@@ -1,6 +1,7 @@
 package telepresence
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/shipyard/shipyard-cli/pkg/client"
 	"github.com/shipyard/shipyard-cli/pkg/display"
 	"github.com/shipyard/shipyard-cli/pkg/k8s"
+	"github.com/shipyard/shipyard-cli/pkg/resolve"
 )
 
 func NewTelepresenceCmd(c client.Client) *cobra.Command {
@@ -32,21 +34,23 @@ func NewConnectCmd(c client.Client) *cobra.Command {
 			_ = viper.BindPFlag("env", cmd.Flags().Lookup("env"))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return connect(c)
+			return connect(cmd.Context(), c)
 		},
 	}
-	cmd.Flags().String("env", "", "environment ID")
-	_ = cmd.MarkFlagRequired("env")
+	cmd.Flags().String("env", "", "environment ID, inferred from the git checkout or picked interactively if omitted")
 	return cmd
 }
 
-func connect(c client.Client) error {
+func connect(ctx context.Context, c client.Client) error {
 	if _, err := exec.LookPath("telepresence"); err != nil {
 		return fmt.Errorf("telepresence not found, please make sure it's in your PATH")
 	}
 
-	id := viper.GetString("env")
-	k, err := k8s.NewConfig(c, id)
+	id, err := resolve.EnvironmentID(ctx, c, viper.GetString("env"))
+	if err != nil {
+		return err
+	}
+	k, err := k8s.NewConfig(ctx, c, id)
 	if err != nil {
 		return err
 	}
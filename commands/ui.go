@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+
+	"github.com/shipyard/shipyard-cli/config"
+	"github.com/shipyard/shipyard-cli/constants"
+	"github.com/shipyard/shipyard-cli/pkg/client"
+	"github.com/shipyard/shipyard-cli/pkg/tui"
+)
+
+func NewUICmd(c client.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "ui",
+		GroupID:      constants.GroupEnvironments,
+		SilenceUsage: true,
+		Short:        "Browse and manage environments in an interactive dashboard",
+		Long: `Open a full-screen dashboard of the environments in an org.
+
+The list is refreshed every --interval. Select an environment to see its services,
+tail their logs, open a shell or forward their ports. The keys are listed at the bottom.`,
+		Example: `  # Open the dashboard for your default org:
+  shipyard ui
+
+  # Only show the environments of a repo, polling every 10 seconds:
+  shipyard ui --repo-name flask-backend --interval 10s`,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			_ = viper.BindPFlag("name", cmd.Flags().Lookup("name"))
+			_ = viper.BindPFlag("repo-name", cmd.Flags().Lookup("repo-name"))
+			_ = viper.BindPFlag("branch", cmd.Flags().Lookup("branch"))
+			_ = viper.BindPFlag("pull-request-number", cmd.Flags().Lookup("pull-request-number"))
+			_ = viper.BindPFlag("interval", cmd.Flags().Lookup("interval"))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUI(cmd, c)
+		},
+	}
+
+	cmd.Flags().String("name", "", "Filter by name of the application")
+	cmd.Flags().String("repo-name", "", "Filter by repo name")
+	cmd.Flags().String("branch", "", "Filter by branch name")
+	cmd.Flags().String("pull-request-number", "", "Filter by pull request number")
+	cmd.Flags().Duration("interval", 5*time.Second, "Time between polls")
+
+	return cmd
+}
+
+func runUI(cmd *cobra.Command, c client.Client) error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return errors.New("shipyard ui needs a terminal, use 'shipyard get environments --watch' in scripts")
+	}
+	interval := viper.GetDuration("interval")
+	if interval < time.Second {
+		return errors.New("--interval must be at least 1s")
+	}
+
+	filter := client.EnvironmentFilter{
+		Name:              viper.GetString("name"),
+		RepoName:          config.RepoName(),
+		Branch:            viper.GetString("branch"),
+		PullRequestNumber: viper.GetString("pull-request-number"),
+	}
+
+	// The Kubernetes helpers log to stderr, which would write over the dashboard.
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	app := tui.New(tui.NewBackend(c, filter), uiTitle(c.OrgLookupFn(), filter))
+	return tui.Run(cmd.Context(), app, interval)
+}
+
+// uiTitle describes which environments the dashboard lists.
+func uiTitle(org string, filter client.EnvironmentFilter) string {
+	parts := []string{"default org"}
+	if org != "" {
+		parts[0] = "org " + org
+	}
+	for _, f := range []struct{ name, value string }{
+		{"name", filter.Name},
+		{"repo", filter.RepoName},
+		{"branch", filter.Branch},
+		{"PR", filter.PullRequestNumber},
+	} {
+		if f.value != "" {
+			parts = append(parts, fmt.Sprintf("%s %s", f.name, f.value))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -1,13 +1,18 @@
 package volumes
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/shipyard/shipyard-cli/pkg/client"
+	"github.com/shipyard/shipyard-cli/pkg/display"
 	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
+	"github.com/shipyard/shipyard-cli/pkg/resolve"
+	"github.com/shipyard/shipyard-cli/pkg/types"
 )
 
 func NewCreateCmd(c client.Client) *cobra.Command {
@@ -28,19 +33,21 @@ func NewCreateSnapshotCmd(c client.Client) *cobra.Command {
 			_ = viper.BindPFlag("note", cmd.Flags().Lookup("note"))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return handleCreateSnapshotCmd(c)
+			return handleCreateSnapshotCmd(cmd.Context(), c)
 		},
 	}
 
-	cmd.Flags().String("env", "", "environment ID")
-	_ = cmd.MarkFlagRequired("env")
+	cmd.Flags().String("env", "", "environment ID, inferred from the git checkout or picked interactively if omitted")
 	cmd.Flags().String("note", "", "An optional description of the snapshot")
 
 	return cmd
 }
 
-func handleCreateSnapshotCmd(c client.Client) error {
-	envID := viper.GetString("env")
+func handleCreateSnapshotCmd(ctx context.Context, c client.Client) error {
+	envID, err := resolve.EnvironmentID(ctx, c, viper.GetString("env"))
+	if err != nil {
+		return err
+	}
 	params := make(map[string]string)
 	if org := c.OrgLookupFn(); org != "" {
 		params["org"] = org
@@ -48,6 +55,13 @@ func handleCreateSnapshotCmd(c client.Client) error {
 	body := map[string]any{
 		"note": viper.GetString("note"),
 	}
-	_, err := c.Requester.Do(http.MethodPost, uri.CreateResourceURI("", "environment", envID, "snapshot-create", params), "application/json", body)
-	return err
+	_, err = c.Requester.Do(ctx, http.MethodPost, uri.CreateResourceURI("", "environment", envID, "snapshot-create", params), "application/json", body)
+	if err != nil {
+		return err
+	}
+
+	return display.PrintResource(display.ActionResource(
+		fmt.Sprintf("Snapshot created for environment %s.", envID),
+		types.ActionResult{ID: envID, Action: "create snapshot", Result: "snapshot created"},
+	))
 }
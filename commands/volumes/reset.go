@@ -1,6 +1,7 @@
 package volumes
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
@@ -8,7 +9,10 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/shipyard/shipyard-cli/pkg/client"
+	"github.com/shipyard/shipyard-cli/pkg/display"
 	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
+	"github.com/shipyard/shipyard-cli/pkg/resolve"
+	"github.com/shipyard/shipyard-cli/pkg/types"
 )
 
 func NewResetCmd(c client.Client) *cobra.Command {
@@ -29,20 +33,22 @@ func NewResetVolumeCmd(c client.Client) *cobra.Command {
 			_ = viper.BindPFlag("volume", cmd.Flags().Lookup("volume"))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return handleResetVolumeCmd(c)
+			return handleResetVolumeCmd(cmd.Context(), c)
 		},
 	}
 
-	cmd.Flags().String("env", "", "environment ID")
+	cmd.Flags().String("env", "", "environment ID, inferred from the git checkout or picked interactively if omitted")
 	cmd.Flags().String("volume", "", "volume name")
-	_ = cmd.MarkFlagRequired("env")
 	_ = cmd.MarkFlagRequired("volume")
 
 	return cmd
 }
 
-func handleResetVolumeCmd(c client.Client) error {
-	envID := viper.GetString("env")
+func handleResetVolumeCmd(ctx context.Context, c client.Client) error {
+	envID, err := resolve.EnvironmentID(ctx, c, viper.GetString("env"))
+	if err != nil {
+		return err
+	}
 	volume := viper.GetString("volume")
 	params := make(map[string]string)
 	if org := c.OrgLookupFn(); org != "" {
@@ -50,6 +56,13 @@ func handleResetVolumeCmd(c client.Client) error {
 	}
 
 	subresource := fmt.Sprintf("volume/%s/volume-reset", volume)
-	_, err := c.Requester.Do(http.MethodPost, uri.CreateResourceURI("", "environment", envID, subresource, params), "application/json", nil)
-	return err
+	_, err = c.Requester.Do(ctx, http.MethodPost, uri.CreateResourceURI("", "environment", envID, subresource, params), "application/json", nil)
+	if err != nil {
+		return err
+	}
+
+	return display.PrintResource(display.ActionResource(
+		fmt.Sprintf("Volume %s in environment %s has been reset to its initial state.", volume, envID),
+		types.ActionResult{ID: envID, Action: "reset volume", Result: fmt.Sprintf("volume %s reset", volume)},
+	))
 }
@@ -1,10 +1,10 @@
 package volumes
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
 	"strconv"
 
 	"github.com/fatih/color"
@@ -14,34 +14,90 @@ import (
 	"github.com/shipyard/shipyard-cli/pkg/client"
 	"github.com/shipyard/shipyard-cli/pkg/display"
 	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
+	"github.com/shipyard/shipyard-cli/pkg/resolve"
 	"github.com/shipyard/shipyard-cli/pkg/types"
 )
 
 func NewGetVolumeSnapshotsCmd(c client.Client) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:          "snapshots",
-		Short:        "Get volume snapshots in an environment",
+		Use:   "snapshots",
+		Short: "Get volume snapshots in an environment",
+		Example: `  # Get the first page of snapshots for environment ID 12345:
+  shipyard get volumes snapshots --env 12345
+
+  # Get the snapshots from every page as a single JSON array:
+  shipyard get volumes snapshots --env 12345 --all -o json`,
 		SilenceUsage: true,
 		PreRun: func(cmd *cobra.Command, args []string) {
 			_ = viper.BindPFlag("env", cmd.Flags().Lookup("env"))
 			_ = viper.BindPFlag("page", cmd.Flags().Lookup("page"))
 			_ = viper.BindPFlag("page-size", cmd.Flags().Lookup("page-size"))
+			_ = viper.BindPFlag("all", cmd.Flags().Lookup("all"))
 			_ = viper.BindPFlag("json", cmd.Flags().Lookup("json"))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return handleGetVolumeSnapshotsCmd(c)
+			if viper.GetBool("all") {
+				return handleGetEveryVolumeSnapshot(cmd.Context(), c)
+			}
+			return handleGetVolumeSnapshotsCmd(cmd.Context(), c)
 		},
 	}
 
-	cmd.Flags().String("env", "", "environment ID")
+	cmd.Flags().String("env", "", "environment ID, inferred from the git checkout or picked interactively if omitted")
 	cmd.Flags().Int("page", 1, "Page number requested")
 	cmd.Flags().Int("page-size", 20, "Page size requested")
-	cmd.Flags().Bool("json", false, "JSON output")
-	_ = cmd.MarkFlagRequired("env")
+	cmd.Flags().Bool("all", false, "Fetch every page instead of a single one")
+	cmd.Flags().Bool("json", false, "JSON output, same as -o json")
+	cmd.MarkFlagsMutuallyExclusive("all", "page")
 	return cmd
 }
 
-func handleGetVolumeSnapshotsCmd(c client.Client) error {
+// handleGetEveryVolumeSnapshot follows the pages until the last one and prints
+// all snapshots in a single table or a single JSON array.
+func handleGetEveryVolumeSnapshot(ctx context.Context, c client.Client) error {
+	id, err := resolve.EnvironmentID(ctx, c, viper.GetString("env"))
+	if err != nil {
+		return err
+	}
+
+	snapshots := []types.Snapshot{}
+	for snapshot, err := range c.ListSnapshots(ctx, id, viper.GetInt("page-size")) {
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	// Every page together is a plain array, without the links of a single page
+	return display.PrintResource(snapshotsResource(snapshots, snapshots))
+}
+
+// snapshotsResource lays out snapshots for every output format, with object as the structured output.
+func snapshotsResource(snapshots []types.Snapshot, object any) display.Resource {
+	res := display.Resource{
+		Object:      object,
+		Columns:     []string{"From", "Sequence", "Status", "Type"},
+		WideColumns: []string{"Created", "Size", "ID"},
+	}
+	if len(snapshots) == 0 {
+		res.Message = "No snapshots found for this environment."
+	}
+	for _, v := range snapshots {
+		res.Rows = append(res.Rows, []string{
+			strconv.Itoa(v.Attributes.FromSnapshotNumber),
+			strconv.Itoa(v.Attributes.SequenceNumber),
+			v.Attributes.Status,
+			v.Type,
+			v.Attributes.CreatedAt,
+			strconv.Itoa(v.Attributes.TotalSize),
+			v.ID,
+		})
+		res.Names = append(res.Names, strconv.Itoa(v.Attributes.SequenceNumber))
+	}
+	return res
+}
+
+func handleGetVolumeSnapshotsCmd(ctx context.Context, c client.Client) error {
 	params := make(map[string]string)
 	if org := c.OrgLookupFn(); org != "" {
 		params["org"] = org
@@ -52,37 +108,20 @@ func handleGetVolumeSnapshotsCmd(c client.Client) error {
 	if pageSize := viper.GetInt("page-size"); pageSize != 0 {
 		params["page_size"] = strconv.Itoa(pageSize)
 	}
-	id := viper.GetString("env")
-	body, err := c.Requester.Do(http.MethodGet, uri.CreateResourceURI("", "environment", id, "volume-snapshots", params), "application/json", nil)
+	id, err := resolve.EnvironmentID(ctx, c, viper.GetString("env"))
 	if err != nil {
 		return err
 	}
-	if viper.GetBool("json") {
-		display.Println(body)
-		return nil
+	body, err := c.Requester.Do(ctx, http.MethodGet, uri.CreateResourceURI("", "environment", id, "volume-snapshots", params), "application/json", nil)
+	if err != nil {
+		return err
 	}
-
 	var resp types.SnapshotsResponse
 	if err := json.Unmarshal(body, &resp); err != nil {
 		return fmt.Errorf("failed to unmarshal snapshots: %w", err)
 	}
-	if len(resp.Data) == 0 {
-		display.Println("No snapshots found for this environment.")
-		return nil
-	}
 
-	var data [][]string
-	for _, v := range resp.Data {
-		data = append(data, []string{
-			strconv.Itoa(v.Attributes.FromSnapshotNumber),
-			strconv.Itoa(v.Attributes.SequenceNumber),
-			v.Attributes.Status,
-			v.Type,
-		})
-	}
-
-	columns := []string{"From", "Sequence", "Status", "Type"}
-	display.RenderTable(os.Stdout, columns, data)
+	res := snapshotsResource(resp.Data, resp)
 	if resp.Links.Next != "" {
 		nextPage := resp.Links.NextPage()
 		cmd := " shipyard get volumes snapshots --page " + strconv.Itoa(nextPage) + " "
@@ -94,14 +133,14 @@ func handleGetVolumeSnapshotsCmd(c client.Client) error {
 		if pageSize := viper.GetInt("page-size"); pageSize != 0 && pageSize != 20 {
 			cmd += " --page-size " + strconv.Itoa(pageSize)
 		}
-		if viper.GetBool("json") {
-			cmd += " --json"
+		if display.OutputFormat() == display.OutputWide {
+			cmd += " -o wide"
 		}
 
 		styledCmd := color.New(color.FgHiWhite, color.BgBlue).Sprint(cmd)
-		display.Println(fmt.Sprintf("Table is truncated, fetch the next page %d. %s", nextPage, styledCmd))
+		res.Footer = fmt.Sprintf("Table is truncated, fetch the next page %d. %s\nPass --all to fetch every page at once.", nextPage, styledCmd)
 	}
-	return nil
+	return display.PrintResource(res)
 }
 
 func NewLoadCmd(c client.Client) *cobra.Command {
@@ -123,24 +162,26 @@ func NewLoadVolumeSnapshotCmd(c client.Client) *cobra.Command {
 			_ = viper.BindPFlag("source-application-id", cmd.Flags().Lookup("source-application-id"))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return handleLoadVolumeSnapshotCmd(c)
+			return handleLoadVolumeSnapshotCmd(cmd.Context(), c)
 		},
 	}
 
-	cmd.Flags().String("env", "", "environment ID")
+	cmd.Flags().String("env", "", "environment ID, inferred from the git checkout or picked interactively if omitted")
 	cmd.Flags().String("sequence-number", "", "sequence number of a snapshot")
 	cmd.Flags().String("source-application-id", "", "source application ID")
-	_ = cmd.MarkFlagRequired("env")
 	_ = cmd.MarkFlagRequired("sequence-number")
 	return cmd
 }
 
-func handleLoadVolumeSnapshotCmd(c client.Client) error {
+func handleLoadVolumeSnapshotCmd(ctx context.Context, c client.Client) error {
 	params := make(map[string]string)
 	if org := c.OrgLookupFn(); org != "" {
 		params["org"] = org
 	}
-	id := viper.GetString("env")
+	id, err := resolve.EnvironmentID(ctx, c, viper.GetString("env"))
+	if err != nil {
+		return err
+	}
 	data := map[string]any{
 		"data": map[string]any{
 			"type": "snapshot-load",
@@ -150,6 +191,14 @@ func handleLoadVolumeSnapshotCmd(c client.Client) error {
 			},
 		},
 	}
-	_, err := c.Requester.Do(http.MethodPost, uri.CreateResourceURI("", "environment", id, "snapshot-load", params), "application/json", data)
-	return err
+	_, err = c.Requester.Do(ctx, http.MethodPost, uri.CreateResourceURI("", "environment", id, "snapshot-load", params), "application/json", data)
+	if err != nil {
+		return err
+	}
+
+	sequence := viper.GetInt("sequence-number")
+	return display.PrintResource(display.ActionResource(
+		fmt.Sprintf("Snapshot %d loaded into environment %s.", sequence, id),
+		types.ActionResult{ID: id, Action: "load snapshot", Result: fmt.Sprintf("snapshot %d loaded", sequence)},
+	))
 }
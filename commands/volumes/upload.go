@@ -2,6 +2,7 @@ package volumes
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -13,7 +14,10 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/shipyard/shipyard-cli/pkg/client"
+	"github.com/shipyard/shipyard-cli/pkg/display"
 	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
+	"github.com/shipyard/shipyard-cli/pkg/resolve"
+	"github.com/shipyard/shipyard-cli/pkg/types"
 	"github.com/shipyard/shipyard-cli/pkg/zip"
 )
 
@@ -36,14 +40,13 @@ func NewUploadVolumeCmd(c client.Client) *cobra.Command {
 			_ = viper.BindPFlag("path", cmd.Flags().Lookup("path"))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return handleUploadVolumeCmd(c)
+			return handleUploadVolumeCmd(cmd.Context(), c)
 		},
 	}
 
-	cmd.Flags().String("env", "", "environment ID")
+	cmd.Flags().String("env", "", "environment ID, inferred from the git checkout or picked interactively if omitted")
 	cmd.Flags().String("volume", "", "volume name")
-	cmd.Flags().String("path", "", "path to a file to upload (either a .bz2 archive, regular file, or directory")
-	_ = cmd.MarkFlagRequired("env")
+	cmd.Flags().String("path", "", "path to a file to upload (either a .bz2 archive, regular file, or directory); files matching 'upload_ignore' in the config are left out of directories")
 	_ = cmd.MarkFlagRequired("volume")
 	_ = cmd.MarkFlagRequired("path")
 
@@ -54,8 +57,11 @@ const (
 	tarbz2 = "%s.tar.bz2"
 )
 
-func handleUploadVolumeCmd(c client.Client) error {
-	envID := viper.GetString("env")
+func handleUploadVolumeCmd(ctx context.Context, c client.Client) error {
+	envID, err := resolve.EnvironmentID(ctx, c, viper.GetString("env"))
+	if err != nil {
+		return err
+	}
 	volume := viper.GetString("volume")
 	params := make(map[string]string)
 	if org := c.OrgLookupFn(); org != "" {
@@ -71,7 +77,8 @@ func handleUploadVolumeCmd(c client.Client) error {
 
 	switch {
 	case fi.IsDir():
-		if err := zip.CreateArchiveFromDir(path); err != nil {
+		// A project config can leave out build output, dependencies and the like.
+		if err := zip.CreateArchiveFromDir(path, viper.GetStringSlice("upload_ignore")...); err != nil {
 			return err
 		}
 		archiveFilename = fmt.Sprintf(tarbz2, path)
@@ -96,8 +103,15 @@ func handleUploadVolumeCmd(c client.Client) error {
 	if err != nil {
 		return err
 	}
-	_, err = c.Requester.Do(http.MethodPost, url, contentType, form)
-	return err
+	_, err = c.Requester.Do(ctx, http.MethodPost, url, contentType, form)
+	if err != nil {
+		return err
+	}
+
+	return display.PrintResource(display.ActionResource(
+		fmt.Sprintf("Uploaded %s to volume %s in environment %s.", path, volume, envID),
+		types.ActionResult{ID: envID, Action: "upload volume", Result: fmt.Sprintf("%s uploaded to volume %s", path, volume)},
+	))
 }
 
 func bz2File(path string) bool {
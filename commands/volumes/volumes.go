@@ -1,10 +1,10 @@
 package volumes
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -12,6 +12,7 @@ import (
 	"github.com/shipyard/shipyard-cli/pkg/client"
 	"github.com/shipyard/shipyard-cli/pkg/display"
 	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
+	"github.com/shipyard/shipyard-cli/pkg/resolve"
 	"github.com/shipyard/shipyard-cli/pkg/types"
 )
 
@@ -25,49 +26,50 @@ func NewGetVolumesCmd(c client.Client) *cobra.Command {
 			_ = viper.BindPFlag("json", cmd.Flags().Lookup("json"))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return handleGetVolumesCmd(c)
+			return handleGetVolumesCmd(cmd.Context(), c)
 		},
 	}
 
-	cmd.Flags().String("env", "", "environment ID")
-	cmd.Flags().Bool("json", false, "JSON output")
-	_ = cmd.MarkFlagRequired("env")
+	cmd.Flags().String("env", "", "environment ID, inferred from the git checkout or picked interactively if omitted")
+	cmd.Flags().Bool("json", false, "JSON output, same as -o json")
 	return cmd
 }
 
-func handleGetVolumesCmd(c client.Client) error {
-	id := viper.GetString("env")
+func handleGetVolumesCmd(ctx context.Context, c client.Client) error {
+	id, err := resolve.EnvironmentID(ctx, c, viper.GetString("env"))
+	if err != nil {
+		return err
+	}
 	params := make(map[string]string)
 	if org := c.OrgLookupFn(); org != "" {
 		params["org"] = org
 	}
 
-	body, err := c.Requester.Do(http.MethodGet, uri.CreateResourceURI("", "environment", id, "volumes", params), "application/json", nil)
+	body, err := c.Requester.Do(ctx, http.MethodGet, uri.CreateResourceURI("", "environment", id, "volumes", params), "application/json", nil)
 	if err != nil {
 		return err
 	}
-	if viper.GetBool("json") {
-		display.Println(body)
-		return nil
-	}
-
 	var resp types.VolumesResponse
 	if err := json.Unmarshal(body, &resp); err != nil {
 		return fmt.Errorf("failed to unmarshal volumes: %w", err)
 	}
+
+	res := display.Resource{
+		Object:      resp,
+		Columns:     []string{"Name", "Service"},
+		WideColumns: []string{"Path", "Compose Path"},
+	}
 	if len(resp.Data) == 0 {
-		display.Println("No volumes found in the environment.")
-		return nil
+		res.Message = "No volumes found in the environment."
 	}
-
-	var data [][]string
 	for _, v := range resp.Data {
-		data = append(data, []string{
+		res.Rows = append(res.Rows, []string{
 			v.Attributes.Name,
 			v.Attributes.ServiceName,
+			v.Attributes.VolumePath,
+			v.Attributes.ComposePath,
 		})
+		res.Names = append(res.Names, v.Attributes.Name)
 	}
-	columns := []string{"Name", "Service"}
-	display.RenderTable(os.Stdout, columns, data)
-	return nil
+	return display.PrintResource(res)
 }
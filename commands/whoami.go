@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/shipyard/shipyard-cli/config"
+	"github.com/shipyard/shipyard-cli/pkg/client"
+	"github.com/shipyard/shipyard-cli/pkg/display"
+	"github.com/shipyard/shipyard-cli/pkg/requests"
+	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
+)
+
+func NewWhoAmICmd(c client.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "whoami",
+		Short:        "Show who the API token belongs to",
+		Long:         `Check the API token with the API and show the user it belongs to, the kind of token, the default org and the API URL in use.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			_ = viper.BindPFlag("json", cmd.Flags().Lookup("json"))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return whoAmI(cmd.Context(), c)
+		},
+	}
+
+	cmd.Flags().Bool("json", false, "JSON output, same as -o json")
+
+	return cmd
+}
+
+type identity struct {
+	Name       string `json:"name,omitempty"`
+	Email      string `json:"email,omitempty"`
+	TokenType  string `json:"token_type"`
+	DefaultOrg string `json:"default_org,omitempty"`
+	Org        string `json:"org,omitempty"`
+	APIURL     string `json:"api_url"`
+	Profile    string `json:"profile,omitempty"`
+}
+
+func whoAmI(ctx context.Context, c client.Client) error {
+	user, err := c.CurrentUser(ctx)
+	if requests.IsUnauthorized(err) {
+		return fmt.Errorf("the API token is not valid: %w, run 'shipyard login' to get a new one", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	id := identity{
+		Name:       user.Name,
+		Email:      user.Email,
+		TokenType:  user.TokenType,
+		DefaultOrg: user.DefaultOrg,
+		Org:        c.OrgLookupFn(),
+		APIURL:     uri.BaseURL(),
+		Profile:    config.ActiveProfile(),
+	}
+
+	tokenType := "user"
+	if id.TokenType == "org" {
+		tokenType = "org-level"
+	}
+	userName := id.Name
+	if id.Email != "" {
+		userName += " <" + id.Email + ">"
+	}
+	var b bytes.Buffer
+	fields := [][2]string{
+		{"User", userName},
+		{"Token type", tokenType},
+		{"Default org", id.DefaultOrg},
+		{"Org in use", id.Org},
+		{"API URL", id.APIURL},
+		{"Profile", id.Profile},
+	}
+	for _, f := range fields {
+		if f[1] == "" {
+			continue
+		}
+		_, _ = fmt.Fprintf(&b, "%-13s%s\n", f[0]+":", f[1])
+	}
+
+	var names []string
+	if id.Email != "" {
+		names = append(names, id.Email)
+	}
+	return display.PrintResource(display.Resource{
+		Object:  id,
+		Columns: []string{"User", "Email", "Token type", "Default org", "Org", "API URL"},
+		Rows:    [][]string{{id.Name, id.Email, tokenType, id.DefaultOrg, id.Org, id.APIURL}},
+		Names:   names,
+		Message: strings.TrimSuffix(b.String(), "\n"),
+	})
+}
@@ -8,16 +8,102 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-type Profile struct {
-	AuthToken string `yaml:"auth_token"`
-}
-
 type Config struct {
 	Token    string             `yaml:"api_token"`
 	Org      string             `yaml:"org"`
 	Verbose  bool               `yaml:"verbose"`
 	ApiURL   string             `yaml:"api_url"`
 	Profiles map[string]Profile `yaml:"profiles"`
+	// Profile is the profile in use, selected with 'shipyard profile use'.
+	Profile string `yaml:"profile,omitempty"`
+	// CredentialStore selects where tokens are saved: auto, keyring, file or config.
+	CredentialStore string `yaml:"credential_store,omitempty"`
+	// Aliases maps short names to environment IDs.
+	Aliases map[string]string `yaml:"aliases"`
+	// RepoName, Service and UploadIgnore are usually set by a project config, see ProjectFileName.
+	RepoName     string   `yaml:"repo_name,omitempty"`
+	Service      string   `yaml:"service,omitempty"`
+	UploadIgnore []string `yaml:"upload_ignore,omitempty"`
+	MCP          MCP      `yaml:"mcp,omitempty"`
+}
+
+// MCP configures 'shipyard mcp serve'.
+type MCP struct {
+	// Transport is stdio, for an assistant that starts the server, or http to serve several clients.
+	Transport string `yaml:"transport" mapstructure:"transport" default:"stdio"`
+	// Host and Port are the address the http transport listens on.
+	Host string `yaml:"host,omitempty" mapstructure:"host" default:"127.0.0.1"`
+	Port int    `yaml:"port" mapstructure:"port" default:"8080"`
+	// AuditLogging records every tool call and resource read in AuditLog, mcp-audit.log next to the
+	// config file by default. The log moves to AuditLog.1 once it reaches AuditLogMaxSize megabytes,
+	// and AuditLogMaxFiles of the older logs are kept.
+	AuditLogging     bool   `yaml:"audit_logging" mapstructure:"audit_logging" default:"true"`
+	AuditLog         string `yaml:"audit_log,omitempty" mapstructure:"audit_log"`
+	AuditLogMaxSize  int    `yaml:"audit_log_max_size,omitempty" mapstructure:"audit_log_max_size" default:"10"`
+	AuditLogMaxFiles int    `yaml:"audit_log_max_files,omitempty" mapstructure:"audit_log_max_files" default:"5"`
+	// AllowedOrigins are the browser origins the http transport accepts, besides the local ones.
+	AllowedOrigins []string `yaml:"allowed_origins,omitempty" mapstructure:"allowed_origins"`
+	// MaxConcurrency is the number of requests handled at once. The others wait for a free slot.
+	MaxConcurrency int `yaml:"max_concurrency,omitempty" mapstructure:"max_concurrency" default:"8"`
+	// SessionTimeout ends the sessions of the http transport idle for that many minutes, and
+	// MaxSessions limits the sessions open at once.
+	SessionTimeout int `yaml:"session_timeout,omitempty" mapstructure:"session_timeout" default:"30"`
+	MaxSessions    int `yaml:"max_sessions,omitempty" mapstructure:"max_sessions" default:"100"`
+	// Auth requires the clients of the http transport to send a bearer token.
+	Auth MCPAuth `yaml:"auth,omitempty" mapstructure:"auth"`
+	// ReadOnly offers only the tools that change nothing.
+	ReadOnly bool `yaml:"read_only,omitempty" mapstructure:"read_only"`
+	// Tools limits the tools the server offers and what they may act on.
+	Tools MCPTools `yaml:"tools,omitempty" mapstructure:"tools"`
+}
+
+// MCPTools limits the tools of the MCP server.
+type MCPTools struct {
+	// Allow lists the only tools offered, if any. Deny lists tools that are never offered.
+	Allow []string `yaml:"allow,omitempty" mapstructure:"allow"`
+	Deny  []string `yaml:"deny,omitempty" mapstructure:"deny"`
+	// Confirm makes destructive tools, such as stop_environment, fail until they are called
+	// again with confirm: true, so that the assistant asks the user first.
+	Confirm bool `yaml:"confirm,omitempty" mapstructure:"confirm"`
+	// Scopes limit a tool, or every tool with "*", to some orgs and environments.
+	Scopes map[string]MCPToolScope `yaml:"scopes,omitempty" mapstructure:"scopes"`
+}
+
+// MCPToolScope lists the orgs and environment IDs a tool may act on. An empty list allows all.
+type MCPToolScope struct {
+	Orgs         []string `yaml:"orgs,omitempty" mapstructure:"orgs"`
+	Environments []string `yaml:"environments,omitempty" mapstructure:"environments"`
+}
+
+// MCPAuth lists the bearer tokens the MCP server accepts. Without any, it accepts every client.
+type MCPAuth struct {
+	// Tokens act with the API token and org of the server.
+	Tokens []string `yaml:"tokens,omitempty" mapstructure:"tokens"`
+	// Users have a token each, and may act with an API token and org of their own,
+	// so that one server serves several people.
+	Users map[string]MCPUser `yaml:"users,omitempty" mapstructure:"users"`
+}
+
+// MCPUser is a client of the MCP server.
+type MCPUser struct {
+	Token    string `yaml:"token" mapstructure:"token"`
+	APIToken string `yaml:"api_token,omitempty" mapstructure:"api_token"`
+	Org      string `yaml:"org,omitempty" mapstructure:"org"`
+}
+
+// Enabled reports whether the server requires a bearer token.
+func (a MCPAuth) Enabled() bool {
+	return len(a.Tokens) > 0 || len(a.Users) > 0
+}
+
+// UpdateProfile lets fn change a profile, adding it if needed.
+func (c *Config) UpdateProfile(name string, fn func(p *Profile)) {
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]Profile)
+	}
+	p := c.Profiles[name]
+	fn(&p)
+	c.Profiles[name] = p
 }
 
 // CreateDefaultConfig tries to create a config.yaml file in the default
@@ -37,5 +123,5 @@ func CreateDefaultConfig(homedir string) error {
 		return err
 	}
 
-	return os.WriteFile(p, b, 0o600)
+	return writeFile(p, b)
 }
@@ -0,0 +1,371 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is a config file loaded for a change. It keeps the parsed YAML document, so that saving
+// the file preserves its comments, the order of its keys and the keys Config does not know.
+type File struct {
+	path string
+	doc  *yaml.Node
+	// saved is the document as Config read it, to tell which values a change touches.
+	saved Config
+	// indent is the indentation of the file, which Save keeps.
+	indent int
+}
+
+// Load reads the config file at path.
+func Load(path string) (*File, error) {
+	if path == "" {
+		return nil, errors.New("no config file is in use")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the config file: %w", err)
+	}
+
+	f := &File{path: path, doc: &yaml.Node{}}
+	if err := yaml.Unmarshal(b, f.doc); err != nil {
+		return nil, fmt.Errorf("failed to parse the config file: %w", err)
+	}
+	f.indent = detectIndent(f.doc)
+	if len(f.doc.Content) == 0 || isNull(f.doc.Content[0]) {
+		f.doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if f.root().Kind != yaml.MappingNode {
+		return nil, errors.New("failed to parse the config file: it is not a mapping of keys to values")
+	}
+	if err := f.reload(); err != nil {
+		return nil, fmt.Errorf("failed to parse the config file: %w", err)
+	}
+	return f, nil
+}
+
+// Edit loads the config file at path, lets fn change its values and saves it.
+// Only the values fn changes are written, the rest of the file stays as it is.
+func Edit(path string, fn func(cfg *Config) error) error {
+	f, err := Load(path)
+	if err != nil {
+		return err
+	}
+	cfg := f.Config()
+	if err := fn(&cfg); err != nil {
+		return err
+	}
+	if err := f.SetConfig(cfg); err != nil {
+		return err
+	}
+	return f.Save()
+}
+
+// Config returns the values of the file.
+func (f *File) Config() Config {
+	cfg := f.saved
+	// The maps are copied, so that changing them leaves saved as it was read.
+	cfg.Profiles = copyMap(f.saved.Profiles)
+	cfg.Aliases = copyMap(f.saved.Aliases)
+	cfg.MCP.Auth.Users = copyMap(f.saved.MCP.Auth.Users)
+	cfg.MCP.Tools.Scopes = copyMap(f.saved.MCP.Tools.Scopes)
+	return cfg
+}
+
+func copyMap[V any](m map[string]V) map[string]V {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// SetConfig updates the document with the values of cfg that differ from the file.
+// A value set back to its zero value is removed from the file, unless the key has another default,
+// such as mcp.audit_logging, in which case the zero value is written.
+func (f *File) SetConfig(cfg Config) error {
+	var before, after yaml.Node
+	if err := before.Encode(f.saved); err != nil {
+		return err
+	}
+	if err := after.Encode(cfg); err != nil {
+		return err
+	}
+	mergeChanges(f.root(), &before, &after, "")
+	f.saved = cfg
+	return nil
+}
+
+// mergeChanges applies the differences between the mappings before and after to the mapping dst,
+// found at the dotted prefix of the config.
+func mergeChanges(dst, before, after *yaml.Node, prefix string) {
+	for i := 0; i+1 < len(after.Content); i += 2 {
+		key, value := after.Content[i].Value, after.Content[i+1]
+		old := mappingValue(before, key)
+		if old != nil && equalNodes(old, value) {
+			continue
+		}
+		current := mappingValue(dst, key)
+		switch {
+		case old != nil && old.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode &&
+			current != nil && current.Kind == yaml.MappingNode:
+			mergeChanges(current, old, value, prefix+key+".")
+		case isZero(value) && zeroDefault(prefix+key) == nil:
+			removeMappingValue(dst, key)
+		default:
+			setMappingValue(dst, key, value)
+		}
+	}
+	for i := 0; i+1 < len(before.Content); i += 2 {
+		key := before.Content[i].Value
+		if mappingValue(after, key) != nil {
+			continue
+		}
+		// omitempty leaves out a zero value, which must still be written over another default
+		old, current := before.Content[i+1], mappingValue(dst, key)
+		switch zero := zeroDefault(prefix + key); {
+		case zero != nil:
+			// A zero value that was already zero is left as it is
+			if !isZero(old) {
+				setMappingValue(dst, key, zero)
+			}
+		case old.Kind == yaml.MappingNode && current != nil && current.Kind == yaml.MappingNode &&
+			hasNonZeroDefaults(prefix+key+"."):
+			mergeChanges(current, old, &yaml.Node{Kind: yaml.MappingNode}, prefix+key+".")
+			if len(current.Content) == 0 {
+				removeMappingValue(dst, key)
+			}
+		default:
+			removeMappingValue(dst, key)
+		}
+	}
+}
+
+// hasNonZeroDefaults reports whether a key under the dotted prefix has a default that is not the zero value.
+func hasNonZeroDefaults(prefix string) bool {
+	for _, k := range Keys() {
+		if strings.HasPrefix(k.Name, prefix) && zeroDefault(k.Name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// zeroDefault returns the zero value of a key whose default is not the zero value, or nil for other keys.
+func zeroDefault(name string) *yaml.Node {
+	k, err := LookupKey(name)
+	if err != nil || k.Default == "" {
+		return nil
+	}
+	switch k.Type {
+	case "bool":
+		if k.Default == "false" {
+			return nil
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"}
+	case "int":
+		if k.Default == "0" {
+			return nil
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: "0"}
+	case "list":
+		return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "", Style: yaml.DoubleQuotedStyle}
+	}
+}
+
+// Set sets a dotted key like "profiles.qa.org" in the document, adding the mappings on its way.
+func (f *File) Set(key string, value any) error {
+	var v yaml.Node
+	if err := v.Encode(value); err != nil {
+		return err
+	}
+	m := f.root()
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next := mappingValue(m, part)
+		if next == nil || next.Kind != yaml.MappingNode {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(m, part, next)
+		}
+		m = next
+	}
+	setMappingValue(m, parts[len(parts)-1], &v)
+	return f.reload()
+}
+
+// Unset removes a dotted key from the document and reports whether it was there.
+// The mappings on its way are kept, so that unsetting the org of a profile does not remove the profile.
+func (f *File) Unset(key string) (bool, error) {
+	m := f.root()
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		if m = mappingValue(m, part); m == nil || m.Kind != yaml.MappingNode {
+			return false, nil
+		}
+	}
+	if !removeMappingValue(m, parts[len(parts)-1]) {
+		return false, nil
+	}
+	return true, f.reload()
+}
+
+// reload decodes the document into saved. Values of the wrong type are left out rather than
+// failing, so that 'shipyard config set' can still fix them.
+func (f *File) reload() error {
+	var cfg Config
+	if err := f.doc.Decode(&cfg); err != nil && !errors.As(err, new(*yaml.TypeError)) {
+		return err
+	}
+	f.saved = cfg
+	return nil
+}
+
+// Save writes the file atomically: readers see either the old or the new config, never a part of it.
+// The file is only readable by its owner, since it may hold tokens.
+func (f *File) Save() error {
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(f.indent)
+	if err := enc.Encode(f.doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return writeFile(f.path, b.Bytes())
+}
+
+// detectIndent returns the indentation of the first nested block mapping of a document, or 2 if it has none.
+func detectIndent(doc *yaml.Node) int {
+	var find func(n *yaml.Node) int
+	find = func(n *yaml.Node) int {
+		if n.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				if value.Kind == yaml.MappingNode && value.Style&yaml.FlowStyle == 0 && len(value.Content) > 0 {
+					return value.Content[0].Column - key.Column
+				}
+			}
+		}
+		for _, c := range n.Content {
+			if indent := find(c); indent > 0 {
+				return indent
+			}
+		}
+		return 0
+	}
+	if indent := find(doc); indent > 0 {
+		return indent
+	}
+	return 2
+}
+
+func (f *File) root() *yaml.Node {
+	return f.doc.Content[0]
+}
+
+// writeFile replaces the file at path with data through a temporary file in the same directory.
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write the config file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write the config file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write the config file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write the config file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the config file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write the config file: %w", err)
+	}
+	return nil
+}
+
+// mappingValue returns the value of a key in a mapping node, comparing keys case-insensitively like viper.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if i := mappingIndex(m, key); i >= 0 {
+		return m.Content[i+1]
+	}
+	return nil
+}
+
+func mappingIndex(m *yaml.Node, key string) int {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if strings.EqualFold(m.Content[i].Value, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// setMappingValue replaces the value of a key in place, keeping its comments, or appends the key.
+func setMappingValue(m *yaml.Node, key string, value *yaml.Node) {
+	if i := mappingIndex(m, key); i >= 0 {
+		old := m.Content[i+1]
+		value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+		m.Content[i+1] = value
+		return
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+func removeMappingValue(m *yaml.Node, key string) bool {
+	i := mappingIndex(m, key)
+	if i < 0 {
+		return false
+	}
+	m.Content = append(m.Content[:i], m.Content[i+2:]...)
+	return true
+}
+
+func equalNodes(a, b *yaml.Node) bool {
+	if a.Kind != b.Kind || a.Value != b.Value || len(a.Content) != len(b.Content) {
+		return false
+	}
+	if a.Kind == yaml.ScalarNode && a.Tag != b.Tag {
+		return false
+	}
+	for i := range a.Content {
+		if !equalNodes(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isZero reports whether a node holds the zero value of its type: an empty string, false, 0 or an empty list.
+// An empty mapping is not, since an empty profile still counts.
+func isZero(n *yaml.Node) bool {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return isNull(n) || n.Value == "" || (n.Tag == "!!bool" && n.Value == "false") || (n.Tag == "!!int" && n.Value == "0")
+	case yaml.SequenceNode:
+		return len(n.Content) == 0
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEdit(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	b := `# Shipyard CLI
+org: acme # the default org
+api_token: secret
+profiles:
+    staging:
+        api_url: https://staging.example.com/api/v1
+        auth_token: staging-token
+aliases:
+    web: 8f3c1a
+editor: vim
+`
+	if err := os.WriteFile(path, []byte(b), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Edit(path, func(cfg *Config) error {
+		cfg.Org = "fleet"
+		cfg.Token = ""
+		cfg.UpdateProfile("staging", func(p *Profile) { p.AuthToken = "" })
+		cfg.UpdateProfile("qa", func(p *Profile) { p.Org = "qa" })
+		cfg.Aliases["api"] = "77ab01"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Shipyard CLI
+org: fleet # the default org
+profiles:
+    staging:
+        api_url: https://staging.example.com/api/v1
+    qa:
+        org: qa
+aliases:
+    web: 8f3c1a
+    api: 77ab01
+editor: vim
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Error(diff)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected the file to be saved with 0600, but got %o", perm)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected the temporary file to be gone, but found %d files", len(entries))
+	}
+}
+
+func TestFileSetUnset(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("verbose: maybe # fix me\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Set("verbose", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Set("mcp.port", 9090); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := f.Unset("org"); err != nil || ok {
+		t.Errorf("expected org not to be found, but got %v, %v", ok, err)
+	}
+	if err := f.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg := f.Config(); !cfg.Verbose || cfg.MCP.Port != 9090 {
+		t.Errorf("expected the values to be set, but got %+v", cfg)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "verbose: true # fix me\nmcp:\n  port: 9090\n"
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestEditZeroValues(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	b := `verbose: true
+mcp:
+  host: 0.0.0.0
+  audit_logging: true
+  read_only: true
+`
+	if err := os.WriteFile(path, []byte(b), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Edit(path, func(cfg *Config) error {
+		cfg.Verbose = false
+		cfg.MCP.Host = ""
+		cfg.MCP.AuditLogging = false
+		cfg.MCP.ReadOnly = false
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Zero values are removed where they are the default, and written where the default differs
+	want := `mcp:
+  host: ""
+  audit_logging: false
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/agnivade/levenshtein"
+)
+
+// Key describes a config key of Config. The names of map entries are written as <name>,
+// e.g. "profiles.<name>.org".
+type Key struct {
+	Name string `json:"name"`
+	// Type is string, bool, int or list, a comma-separated list on the command line.
+	Type string `json:"type"`
+	// Values are the values the key accepts, if it is limited to a few.
+	Values  []string `json:"values,omitempty"`
+	Default string   `json:"default,omitempty"`
+	// Secret keys hold tokens, which are masked.
+	Secret bool `json:"secret,omitempty"`
+	// Credential keys hold API tokens, which are only saved with 'shipyard set token' or 'shipyard login'.
+	Credential bool `json:"credential,omitempty"`
+
+	// pattern is the name in Keys, which differs from Name for map entries.
+	pattern string
+}
+
+// keyValues limits the values of some keys.
+var keyValues = map[string][]string{
+	"credential_store": {"auto", "keyring", "file", "config"},
+	"mcp.transport":    {"stdio", "http"},
+}
+
+// keyChecks validate the values of some keys further.
+var keyChecks = map[string]func(value any) error{
+	"api_url":                 checkURL,
+	"profiles.<name>.api_url": checkURL,
+	"mcp.port":                checkPort,
+	"mcp.max_concurrency":     checkPositive,
+	"mcp.audit_log_max_size":  checkPositive,
+	"mcp.session_timeout":     checkPositive,
+	"mcp.max_sessions":        checkPositive,
+}
+
+// Keys returns every key of Config, in the order of its fields.
+func Keys() []Key {
+	var keys []Key
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := prefix + yamlName(f)
+			switch ft := f.Type; ft.Kind() {
+			case reflect.Struct:
+				walk(ft, name+".")
+			case reflect.Map:
+				if ft.Elem().Kind() == reflect.Struct {
+					walk(ft.Elem(), name+".<name>.")
+				} else {
+					keys = append(keys, newKey(name+".<name>", ft.Elem(), ""))
+				}
+			default:
+				keys = append(keys, newKey(name, ft, f.Tag.Get("default")))
+			}
+		}
+	}
+	walk(reflect.TypeFor[Config](), "")
+	return keys
+}
+
+func newKey(name string, t reflect.Type, def string) Key {
+	k := Key{Name: name, Default: def, Values: keyValues[name], pattern: name}
+	switch t.Kind() {
+	case reflect.Bool:
+		k.Type = "bool"
+	case reflect.Int:
+		k.Type = "int"
+	case reflect.Slice:
+		k.Type = "list"
+	default:
+		k.Type = "string"
+	}
+	k.Secret = strings.HasSuffix(name, "token") || strings.HasSuffix(name, "tokens")
+	// The tokens of the MCP server are not kept in the credential store
+	k.Credential = k.Secret && !strings.HasPrefix(name, "mcp.")
+	return k
+}
+
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	return name
+}
+
+// LookupKey returns the key a dotted name like "org" or "profiles.qa.org" refers to, named after it.
+// An unknown name is an error that suggests the closest key.
+func LookupKey(name string) (Key, error) {
+	name = strings.ToLower(name)
+	parts := strings.Split(name, ".")
+	for _, k := range Keys() {
+		if matchKey(k.pattern, parts) {
+			if err := checkEntryNames(k.pattern, parts); err != nil {
+				return Key{}, err
+			}
+			k.Name = name
+			return k, nil
+		}
+	}
+	return Key{}, unknownKey(name)
+}
+
+func matchKey(pattern string, parts []string) bool {
+	p := strings.Split(pattern, ".")
+	return len(p) == len(parts) && matchPrefix(p, parts)
+}
+
+// matchPrefix reports whether parts match the start of the parts p of a key pattern.
+func matchPrefix(p, parts []string) bool {
+	if len(parts) > len(p) {
+		return false
+	}
+	for i := range parts {
+		if parts[i] == "" || (p[i] != "<name>" && p[i] != parts[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkEntryNames rejects profile names viper could not read back.
+func checkEntryNames(pattern string, parts []string) error {
+	if strings.HasPrefix(pattern, "profiles.") {
+		return ValidateProfileName(parts[1])
+	}
+	return nil
+}
+
+// unknownKey returns an error for an unknown name, with the closest key if one is near enough.
+func unknownKey(name string) error {
+	name = strings.ToLower(name)
+	parts := strings.Split(name, ".")
+	best, bestDistance := "", -1
+	for _, k := range Keys() {
+		// Compare with the entry names of name filled in, so that "profiles.qa.orgg" suggests "profiles.qa.org".
+		candidate := strings.Split(k.Name, ".")
+		for i, p := range candidate {
+			if p == "<name>" && i < len(parts) && parts[i] != "" {
+				candidate[i] = parts[i]
+			}
+		}
+		c := strings.Join(candidate, ".")
+		if d := levenshtein.ComputeDistance(name, c); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	if bestDistance >= 0 && bestDistance <= max(2, len(name)/3) {
+		return fmt.Errorf("unknown config key %q, did you mean %q?", name, best)
+	}
+	return fmt.Errorf("unknown config key %q, see 'shipyard config list' for the supported keys", name)
+}
+
+// Parse converts a value given on the command line to the type of the key and validates it.
+func (k Key) Parse(value string) (any, error) {
+	var v any
+	switch k.Type {
+	case "bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false, not %q", k.Name, value)
+		}
+		v = b
+	case "int":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a whole number, not %q", k.Name, value)
+		}
+		v = n
+	case "list":
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v = items
+	default:
+		v = value
+	}
+	if err := k.check(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (k Key) check(v any) error {
+	if len(k.Values) > 0 && v != "" && !slices.Contains(k.Values, fmt.Sprint(v)) {
+		return fmt.Errorf("%s must be one of %s, not %q", k.Name, strings.Join(k.Values, ", "), v)
+	}
+	if check, ok := keyChecks[k.pattern]; ok {
+		if err := check(v); err != nil {
+			return fmt.Errorf("%s: %w", k.Name, err)
+		}
+	}
+	return nil
+}
+
+func checkURL(value any) error {
+	s, _ := value.(string)
+	if s == "" {
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http or https URL", s)
+	}
+	return nil
+}
+
+func checkPort(value any) error {
+	if n, _ := value.(int); n < 1 || n > 65535 {
+		return fmt.Errorf("%v is not a port number between 1 and 65535", value)
+	}
+	return nil
+}
+
+func checkPositive(value any) error {
+	if n, _ := value.(int); n < 1 {
+		return fmt.Errorf("%v must be at least 1", value)
+	}
+	return nil
+}
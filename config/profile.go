@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// DefaultProfile names the settings at the top level of the config, used when no profile is selected.
+const DefaultProfile = "default"
+
+// ModeURLs are the API URLs of the built-in profiles, which work without being added to the config.
+// A profile of the same name in the config can still change their settings.
+var ModeURLs = map[string]string{
+	"local":  "http://localhost:8080/api/v1",
+	"qa":     "https://qa.shipyard.build/api/v1",
+	"werzer": "https://werzer.shipyard.build/api/v1",
+	"prod":   "https://shipyard.build/api/v1",
+}
+
+// Profile names are stored as config keys, which viper lowercases and splits on dots.
+var profileName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+type Profile struct {
+	APIURL  string `yaml:"api_url,omitempty"`
+	Org     string `yaml:"org,omitempty"`
+	Verbose bool   `yaml:"verbose,omitempty"`
+	// AuthToken is only used when tokens are kept in the config file, see 'credential_store'.
+	AuthToken string `yaml:"auth_token,omitempty"`
+}
+
+// ValidateProfileName checks that name can be used for a new profile.
+func ValidateProfileName(name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("%q names the settings at the top level of the config, choose another name", DefaultProfile)
+	}
+	if !profileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use lowercase letters, digits, '-' and '_', starting with a letter or digit", name)
+	}
+	return nil
+}
+
+// ActiveProfile returns the profile selected by --profile, SHIPYARD_PROFILE or 'shipyard profile use',
+// or an empty string for the default one.
+func ActiveProfile() string {
+	name := strings.ToLower(viper.GetString("profile"))
+	if name == DefaultProfile {
+		return ""
+	}
+	return name
+}
+
+// LoadProfile returns the settings of a profile from the config, or of a built-in profile.
+func LoadProfile(name string) (Profile, bool) {
+	name = strings.ToLower(name)
+	_, saved := viper.GetStringMap("profiles")[name]
+	modeURL, builtIn := ModeURLs[name]
+	if !saved && !builtIn {
+		return Profile{}, false
+	}
+
+	key := "profiles." + name + "."
+	p := Profile{
+		APIURL:    viper.GetString(key + "api_url"),
+		Org:       viper.GetString(key + "org"),
+		Verbose:   viper.GetBool(key + "verbose"),
+		AuthToken: viper.GetString(key + "auth_token"),
+	}
+	if p.APIURL == "" {
+		p.APIURL = modeURL
+	}
+	return p, true
+}
+
+// TopLevel returns the settings of the default profile, which ApplyProfile may have replaced.
+func TopLevel() Profile {
+	get := func(key string) any {
+		if value, ok := appliedDefaults[key]; ok {
+			return value
+		}
+		return viper.Get(key)
+	}
+	var p Profile
+	p.APIURL, _ = get("api_url").(string)
+	p.Org, _ = get("org").(string)
+	switch v := get("verbose").(type) {
+	case bool:
+		p.Verbose = v
+	case string:
+		p.Verbose = v == "true"
+	}
+	if p.APIURL == "" {
+		p.APIURL = ModeURLs["prod"]
+	}
+	return p
+}
+
+// ProfileNames returns the profiles saved in the config and the built-in ones, sorted.
+func ProfileNames() []string {
+	seen := make(map[string]bool)
+	for name := range viper.GetStringMap("profiles") {
+		seen[name] = true
+	}
+	for name := range ModeURLs {
+		// werzer is an internal API, only listed once saved.
+		if name != "werzer" {
+			seen[name] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// appliedDefaults keeps the values ApplyProfile replaced, so that applying another profile
+// in the same run starts from them again.
+var appliedDefaults = make(map[string]any)
+
+// ApplyProfile makes the settings of the active profile take the place of the top-level ones.
+// Flags and environment variables still take precedence: explicit reports whether one sets a key.
+func ApplyProfile(explicit func(key string) bool) error {
+	for key, value := range appliedDefaults {
+		viper.Set(key, value)
+	}
+
+	name := ActiveProfile()
+	if name == "" {
+		return nil
+	}
+	p, ok := LoadProfile(name)
+	if !ok {
+		return fmt.Errorf("profile %q not found, add it with 'shipyard profile add %s --api-url ...'", name, name)
+	}
+
+	values := map[string]any{"api_url": p.APIURL, "org": p.Org}
+	if p.Verbose {
+		values["verbose"] = true
+	}
+	for key, value := range values {
+		if value == "" || explicit(key) {
+			continue
+		}
+		if _, ok := appliedDefaults[key]; !ok {
+			appliedDefaults[key] = viper.Get(key)
+		}
+		viper.Set(key, value)
+	}
+	return nil
+}
+
+// EnvSet reports whether the environment variable of a key is set, e.g. SHIPYARD_ORG for org.
+// Like viper, it ignores empty variables.
+func EnvSet(key string) bool {
+	return os.Getenv("SHIPYARD_"+strings.ToUpper(key)) != ""
+}
+
+// SaveOrg sets the org of the active profile in the config file,
+// or the top-level one if the default profile is in use.
+func SaveOrg(name string) error {
+	viper.Set("org", name)
+	profile := ActiveProfile()
+	return Edit(viper.ConfigFileUsed(), func(cfg *Config) error {
+		if profile == "" {
+			cfg.Org = name
+		} else {
+			cfg.UpdateProfile(profile, func(p *Profile) { p.Org = name })
+		}
+		return nil
+	})
+}
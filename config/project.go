@@ -0,0 +1,171 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFileName is the project config, looked up from the working directory to the root of the file system.
+const ProjectFileName = ".shipyard.yaml"
+
+// projectKeys are the values a project config may set. The API URL, tokens and profiles are left out,
+// so that a repository cannot send the token of whoever checks it out to another server.
+var projectKeys = []string{"org", "repo_name", "aliases", "service", "upload_ignore"}
+
+// sources records the files the config was read from, for 'shipyard config view --show-origin'.
+var sources struct {
+	user, project             string
+	userValues, projectValues map[string]any
+}
+
+// FindProjectFile returns the closest project config in dir or its parents, or an empty string.
+func FindProjectFile(dir string) string {
+	for {
+		p := filepath.Join(dir, ProjectFileName)
+		if fi, err := os.Stat(p); err == nil && fi.Mode().IsRegular() {
+			return p
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// MergeProjectFile merges the project config found from dir over the user config read by viper.
+// It returns the path of the project config, or an empty string if there is none.
+func MergeProjectFile(dir string) (string, error) {
+	sources.user = viper.ConfigFileUsed()
+	sources.userValues = readValues(sources.user)
+
+	p := FindProjectFile(dir)
+	if p == "" {
+		return "", nil
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+	values := make(map[string]any)
+	if err := yaml.Unmarshal(b, &values); err != nil {
+		return "", fmt.Errorf("failed to parse %s, check YAML for syntax errors: %w", p, err)
+	}
+	for key := range values {
+		if !slices.Contains(projectKeys, key) {
+			return "", fmt.Errorf("%s: %q cannot be set in a project config, only %s", p, key, strings.Join(projectKeys, ", "))
+		}
+	}
+	if err := viper.MergeConfigMap(values); err != nil {
+		return "", fmt.Errorf("failed to merge %s: %w", p, err)
+	}
+
+	sources.project = p
+	sources.projectValues = values
+	return p, nil
+}
+
+// RepoName returns the repo name to filter environments by: the --repo-name flag,
+// or the 'repo_name' value a project config sets for the app of a monorepo.
+func RepoName() string {
+	if name := viper.GetString("repo-name"); name != "" {
+		return name
+	}
+	return viper.GetString("repo_name")
+}
+
+// InProject reports whether the project config sets a key, e.g. "org" or "aliases.web".
+func InProject(key string) bool {
+	return lookup(sources.projectValues, key)
+}
+
+// Setting is a config value and where it comes from.
+type Setting struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Origin string `json:"origin,omitempty"`
+}
+
+// Settings returns the values set in the config files, by environment variables, by the active profile
+// or by the flags flagChanged reports as set, sorted by key. Tokens are masked.
+func Settings(flagChanged func(key string) bool) []Setting {
+	var settings []Setting
+	for _, key := range viper.AllKeys() {
+		origin := origin(key, flagChanged)
+		if origin == "" {
+			continue
+		}
+		value := viper.Get(key)
+		if k, err := LookupKey(key); err == nil && k.Secret && value != "" && value != nil {
+			value = "********"
+		}
+		settings = append(settings, Setting{Key: key, Value: value, Origin: origin})
+	}
+	slices.SortFunc(settings, func(a, b Setting) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return settings
+}
+
+// origin follows the precedence of viper, with the active profile between the environment and the files.
+func origin(key string, flagChanged func(key string) bool) string {
+	switch {
+	case flagChanged(key):
+		return "flag --" + key
+	case EnvSet(key):
+		return "env SHIPYARD_" + strings.ToUpper(key)
+	}
+	if _, ok := appliedDefaults[key]; ok && ActiveProfile() != "" {
+		name := ActiveProfile()
+		if lookup(sources.userValues, "profiles."+name+"."+key) {
+			return fmt.Sprintf("profile %s in %s", name, sources.user)
+		}
+		return fmt.Sprintf("profile %s (built in)", name)
+	}
+	switch {
+	case InProject(key):
+		return sources.project
+	case lookup(sources.userValues, key):
+		return sources.user
+	}
+	return ""
+}
+
+// readValues parses a config file, or returns nil if it cannot.
+func readValues(path string) map[string]any {
+	if path == "" {
+		return nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	values := make(map[string]any)
+	if yaml.Unmarshal(b, &values) != nil {
+		return nil
+	}
+	return values
+}
+
+// lookup reports whether values holds a dotted key, comparing keys case-insensitively like viper.
+func lookup(values map[string]any, key string) bool {
+	first, rest, nested := strings.Cut(key, ".")
+	for k, v := range values {
+		if !strings.EqualFold(k, first) {
+			continue
+		}
+		if !nested {
+			return true
+		}
+		if m, ok := v.(map[string]any); ok {
+			return lookup(m, rest)
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestMergeProjectFile(t *testing.T) {
+	defer viper.Reset()
+	root := t.TempDir()
+	dir := filepath.Join(root, "apps", "web")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindProjectFile(dir); got != "" {
+		t.Fatalf("expected no project config, but got %s", got)
+	}
+
+	project := filepath.Join(root, ProjectFileName)
+	if err := os.WriteFile(project, []byte("org: acme\naliases:\n  web: 8f3c1a\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("aliases", map[string]any{"api": "77ab01"})
+	got, err := MergeProjectFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != project {
+		t.Errorf("expected %s, but got %s", project, got)
+	}
+	if org := viper.GetString("org"); org != "acme" {
+		t.Errorf("expected the org of the project, but got %q", org)
+	}
+	if !InProject("aliases.web") || InProject("aliases.api") {
+		t.Error("expected only the alias of the project to come from it")
+	}
+
+	if err := os.WriteFile(project, []byte("profiles:\n  evil:\n    api_url: https://example.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MergeProjectFile(dir); err == nil {
+		t.Error("expected an error for a key a project config cannot set")
+	}
+}
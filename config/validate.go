@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is an error in a config file. Line and Column are 0 when the YAML parser does not report them.
+type Problem struct {
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	switch {
+	case p.Column > 0:
+		return fmt.Sprintf("line %d, column %d: %s", p.Line, p.Column, p.Message)
+	case p.Line > 0:
+		return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+	}
+	return p.Message
+}
+
+// ValidateFile reads the config file at path and returns its problems, see Validate.
+func ValidateFile(path string) ([]Problem, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the config file: %w", err)
+	}
+	return Validate(b), nil
+}
+
+// Validate parses a config and checks its keys and the types of their values against Config.
+// A syntax error stops the check, so it is then the only problem returned.
+func Validate(b []byte) []Problem {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return []Problem{syntaxProblem(err)}
+	}
+	if len(doc.Content) == 0 || isNull(doc.Content[0]) {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return []Problem{{Line: root.Line, Column: root.Column, Message: "the config must be a mapping of keys to values"}}
+	}
+	var problems []Problem
+	validateMapping(root, nil, &problems)
+	return problems
+}
+
+var yamlLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// syntaxProblem turns an error of the YAML parser, like "yaml: line 3: did not find expected key", into a Problem.
+func syntaxProblem(err error) Problem {
+	m := yamlLine.FindStringSubmatch(err.Error())
+	if m == nil {
+		return Problem{Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+	line, _ := strconv.Atoi(m[1])
+	return Problem{Line: line, Message: m[2]}
+}
+
+func validateMapping(m *yaml.Node, path []string, problems *[]Problem) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		k, v := m.Content[i], m.Content[i+1]
+		parts := append(path[:len(path):len(path)], strings.ToLower(k.Value))
+		name := strings.Join(parts, ".")
+		add := func(format string, args ...any) {
+			*problems = append(*problems, Problem{Line: k.Line, Column: k.Column, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if len(parts) == 2 && parts[0] == "profiles" {
+			if err := ValidateProfileName(parts[1]); err != nil {
+				add("%v", err)
+				continue
+			}
+		}
+		if key, err := LookupKey(name); err == nil {
+			if msg := checkValue(key, v); msg != "" {
+				add("%s", msg)
+			}
+			continue
+		}
+		if !isPrefix(parts) {
+			add("%v", unknownKey(name))
+			continue
+		}
+		switch {
+		case isNull(v):
+		case v.Kind == yaml.MappingNode:
+			validateMapping(v, parts, problems)
+		default:
+			add("%s must be a mapping of keys to values", name)
+		}
+	}
+}
+
+// isPrefix reports whether parts lead to keys nested below them, like "profiles.qa" or "mcp".
+func isPrefix(parts []string) bool {
+	for _, k := range Keys() {
+		p := strings.Split(k.pattern, ".")
+		if len(parts) < len(p) && matchPrefix(p, parts) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkValue returns why the node v is not a valid value of key, or an empty string.
+func checkValue(key Key, v *yaml.Node) string {
+	if isNull(v) {
+		return ""
+	}
+	if key.Type == "list" {
+		if v.Kind != yaml.SequenceNode {
+			return fmt.Sprintf("%s must be a list", key.Name)
+		}
+		for _, item := range v.Content {
+			if item.Kind != yaml.ScalarNode {
+				return fmt.Sprintf("%s must be a list of strings", key.Name)
+			}
+		}
+		return ""
+	}
+	if v.Kind != yaml.ScalarNode {
+		return fmt.Sprintf("%s must be a %s, not a %s", key.Name, key.Type, kindName(v.Kind))
+	}
+	if _, err := key.Parse(v.Value); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func isNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null"
+}
+
+func kindName(kind yaml.Kind) string {
+	switch kind {
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.SequenceNode:
+		return "list"
+	}
+	return "value"
+}
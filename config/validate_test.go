@@ -0,0 +1,93 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLookupKey(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		wantType string
+		wantErr  string
+	}{
+		{name: "org", wantType: "string"},
+		{name: "MCP.Port", wantType: "int"},
+		{name: "profiles.staging.verbose", wantType: "bool"},
+		{name: "aliases.web", wantType: "string"},
+		{name: "upload_ignore", wantType: "list"},
+		{name: "ogr", wantErr: `unknown config key "ogr", did you mean "org"?`},
+		{name: "profiles.staging", wantErr: `unknown config key "profiles.staging", did you mean "profiles.staging.org"?`},
+		{name: "colour_scheme", wantErr: `unknown config key "colour_scheme", see 'shipyard config list' for the supported keys`},
+		{name: "profiles.default.org", wantErr: `"default" names the settings at the top level of the config, choose another name`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			k, err := LookupKey(tt.name)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, but got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if k.Type != tt.wantType {
+				t.Errorf("expected type %s, but got %s", tt.wantType, k.Type)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		config string
+		want   []Problem
+	}{
+		{
+			name:   "valid",
+			config: "org: acme\nverbose: true\nprofiles:\n  qa:\n    org: qa\naliases:\n  web: 8f3c1a\nupload_ignore: [dist]\nmcp:\n  port: 9090\n",
+		},
+		{
+			name:   "empty",
+			config: "",
+		},
+		{
+			name:   "syntax",
+			config: "org: acme\n  verbose: true\n",
+			want:   []Problem{{Line: 2, Message: "mapping values are not allowed in this context"}},
+		},
+		{
+			name:   "types",
+			config: "verbose: 3\nmcp:\n  port: http\naliases: web\nupload_ignore: dist\n",
+			want: []Problem{
+				{Line: 1, Column: 1, Message: `verbose must be true or false, not "3"`},
+				{Line: 3, Column: 3, Message: `mcp.port must be a whole number, not "http"`},
+				{Line: 4, Column: 1, Message: "aliases must be a mapping of keys to values"},
+				{Line: 5, Column: 1, Message: "upload_ignore must be a list"},
+			},
+		},
+		{
+			name:   "unknown keys",
+			config: "orgs: acme\nprofiles:\n  Bad.Name:\n    org: x\n",
+			want: []Problem{
+				{Line: 1, Column: 1, Message: `unknown config key "orgs", did you mean "org"?`},
+				{Line: 3, Column: 3, Message: `invalid profile name "bad.name": use lowercase letters, digits, '-' and '_', starting with a letter or digit`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if diff := cmp.Diff(Validate([]byte(tt.config)), tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
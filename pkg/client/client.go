@@ -1,9 +1,14 @@
 package client
 
-import "github.com/shipyard/shipyard-cli/pkg/requests"
+import (
+	"context"
+	"errors"
+
+	"github.com/shipyard/shipyard-cli/pkg/requests"
+)
 
 type Requester interface {
-	Do(method string, uri string, contentType string, body any) ([]byte, error)
+	Do(ctx context.Context, method string, uri string, contentType string, body any) ([]byte, error)
 }
 
 type Client struct {
@@ -14,3 +19,25 @@ type Client struct {
 func New(r requests.Requester, orgLookupFn func() string) Client {
 	return Client{Requester: r, OrgLookupFn: orgLookupFn}
 }
+
+// TokenRequester is a Requester that can send its requests with another API token.
+type TokenRequester interface {
+	requests.Requester
+	WithToken(token string) requests.Requester
+}
+
+// As returns a client that acts as another API user, with its token and org.
+// Empty values keep those of c.
+func (c Client) As(token, org string) (Client, error) {
+	if token != "" {
+		r, ok := c.Requester.(TokenRequester)
+		if !ok {
+			return Client{}, errors.New("the client cannot send requests with another API token")
+		}
+		c.Requester = r.WithToken(token)
+	}
+	if org != "" {
+		c.OrgLookupFn = func() string { return org }
+	}
+	return c, nil
+}
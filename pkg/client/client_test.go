@@ -1,8 +1,11 @@
 package client
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -16,7 +19,7 @@ func TestEnvByID(t *testing.T) {
 	client, cleanup := setup()
 	defer cleanup()
 
-	e, err := client.EnvByID("123abc")
+	e, err := client.EnvByID(context.Background(), "123abc")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -31,7 +34,7 @@ func TestAllServices(t *testing.T) {
 	client, cleanup := setup()
 	defer cleanup()
 
-	svcs, err := client.AllServices("123abc")
+	svcs, err := client.AllServices(context.Background(), "123abc")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -46,7 +49,7 @@ func TestFindService(t *testing.T) {
 	client, cleanup := setup()
 	defer cleanup()
 
-	got, err := client.FindService("web", "123abc")
+	got, err := client.FindService(context.Background(), "web", "123abc")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -61,6 +64,66 @@ func TestFindService(t *testing.T) {
 	}
 }
 
+func TestListEnvironments(t *testing.T) {
+	client, cleanup := setup()
+	defer cleanup()
+
+	var ids []string
+	for env, err := range client.ListEnvironments(context.Background(), EnvironmentFilter{Branch: "main"}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, env.ID)
+	}
+
+	want := []string{"env-1", "env-2", "env-3"}
+	if !cmp.Equal(ids, want) {
+		t.Error(cmp.Diff(ids, want))
+	}
+}
+
+func TestListEnvironmentsStopsEarly(t *testing.T) {
+	client, cleanup := setup()
+	defer cleanup()
+
+	var ids []string
+	for env, err := range client.ListEnvironments(context.Background(), EnvironmentFilter{Branch: "main"}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, env.ID)
+		break
+	}
+
+	want := []string{"env-1"}
+	if !cmp.Equal(ids, want) {
+		t.Error(cmp.Diff(ids, want))
+	}
+}
+
+func TestListEnvironmentsError(t *testing.T) {
+	client, cleanup := setup()
+	defer cleanup()
+
+	var ids []string
+	var errs int
+	for env, err := range client.ListEnvironments(context.Background(), EnvironmentFilter{Branch: "broken"}) {
+		if err != nil {
+			errs++
+			continue
+		}
+		ids = append(ids, env.ID)
+	}
+
+	if errs != 1 {
+		t.Errorf("expected exactly one error, but got %d", errs)
+	}
+	want := []string{"env-1"}
+	if !cmp.Equal(ids, want) {
+		t.Error(cmp.Diff(ids, want))
+	}
+}
+
 func setup() (client Client, cleanup func()) {
 	handler := newMux()
 	server := httptest.NewServer(handler)
@@ -127,5 +190,18 @@ func newMux() *http.ServeMux {
   }
 }`))
 	})
+	mux.HandleFunc("/environment", func(w http.ResponseWriter, r *http.Request) {
+		branch := r.URL.Query().Get("branch")
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if branch == "broken" && page > 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		next := ""
+		if page < 3 {
+			next = fmt.Sprintf("/api/v1/environment?branch=%s&page=%d", branch, page+1)
+		}
+		_, _ = fmt.Fprintf(w, `{"data": [{"id": "env-%d"}], "links": {"next": %q}}`, page, next)
+	})
 	return mux
 }
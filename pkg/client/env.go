@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -10,7 +11,7 @@ import (
 )
 
 // EnvByID tries to fetch an environment given its ID.
-func (c Client) EnvByID(id string) (*types.Response, error) {
+func (c Client) EnvByID(ctx context.Context, id string) (*types.Response, error) {
 	if id == "" {
 		return nil, errors.New("environment ID is an empty string")
 	}
@@ -20,7 +21,7 @@ func (c Client) EnvByID(id string) (*types.Response, error) {
 		params["org"] = org
 	}
 
-	body, err := c.Requester.Do(http.MethodGet, uri.CreateResourceURI("", "environment", id, "", params), "application/json", nil)
+	body, err := c.Requester.Do(ctx, http.MethodGet, uri.CreateResourceURI("", "environment", id, "", params), "application/json", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -29,13 +30,13 @@ func (c Client) EnvByID(id string) (*types.Response, error) {
 }
 
 // AllEnvironmentUUIDs tries to fetch all environment by UUIDs in an org.
-func (c Client) AllEnvironmentUUIDs() (*types.UUIDResponse, error) {
+func (c Client) AllEnvironmentUUIDs(ctx context.Context) (*types.UUIDResponse, error) {
 	params := make(map[string]string)
 	if org := c.OrgLookupFn(); org != "" {
 		params["org"] = org
 	}
 
-	body, err := c.Requester.Do(http.MethodGet, uri.CreateResourceURI("", "environment/uuid", "", "", params), "application/json", nil)
+	body, err := c.Requester.Do(ctx, http.MethodGet, uri.CreateResourceURI("", "environment/uuid", "", "", params), "application/json", nil)
 	if err != nil {
 		return nil, err
 	}
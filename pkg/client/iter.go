@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"strconv"
+
+	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
+	"github.com/shipyard/shipyard-cli/pkg/types"
+)
+
+// EnvironmentFilter holds the filters accepted by the environment list endpoint.
+// Empty values are not sent.
+type EnvironmentFilter struct {
+	Name              string
+	OrgName           string
+	RepoName          string
+	Branch            string
+	PullRequestNumber string
+	Deleted           bool
+	PageSize          int
+}
+
+func (f EnvironmentFilter) params() map[string]string {
+	params := make(map[string]string)
+	if f.Name != "" {
+		params["name"] = f.Name
+	}
+	if f.OrgName != "" {
+		params["org_name"] = f.OrgName
+	}
+	if f.RepoName != "" {
+		params["repo_name"] = f.RepoName
+	}
+	if f.Branch != "" {
+		params["branch"] = f.Branch
+	}
+	if f.PullRequestNumber != "" {
+		params["pull_request_number"] = f.PullRequestNumber
+	}
+	if f.Deleted {
+		params["deleted"] = "true"
+	}
+	if f.PageSize != 0 {
+		params["page_size"] = strconv.Itoa(f.PageSize)
+	}
+	return params
+}
+
+// ListEnvironments returns an iterator over every environment that matches the filter.
+// It requests the pages one by one, following links.next until the last page.
+// If a request fails, the error is yielded once and the iteration stops.
+func (c Client) ListEnvironments(ctx context.Context, filter EnvironmentFilter) iter.Seq2[types.Environment, error] {
+	params := filter.params()
+	c.addOrg(params)
+
+	return paginate(ctx, func(page int) ([]types.Environment, types.Links, error) {
+		params["page"] = strconv.Itoa(page)
+		body, err := c.Requester.Do(ctx, http.MethodGet, uri.CreateResourceURI("", "environment", "", "", params), "application/json", nil)
+		if err != nil {
+			return nil, types.Links{}, err
+		}
+		r, err := types.UnmarshalManyEnvs(body)
+		if err != nil {
+			return nil, types.Links{}, err
+		}
+		return r.Data, r.Links, nil
+	})
+}
+
+// ListSnapshots returns an iterator over every volume snapshot in an environment.
+// It behaves like ListEnvironments. A zero pageSize uses the API default.
+func (c Client) ListSnapshots(ctx context.Context, envID string, pageSize int) iter.Seq2[types.Snapshot, error] {
+	params := make(map[string]string)
+	c.addOrg(params)
+	if pageSize != 0 {
+		params["page_size"] = strconv.Itoa(pageSize)
+	}
+
+	return paginate(ctx, func(page int) ([]types.Snapshot, types.Links, error) {
+		if envID == "" {
+			return nil, types.Links{}, fmt.Errorf("environment ID is missing")
+		}
+		params["page"] = strconv.Itoa(page)
+		body, err := c.Requester.Do(ctx, http.MethodGet, uri.CreateResourceURI("", "environment", envID, "volume-snapshots", params), "application/json", nil)
+		if err != nil {
+			return nil, types.Links{}, err
+		}
+		var r types.SnapshotsResponse
+		if err := json.Unmarshal(body, &r); err != nil {
+			return nil, types.Links{}, fmt.Errorf("failed to unmarshal snapshots: %w", err)
+		}
+		return r.Data, r.Links, nil
+	})
+}
+
+// paginate turns a function that fetches a single page into an iterator over all items.
+// The page to fetch next is taken from the "next" link of the previous page.
+func paginate[T any](ctx context.Context, fetch func(page int) ([]T, types.Links, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		for page := 1; page > 0; {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+			items, links, err := fetch(page)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for i := range items {
+				if !yield(items[i], nil) {
+					return
+				}
+			}
+
+			next := links.NextPage()
+			if links.Next == "" || next <= page {
+				return
+			}
+			page = next
+		}
+	}
+}
+
+func (c Client) addOrg(params map[string]string) {
+	if c.OrgLookupFn == nil {
+		return
+	}
+	if org := c.OrgLookupFn(); org != "" {
+		params["org"] = org
+	}
+}
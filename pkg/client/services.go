@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"sort"
 
@@ -9,7 +10,7 @@ import (
 )
 
 // FindService tries to fetch a single service.
-func (c Client) FindService(serviceName, envID string) (*types.Service, error) {
+func (c Client) FindService(ctx context.Context, serviceName, envID string) (*types.Service, error) {
 	if serviceName == "" {
 		return nil, fmt.Errorf("service name not provided")
 	}
@@ -17,7 +18,7 @@ func (c Client) FindService(serviceName, envID string) (*types.Service, error) {
 		return nil, fmt.Errorf("environment ID not provided")
 	}
 
-	svcs, err := c.AllServices(envID)
+	svcs, err := c.AllServices(ctx, envID)
 	if err != nil {
 		return nil, err
 	}
@@ -30,12 +31,12 @@ func (c Client) FindService(serviceName, envID string) (*types.Service, error) {
 }
 
 // AllServices tries to fetch an environment's services.
-func (c Client) AllServices(envID string) ([]types.Service, error) {
+func (c Client) AllServices(ctx context.Context, envID string) ([]types.Service, error) {
 	if envID == "" {
 		return nil, fmt.Errorf("environment ID is missing")
 	}
 
-	environment, err := c.EnvByID(envID)
+	environment, err := c.EnvByID(ctx, envID)
 	if err != nil {
 		return nil, err
 	}
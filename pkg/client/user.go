@@ -0,0 +1,24 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
+	"github.com/shipyard/shipyard-cli/pkg/types"
+)
+
+// CurrentUser fetches the user the API token belongs to, which also checks that the token is valid.
+func (c Client) CurrentUser(ctx context.Context) (*types.UserAttributes, error) {
+	body, err := c.Requester.Do(ctx, http.MethodGet, uri.CreateResourceURI("", "me", "", "", nil), "application/json", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res types.UserResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+	return &res.Data.Attributes, nil
+}
@@ -0,0 +1,88 @@
+package completion
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/shipyard/shipyard-cli/auth"
+	"github.com/shipyard/shipyard-cli/config"
+)
+
+// cacheTTL is how long suggestions are reused. Shells ask again on every tab press,
+// so even a short cache saves most of the round trips to the API.
+const cacheTTL = 30 * time.Second
+
+// cache keeps suggestions on disk between completion requests, which run in separate processes.
+type cache struct {
+	dir string
+	now func() time.Time
+	// token returns the API token the suggestions are fetched with, so that the accounts
+	// of several profiles on the same API do not share them.
+	token func() string
+}
+
+type cacheEntry struct {
+	Time        time.Time `json:"time"`
+	Suggestions []string  `json:"suggestions"`
+}
+
+func newCache() *cache {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return &cache{now: time.Now, token: apiToken}
+	}
+	return &cache{dir: filepath.Join(dir, "shipyard", "completion"), now: time.Now, token: apiToken}
+}
+
+func apiToken() string {
+	token, _ := auth.APIToken()
+	return token
+}
+
+// get returns the suggestions stored under key if they are recent enough,
+// or fetches and stores them. The API URL, the org, the profile and the token are part of the key.
+// Errors are not cached.
+func (c *cache) get(key []string, fetch func() ([]string, error)) ([]string, error) {
+	if c.dir == "" {
+		return fetch()
+	}
+	path := filepath.Join(c.dir, c.fileName(key))
+
+	if b, err := os.ReadFile(path); err == nil {
+		var entry cacheEntry
+		if json.Unmarshal(b, &entry) == nil && c.now().Sub(entry.Time) < cacheTTL {
+			return entry.Suggestions, nil
+		}
+	}
+
+	suggestions, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if b, err := json.Marshal(cacheEntry{Time: c.now(), Suggestions: suggestions}); err == nil {
+		// A cache that cannot be written only makes completion slower.
+		if os.MkdirAll(c.dir, 0o700) == nil {
+			_ = os.WriteFile(path, b, 0o600)
+		}
+	}
+	return suggestions, nil
+}
+
+func (c *cache) fileName(key []string) string {
+	var account string
+	if c.token != nil {
+		// Only a hash of the token goes into the key.
+		sum := sha256.Sum256([]byte(c.token()))
+		account = hex.EncodeToString(sum[:])
+	}
+	parts := append([]string{viper.GetString("api_url"), viper.GetString("org"), config.ActiveProfile(), account}, key...)
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:16]) + ".json"
+}
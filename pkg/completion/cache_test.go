@@ -0,0 +1,82 @@
+package completion
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := &cache{dir: t.TempDir(), now: func() time.Time { return now }}
+
+	calls := 0
+	fetch := func() ([]string, error) {
+		calls++
+		return []string{"web\tports 8080", "worker"}, nil
+	}
+
+	for range 2 {
+		got, err := c.get([]string{"services", "env-1"}, fetch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"web\tports 8080", "worker"}; !cmp.Equal(got, want) {
+			t.Error(cmp.Diff(want, got))
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected the second lookup to be cached, but fetched %d times", calls)
+	}
+
+	if _, err := c.get([]string{"services", "env-2"}, fetch); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("expected another environment to be fetched, but fetched %d times", calls)
+	}
+
+	now = now.Add(cacheTTL)
+	if _, err := c.get([]string{"services", "env-1"}, fetch); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("expected an expired entry to be fetched again, but fetched %d times", calls)
+	}
+
+	failing := func() ([]string, error) { return nil, errors.New("boom") }
+	for range 2 {
+		if _, err := c.get([]string{"orgs"}, failing); err == nil {
+			t.Fatal("expected the error to be returned, not cached")
+		}
+	}
+}
+
+func TestCacheSeparatesAccounts(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	token := "first-token"
+	c := &cache{dir: t.TempDir(), now: func() time.Time { return now }, token: func() string { return token }}
+
+	calls := 0
+	fetch := func() ([]string, error) {
+		calls++
+		return []string{token}, nil
+	}
+
+	if _, err := c.get([]string{"orgs"}, fetch); err != nil {
+		t.Fatal(err)
+	}
+	token = "second-token"
+	got, err := c.get([]string{"orgs"}, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"second-token"}; !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if calls != 2 {
+		t.Errorf("expected another token to be fetched again, but fetched %d times", calls)
+	}
+}
@@ -1,25 +1,279 @@
 package completion
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/shipyard/shipyard-cli/config"
 	"github.com/shipyard/shipyard-cli/pkg/client"
+	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
+	"github.com/shipyard/shipyard-cli/pkg/resolve"
+	"github.com/shipyard/shipyard-cli/pkg/services/org"
+	"github.com/shipyard/shipyard-cli/pkg/types"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
+// Modes are the API endpoints 'shipyard mode' switches between.
+var Modes = []string{"local", "qa", "prod"}
+
 type Completion struct {
 	client client.Client
+	cache  *cache
 }
 
 func New(cl client.Client) Completion {
-	return Completion{client: cl}
+	return Completion{client: cl, cache: newCache()}
+}
+
+// RegisterFlags adds completion to the flags of cmd and its subcommands that name
+// an environment, a service, a volume, a snapshot or an org.
+func (c Completion) RegisterFlags(cmd *cobra.Command) {
+	funcs := map[string]cobra.CompletionFunc{
+		"env":             c.EnvironmentUUIDs,
+		"service":         c.Services,
+		"volume":          c.Volumes,
+		"sequence-number": c.SequenceNumbers,
+	}
+	for name, fn := range funcs {
+		if cmd.Flags().Lookup(name) != nil {
+			_ = cmd.RegisterFlagCompletionFunc(name, fn)
+		}
+	}
+	if cmd.PersistentFlags().Lookup("org") != nil {
+		_ = cmd.RegisterFlagCompletionFunc("org", c.Orgs)
+	}
+	if cmd.PersistentFlags().Lookup("profile") != nil {
+		_ = cmd.RegisterFlagCompletionFunc("profile", Profiles)
+	}
+	for _, sub := range cmd.Commands() {
+		c.RegisterFlags(sub)
+	}
+}
+
+// EnvironmentArg completes the environment ID argument of a command that takes one.
+func (c Completion) EnvironmentArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return c.EnvironmentUUIDs(cmd, args, toComplete)
 }
 
 func (c Completion) EnvironmentUUIDs(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
-	resp, err := c.client.AllEnvironmentUUIDs()
+	ids, err := c.cache.get([]string{"environments"}, func() ([]string, error) {
+		resp, err := c.client.AllEnvironmentUUIDs(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, len(resp.Data))
+		copy(ids, resp.Data)
+		return ids, nil
+	})
 	if err != nil {
 		return nil, cobra.ShellCompDirectiveError
 	}
-	ids := make([]string, len(resp.Data))
-	copy(resp.Data, ids)
-	return ids, cobra.ShellCompDirectiveNoFileComp
+	return append(ids, aliases()...), cobra.ShellCompDirectiveNoFileComp
+}
+
+// Services completes the services of the environment given with --env, or of the git checkout.
+func (c Completion) Services(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	return c.forEnvironment(cmd, "services", func(envID string) ([]string, error) {
+		svcs, err := c.client.AllServices(context.Background(), envID)
+		if err != nil {
+			return nil, err
+		}
+		suggestions := make([]string, 0, len(svcs))
+		for _, svc := range svcs {
+			suggestions = append(suggestions, describe(svc.Name, "ports", strings.Join(svc.Ports, ",")))
+		}
+		return suggestions, nil
+	})
+}
+
+// Volumes completes the volumes of the environment given with --env, or of the git checkout.
+func (c Completion) Volumes(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	return c.forEnvironment(cmd, "volumes", func(envID string) ([]string, error) {
+		var resp types.VolumesResponse
+		if err := c.get(uri.CreateResourceURI("", "environment", envID, "volumes", c.orgParams()), &resp); err != nil {
+			return nil, err
+		}
+		suggestions := make([]string, 0, len(resp.Data))
+		for _, v := range resp.Data {
+			suggestions = append(suggestions, describe(v.Attributes.Name, "service", v.Attributes.ServiceName))
+		}
+		return suggestions, nil
+	})
+}
+
+// SequenceNumbers completes the snapshots of the environment given with --env, or of the git checkout.
+func (c Completion) SequenceNumbers(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	return c.forEnvironment(cmd, "snapshots", func(envID string) ([]string, error) {
+		var suggestions []string
+		for s, err := range c.client.ListSnapshots(context.Background(), envID, 0) {
+			if err != nil {
+				return nil, err
+			}
+			suggestions = append(suggestions, fmt.Sprintf("%d\t%s, created %s", s.Attributes.SequenceNumber, s.Attributes.Status, s.Attributes.CreatedAt))
+		}
+		return suggestions, nil
+	})
+}
+
+// Orgs completes the orgs the user is a member of.
+func (c Completion) Orgs(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	orgs, err := c.cache.get([]string{"orgs"}, func() ([]string, error) {
+		return org.NewOrganizationManager(c.client).List(context.Background())
+	})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return orgs, cobra.ShellCompDirectiveNoFileComp
+}
+
+// OrgArg completes the org argument of 'shipyard set org'.
+func (c Completion) OrgArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return c.Orgs(cmd, args, toComplete)
+}
+
+// ModesAndProfiles completes the first argument of 'shipyard mode': a mode, or another
+// profile of config.ProfileNames, such as one added with 'shipyard profile add'.
+func ModesAndProfiles(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	suggestions := append([]string{}, Modes...)
+	for _, name := range config.ProfileNames() {
+		if !slices.Contains(Modes, name) {
+			suggestions = append(suggestions, name+"\tprofile")
+		}
+	}
+	return suggestions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// Profiles completes the name of a profile, built-in or saved in the config.
+func Profiles(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	return append([]string{config.DefaultProfile}, config.ProfileNames()...), cobra.ShellCompDirectiveNoFileComp
+}
+
+// ProfileArg completes the profile argument of the 'shipyard profile' commands.
+func ProfileArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return Profiles(cmd, args, toComplete)
+}
+
+// ConfigKeyArg completes the key argument of the 'shipyard config' commands. Map entries are
+// completed with the profiles and aliases of the config.
+func ConfigKeyArg(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var keys []string
+	for _, k := range config.Keys() {
+		prefix, rest, ok := strings.Cut(k.Name, "<name>")
+		if !ok {
+			keys = append(keys, k.Name)
+			continue
+		}
+		for name := range viper.GetStringMap(strings.TrimSuffix(prefix, ".")) {
+			keys = append(keys, prefix+name+rest)
+		}
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
+
+// forEnvironment finds the environment a flag is completed for and caches what list returns for it.
+func (c Completion) forEnvironment(cmd *cobra.Command, kind string, list func(envID string) ([]string, error)) ([]string, cobra.ShellCompDirective) {
+	envID, err := c.environment(context.Background(), cmd)
+	if err != nil || envID == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	suggestions, err := c.cache.get([]string{kind, envID}, func() ([]string, error) {
+		return list(envID)
+	})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return suggestions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// environment returns the ID given with --env, which may be an alias.
+// Without one, it looks for a single environment of the git checkout, like the commands do,
+// but never prompts. It returns an empty ID if there is no unambiguous environment.
+func (c Completion) environment(ctx context.Context, cmd *cobra.Command) (string, error) {
+	if f := cmd.Flags().Lookup("env"); f != nil && f.Value.String() != "" {
+		if id, ok := resolve.Alias(f.Value.String()); ok {
+			return id, nil
+		}
+		return f.Value.String(), nil
+	}
+
+	checkout, err := resolve.DetectCheckout()
+	if err != nil {
+		return "", nil
+	}
+	ids, err := c.cache.get([]string{"checkout", checkout.RepoName, checkout.Branch, checkout.PullRequestNumber}, func() ([]string, error) {
+		filter := client.EnvironmentFilter{
+			RepoName:          checkout.RepoName,
+			Branch:            checkout.Branch,
+			PullRequestNumber: checkout.PullRequestNumber,
+		}
+		var ids []string
+		for env, err := range c.client.ListEnvironments(ctx, filter) {
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, env.ID)
+		}
+		return ids, nil
+	})
+	if err != nil || len(ids) != 1 {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+func (c Completion) get(url string, v any) error {
+	body, err := c.client.Requester.Do(context.Background(), http.MethodGet, url, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+func (c Completion) orgParams() map[string]string {
+	params := make(map[string]string)
+	if org := c.client.OrgLookupFn(); org != "" {
+		params["org"] = org
+	}
+	return params
+}
+
+// describe adds a description to a suggestion, which shells that support it show next to the value.
+func describe(value, label, detail string) string {
+	if detail == "" {
+		return value
+	}
+	return value + "\t" + label + " " + detail
+}
+
+// aliases returns the configured environment aliases, described by the ID they stand for.
+func aliases() []string {
+	aliases := viper.GetStringMapString("aliases")
+	suggestions := make([]string, 0, len(aliases))
+	for name, id := range aliases {
+		suggestions = append(suggestions, name+"\talias for "+id)
+	}
+	sort.Strings(suggestions)
+	return suggestions
 }
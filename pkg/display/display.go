@@ -1,9 +1,12 @@
 package display
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/fatih/color"
 )
@@ -45,3 +48,28 @@ func Fail(a any) {
 	red := color.New(color.FgRed)
 	_, _ = red.Fprint(os.Stderr, "Error:", a)
 }
+
+// Confirm prints a yes/no prompt and reads the answer from r.
+// Only "y" and "yes" (in any case) count as confirmation; an empty input does not.
+func Confirm(r io.Reader, prompt string) (bool, error) {
+	Print(prompt)
+
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// Capitalize upper-cases the first letter of s, e.g. a verb that starts a prompt.
+func Capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,283 @@
+package display
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/shipyard/shipyard-cli/pkg/types"
+)
+
+// Output formats accepted by the -o/--output flag.
+// JSONPath and Go template formats carry their template after the prefix.
+const (
+	OutputTable      = "table"
+	OutputWide       = "wide"
+	OutputJSON       = "json"
+	OutputYAML       = "yaml"
+	OutputName       = "name"
+	OutputCSV        = "csv"
+	OutputJSONPath   = "jsonpath="
+	OutputGoTemplate = "go-template="
+)
+
+// OutputFormats lists the formats for help texts and shell completion.
+var OutputFormats = []string{OutputTable, OutputWide, OutputJSON, OutputYAML, OutputName, OutputCSV, OutputJSONPath, OutputGoTemplate}
+
+// Resource is what a command prints. Every format picks the part it needs.
+type Resource struct {
+	// Object is serialized for the json, yaml, jsonpath and go-template formats.
+	Object any
+	// Columns are shown in the table. WideColumns are appended to them in the wide table.
+	// Every row holds a cell for each of Columns followed by one for each of WideColumns.
+	// The csv format always includes all of them.
+	Columns     []string
+	WideColumns []string
+	Rows        [][]string
+	// Names are printed one per line by the name format.
+	Names []string
+	// Message, when set, is printed instead of the table in the table and wide formats,
+	// e.g. for action results or empty lists.
+	Message string
+	// Footer is printed after the table in the table and wide formats.
+	Footer string
+}
+
+// Printer writes a Resource in one output format.
+type Printer interface {
+	Print(w io.Writer, r Resource) error
+}
+
+// NewPrinter returns the printer for an output format. An empty format means a table.
+func NewPrinter(format string) (Printer, error) {
+	switch {
+	case format == "" || format == OutputTable:
+		return tablePrinter{}, nil
+	case format == OutputWide:
+		return tablePrinter{wide: true}, nil
+	case format == OutputJSON:
+		return jsonPrinter{}, nil
+	case format == OutputYAML:
+		return yamlPrinter{}, nil
+	case format == OutputName:
+		return namePrinter{}, nil
+	case format == OutputCSV:
+		return csvPrinter{}, nil
+	case strings.HasPrefix(format, OutputJSONPath):
+		return newJSONPathPrinter(strings.TrimPrefix(format, OutputJSONPath))
+	case strings.HasPrefix(format, OutputGoTemplate):
+		return newTemplatePrinter(strings.TrimPrefix(format, OutputGoTemplate))
+	default:
+		return nil, fmt.Errorf("unknown output format %q, expected one of: %s", format, strings.Join(OutputFormats, ", "))
+	}
+}
+
+// OutputFormat returns the format requested with -o/--output.
+// The --json flag some commands have is a shorthand for -o json.
+func OutputFormat() string {
+	if viper.GetBool("json") {
+		return OutputJSON
+	}
+	return viper.GetString("output")
+}
+
+// IsStructuredOutput reports whether the requested format is meant for machines rather than people.
+// Commands use it to skip hints and prompts that would break the output.
+func IsStructuredOutput() bool {
+	switch OutputFormat() {
+	case "", OutputTable, OutputWide:
+		return false
+	default:
+		return true
+	}
+}
+
+// PrintResource writes the resource to stdout in the requested output format.
+func PrintResource(r Resource) error {
+	p, err := NewPrinter(OutputFormat())
+	if err != nil {
+		return err
+	}
+	return p.Print(os.Stdout, r)
+}
+
+// ActionResource describes the result of a single action.
+// The message is what people see in the table formats.
+func ActionResource(message string, result types.ActionResult) Resource {
+	return Resource{
+		Object:  result,
+		Columns: []string{"ID", "Action", "Result"},
+		Rows:    [][]string{{result.ID, result.Action, result.Result}},
+		Names:   []string{result.ID},
+		Message: message,
+	}
+}
+
+type tablePrinter struct {
+	wide bool
+}
+
+func (p tablePrinter) Print(w io.Writer, r Resource) error {
+	if r.Message != "" {
+		_, err := fmt.Fprintln(w, r.Message)
+		return err
+	}
+
+	columns := r.Columns
+	if p.wide {
+		columns = append(append([]string{}, r.Columns...), r.WideColumns...)
+	}
+	RenderTable(w, columns, r.Rows)
+
+	if r.Footer != "" {
+		_, err := fmt.Fprintln(w, r.Footer)
+		return err
+	}
+	return nil
+}
+
+type jsonPrinter struct{}
+
+func (jsonPrinter) Print(w io.Writer, r Resource) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.Object)
+}
+
+type yamlPrinter struct{}
+
+func (yamlPrinter) Print(w io.Writer, r Resource) error {
+	// Go through JSON so that the keys follow the json tags of the API types.
+	obj, err := toGeneric(r.Object)
+	if err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(obj); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+type namePrinter struct{}
+
+func (namePrinter) Print(w io.Writer, r Resource) error {
+	for _, name := range r.Names {
+		if _, err := fmt.Fprintln(w, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type csvPrinter struct{}
+
+func (csvPrinter) Print(w io.Writer, r Resource) error {
+	cw := csv.NewWriter(w)
+	columns := append(append([]string{}, r.Columns...), r.WideColumns...)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	for _, row := range r.Rows {
+		record := make([]string, len(columns))
+		for i := range record {
+			if i < len(row) {
+				record[i] = strings.TrimSpace(StripEscapes(row[i]))
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type jsonPathPrinter struct {
+	parser *jsonpath.JSONPath
+}
+
+func newJSONPathPrinter(expr string) (Printer, error) {
+	if expr == "" {
+		return nil, fmt.Errorf("jsonpath output format requires an expression, e.g. -o jsonpath='{.data[*].id}'")
+	}
+	// Accept expressions without braces as well, like kubectl does.
+	if !strings.Contains(expr, "{") {
+		expr = "{" + expr + "}"
+	}
+	parser := jsonpath.New("output")
+	if err := parser.Parse(expr); err != nil {
+		return nil, fmt.Errorf("invalid jsonpath expression: %w", err)
+	}
+	return jsonPathPrinter{parser: parser}, nil
+}
+
+func (p jsonPathPrinter) Print(w io.Writer, r Resource) error {
+	obj, err := toGeneric(r.Object)
+	if err != nil {
+		return err
+	}
+	if err := p.parser.Execute(w, obj); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
+
+type templatePrinter struct {
+	tmpl *template.Template
+}
+
+func newTemplatePrinter(text string) (Printer, error) {
+	if text == "" {
+		return nil, fmt.Errorf("go-template output format requires a template, e.g. -o go-template='{{range .data}}{{.id}}{{end}}'")
+	}
+	tmpl, err := template.New("output").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid go template: %w", err)
+	}
+	return templatePrinter{tmpl: tmpl}, nil
+}
+
+func (p templatePrinter) Print(w io.Writer, r Resource) error {
+	obj, err := toGeneric(r.Object)
+	if err != nil {
+		return err
+	}
+	if err := p.tmpl.Execute(w, obj); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
+
+// toGeneric converts a typed value into maps and slices keyed by its JSON field names.
+func toGeneric(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var obj any
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// EscapeSequence matches a color, a cursor movement or a terminal hyperlink.
+var EscapeSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]|\x1b\]8;;[^\x1b]*\x1b\\`)
+
+// StripEscapes removes colors, cursor movements and terminal hyperlinks from s.
+func StripEscapes(s string) string {
+	return EscapeSequence.ReplaceAllString(s, "")
+}
@@ -0,0 +1,168 @@
+package display
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/shipyard/shipyard-cli/pkg/types"
+)
+
+func TestPrinters(t *testing.T) {
+	res := Resource{
+		Object: types.RespManyEnvs{Data: []types.Environment{
+			{ID: "abc", Attributes: types.EnvironmentAttributes{Name: "web", Ready: true}},
+			{ID: "def", Attributes: types.EnvironmentAttributes{Name: "api"}},
+		}},
+		Columns:     []string{"App", "UUID"},
+		WideColumns: []string{"Ready"},
+		Rows: [][]string{
+			{"\x1b[32m web \x1b[0m", "\x1b]8;;https://example.com\x1b\\abc\x1b]8;;\x1b\\", "yes"},
+			{"api", "def", "no"},
+		},
+		Names: []string{"abc", "def"},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: OutputName,
+			want:   "abc\ndef\n",
+		},
+		{
+			format: OutputCSV,
+			want:   "App,UUID,Ready\nweb,abc,yes\napi,def,no\n",
+		},
+		{
+			format: "jsonpath={.data[*].id}",
+			want:   "abc def\n",
+		},
+		{
+			format: ".data[0].attributes.name",
+			want:   "",
+		},
+		{
+			format: "jsonpath=.data[0].attributes.name",
+			want:   "web\n",
+		},
+		{
+			format: "go-template={{range .data}}{{.id}}:{{.attributes.ready}} {{end}}",
+			want:   "abc:true def:false \n",
+		},
+		{
+			format: OutputJSON,
+			want: `{
+  "data": [
+    {
+      "id": "abc",
+      "attributes": {
+        "name": "web",
+        "url": "",
+        "ready": true,
+        "stopped": false,
+        "retired": false,
+        "since_last_visit": null,
+        "projects": null,
+        "services": null
+      }
+    },
+    {
+      "id": "def",
+      "attributes": {
+        "name": "api",
+        "url": "",
+        "ready": false,
+        "stopped": false,
+        "retired": false,
+        "since_last_visit": null,
+        "projects": null,
+        "services": null
+      }
+    }
+  ]
+}
+`,
+		},
+		{
+			format: OutputYAML,
+			want: `data:
+  - attributes:
+      name: web
+      projects: null
+      ready: true
+      retired: false
+      services: null
+      since_last_visit: null
+      stopped: false
+      url: ""
+    id: abc
+  - attributes:
+      name: api
+      projects: null
+      ready: false
+      retired: false
+      services: null
+      since_last_visit: null
+      stopped: false
+      url: ""
+    id: def
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			p, err := NewPrinter(test.format)
+			if test.want == "" {
+				if err == nil {
+					t.Fatalf("expected format %q to be rejected", test.format)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			if err := p.Print(&out, res); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, out.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestTablePrinterMessage(t *testing.T) {
+	res := Resource{
+		Object:  types.ActionResult{ID: "abc", Action: "stop", Result: "stopped"},
+		Columns: []string{"ID"},
+		Rows:    [][]string{{"abc"}},
+		Message: "Environment stopped.",
+	}
+	for _, format := range []string{"", OutputTable, OutputWide} {
+		p, err := NewPrinter(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if err := p.Print(&out, res); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := out.String(), "Environment stopped.\n"; got != want {
+			t.Errorf("format %q: want %q, but got %q", format, want, got)
+		}
+	}
+}
+
+func TestInvalidTemplates(t *testing.T) {
+	for _, format := range []string{"jsonpath=", "jsonpath={.data[", "go-template=", "go-template={{.data"} {
+		if _, err := NewPrinter(format); err == nil {
+			t.Errorf("expected format %q to be rejected", format)
+		}
+	}
+}
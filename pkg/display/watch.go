@@ -0,0 +1,220 @@
+package display
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+// MinWatchInterval keeps --watch from flooding the API.
+const MinWatchInterval = time.Second
+
+// WatchFrame is the result of one poll of a watched resource.
+type WatchFrame struct {
+	// Resource is drawn as a table in a terminal.
+	Resource Resource
+	// Items are compared with the ones of the previous poll by ID.
+	Items []WatchItem
+	// RowIDs holds the item ID of each row in Resource.Rows.
+	RowIDs []string
+}
+
+// WatchItem is one thing a watched resource lists, e.g. an environment.
+type WatchItem struct {
+	ID string
+	// State holds what a change is highlighted for in a terminal, e.g. whether an environment is ready.
+	State string
+	// Object is carried by the events. Any change to it produces a "modified" event.
+	Object any
+}
+
+// WatchEvent is printed as a line of JSON for every change when the output is not a terminal.
+type WatchEvent struct {
+	// Type is one of added, modified, deleted or error.
+	Type   string    `json:"type"`
+	ID     string    `json:"id,omitempty"`
+	Time   time.Time `json:"time"`
+	Object any       `json:"object,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// WatchFunc fetches the current state of a watched resource.
+type WatchFunc func(ctx context.Context) (WatchFrame, error)
+
+// Watch calls fetch every interval until ctx is done or the user interrupts it.
+// In a terminal the table is redrawn in place and rows whose state changed are highlighted.
+// Otherwise, or with -o json, every change is printed as a WatchEvent, one per line.
+func Watch(ctx context.Context, title string, interval time.Duration, fetch WatchFunc) error {
+	if interval < MinWatchInterval {
+		return fmt.Errorf("--interval must be at least %s", MinWatchInterval)
+	}
+	w := &Watcher{
+		title:    title,
+		interval: interval,
+		fetch:    fetch,
+		out:      os.Stdout,
+		now:      time.Now,
+	}
+	switch format := OutputFormat(); format {
+	case "", OutputTable, OutputWide:
+		w.redraw = isatty.IsTerminal(os.Stdout.Fd())
+		w.wide = format == OutputWide
+	case OutputJSON:
+	default:
+		return fmt.Errorf("--watch prints a table or JSON events, it cannot be used with -o %s", format)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	return w.Run(ctx)
+}
+
+// Watcher polls a resource and shows how it changes.
+type Watcher struct {
+	title    string
+	interval time.Duration
+	fetch    WatchFunc
+	out      io.Writer
+	// redraw clears the terminal before every table, instead of printing events.
+	redraw bool
+	wide   bool
+	now    func() time.Time
+
+	seen  map[string]watchedItem
+	order []string
+}
+
+type watchedItem struct {
+	state  string
+	object []byte
+}
+
+// Run polls until ctx is done. Only an error on the first poll ends it,
+// later ones are reported and the next poll goes ahead.
+func (w *Watcher) Run(ctx context.Context) error {
+	for first := true; ; first = false {
+		frame, err := w.fetch(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil && first:
+			return err
+		case err != nil:
+			w.reportError(err)
+		default:
+			if err := w.show(frame); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+func (w *Watcher) show(frame WatchFrame) error {
+	current := make(map[string]watchedItem, len(frame.Items))
+	order := make([]string, 0, len(frame.Items))
+	for _, item := range frame.Items {
+		object, err := json.Marshal(item.Object)
+		if err != nil {
+			return err
+		}
+		current[item.ID] = watchedItem{state: item.State, object: object}
+		order = append(order, item.ID)
+	}
+
+	var err error
+	if w.redraw {
+		err = w.draw(frame, current)
+	} else {
+		err = w.printEvents(frame.Items, current)
+	}
+	w.seen, w.order = current, order
+	return err
+}
+
+func (w *Watcher) draw(frame WatchFrame, current map[string]watchedItem) error {
+	r := frame.Resource
+	r.Footer = ""
+	if w.seen != nil {
+		r.Rows = make([][]string, len(frame.Resource.Rows))
+		for i, row := range frame.Resource.Rows {
+			r.Rows[i] = row
+			if i >= len(frame.RowIDs) {
+				continue
+			}
+			prev, ok := w.seen[frame.RowIDs[i]]
+			if !ok || prev.state != current[frame.RowIDs[i]].state {
+				r.Rows[i] = highlightRow(row)
+			}
+		}
+	}
+
+	// Render first and clear the screen right before writing, so that the table does not flicker.
+	var b bytes.Buffer
+	_, _ = fmt.Fprintf(&b, "Every %s: %s\t%s\n\n", w.interval, w.title, w.now().Format(time.TimeOnly))
+	if err := (tablePrinter{wide: w.wide}).Print(&b, r); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.out, "\x1b[H\x1b[2J"+b.String())
+	return err
+}
+
+func (w *Watcher) printEvents(items []WatchItem, current map[string]watchedItem) error {
+	enc := json.NewEncoder(w.out)
+	now := w.now()
+	for _, item := range items {
+		prev, ok := w.seen[item.ID]
+		switch {
+		case !ok:
+			if err := enc.Encode(WatchEvent{Type: "added", ID: item.ID, Time: now, Object: item.Object}); err != nil {
+				return err
+			}
+		case !bytes.Equal(prev.object, current[item.ID].object):
+			if err := enc.Encode(WatchEvent{Type: "modified", ID: item.ID, Time: now, Object: item.Object}); err != nil {
+				return err
+			}
+		}
+	}
+	for _, id := range w.order {
+		if _, ok := current[id]; !ok {
+			if err := enc.Encode(WatchEvent{Type: "deleted", ID: id, Time: now}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) reportError(err error) {
+	if w.redraw {
+		// Keep the last table on screen, it is still the latest known state.
+		_, _ = color.New(color.FgRed).Fprintf(w.out, "\nError: %v\n", err)
+		return
+	}
+	_ = json.NewEncoder(w.out).Encode(WatchEvent{Type: "error", Time: w.now(), Error: err.Error()})
+}
+
+// highlightRow shows the row in reverse video. Colors inside a cell end with a reset,
+// which would end the highlight as well, so it is turned back on after each of them.
+func highlightRow(row []string) []string {
+	const reverse, reset = "\x1b[7m", "\x1b[0m"
+	highlighted := make([]string, len(row))
+	for i, cell := range row {
+		highlighted[i] = reverse + strings.ReplaceAll(cell, reset, reset+reverse) + reset
+	}
+	return highlighted
+}
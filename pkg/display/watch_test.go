@@ -0,0 +1,143 @@
+package display
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type watchedEnv struct {
+	ID    string `json:"id"`
+	Ready bool   `json:"ready"`
+	URL   string `json:"url"`
+}
+
+// scriptedFetch returns a frame for each poll in turn, and cancels the watch after the last one.
+func scriptedFetch(cancel context.CancelFunc, polls ...[]watchedEnv) WatchFunc {
+	i := 0
+	return func(ctx context.Context) (WatchFrame, error) {
+		if i == len(polls) {
+			cancel()
+			return WatchFrame{}, ctx.Err()
+		}
+		envs := polls[i]
+		i++
+		if envs == nil {
+			return WatchFrame{}, errors.New("service unavailable")
+		}
+
+		var frame WatchFrame
+		frame.Resource.Columns = []string{"UUID", "Ready"}
+		for _, env := range envs {
+			ready := "No"
+			if env.Ready {
+				ready = "Yes"
+			}
+			frame.Resource.Rows = append(frame.Resource.Rows, []string{env.ID, ready})
+			frame.RowIDs = append(frame.RowIDs, env.ID)
+			frame.Items = append(frame.Items, WatchItem{ID: env.ID, State: ready, Object: env})
+		}
+		return frame, nil
+	}
+}
+
+func TestWatchEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var out bytes.Buffer
+	w := &Watcher{
+		interval: time.Millisecond,
+		out:      &out,
+		now:      func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) },
+		fetch: scriptedFetch(cancel,
+			[]watchedEnv{{ID: "a"}, {ID: "b"}},
+			[]watchedEnv{{ID: "a"}, {ID: "b"}},
+			nil,
+			[]watchedEnv{{ID: "a", Ready: true}, {ID: "b", URL: "https://b.example.com"}, {ID: "c"}},
+			[]watchedEnv{{ID: "c"}},
+		),
+	}
+	if err := w.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"type":"added","id":"a","time":"2024-05-01T12:00:00Z","object":{"id":"a","ready":false,"url":""}}
+{"type":"added","id":"b","time":"2024-05-01T12:00:00Z","object":{"id":"b","ready":false,"url":""}}
+{"type":"error","time":"2024-05-01T12:00:00Z","error":"service unavailable"}
+{"type":"modified","id":"a","time":"2024-05-01T12:00:00Z","object":{"id":"a","ready":true,"url":""}}
+{"type":"modified","id":"b","time":"2024-05-01T12:00:00Z","object":{"id":"b","ready":false,"url":"https://b.example.com"}}
+{"type":"added","id":"c","time":"2024-05-01T12:00:00Z","object":{"id":"c","ready":false,"url":""}}
+{"type":"deleted","id":"a","time":"2024-05-01T12:00:00Z"}
+{"type":"deleted","id":"b","time":"2024-05-01T12:00:00Z"}
+`
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestWatchRedraw(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var out bytes.Buffer
+	w := &Watcher{
+		title:    "shipyard get environments",
+		interval: time.Millisecond,
+		out:      &out,
+		redraw:   true,
+		now:      func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) },
+		fetch: scriptedFetch(cancel,
+			[]watchedEnv{{ID: "a"}, {ID: "b"}},
+			[]watchedEnv{{ID: "a", Ready: true}, {ID: "b", URL: "https://b.example.com"}},
+		),
+	}
+	if err := w.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	screens := strings.Split(out.String(), "\x1b[H\x1b[2J")
+	if len(screens) != 3 {
+		t.Fatalf("expected 2 screens, but got %d: %q", len(screens)-1, out.String())
+	}
+	if !strings.HasPrefix(screens[1], "Every 1ms: shipyard get environments\t12:00:00\n") {
+		t.Errorf("unexpected title in %q", screens[1])
+	}
+	if strings.Contains(screens[1], "\x1b[7m") {
+		t.Errorf("nothing should be highlighted on the first screen: %q", screens[1])
+	}
+	// Only the row whose state changed is highlighted, a new URL is not a state change.
+	if !strings.Contains(screens[2], "\x1b[7ma\x1b[0m") {
+		t.Errorf("expected row a to be highlighted: %q", screens[2])
+	}
+	if strings.Contains(screens[2], "\x1b[7mb\x1b[0m") {
+		t.Errorf("expected row b not to be highlighted: %q", screens[2])
+	}
+}
+
+func TestWatchFirstError(t *testing.T) {
+	w := &Watcher{
+		interval: time.Millisecond,
+		out:      &bytes.Buffer{},
+		now:      time.Now,
+		fetch: func(context.Context) (WatchFrame, error) {
+			return WatchFrame{}, errors.New("environment not found")
+		},
+	}
+	if err := w.Run(context.Background()); err == nil || err.Error() != "environment not found" {
+		t.Errorf("expected the first error to end the watch, but got %v", err)
+	}
+}
+
+func TestHighlightRow(t *testing.T) {
+	got := highlightRow([]string{"\x1b[32mYes\x1b[0m", "abc"})
+	want := []string{"\x1b[7m\x1b[32mYes\x1b[0m\x1b[7m\x1b[0m", "\x1b[7mabc\x1b[0m"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
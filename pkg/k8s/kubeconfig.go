@@ -1,6 +1,7 @@
 package k8s
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -15,8 +16,8 @@ import (
 
 // setupKubeconfig tries to fetch a kubeconfig for a given environment and
 // save it in the default store directory.
-func setupKubeconfig(c client.Client, envID string) error {
-	cfg, err := fetchKubeconfig(c, envID)
+func setupKubeconfig(ctx context.Context, c client.Client, envID string) error {
+	cfg, err := fetchKubeconfig(ctx, c, envID)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve kubeconfig: %w", err)
 	}
@@ -27,14 +28,14 @@ func setupKubeconfig(c client.Client, envID string) error {
 }
 
 // fetchKubeconfig tries to fetch the Kubeconfig from the backend API.
-func fetchKubeconfig(c client.Client, envID string) ([]byte, error) {
+func fetchKubeconfig(ctx context.Context, c client.Client, envID string) ([]byte, error) {
 	params := make(map[string]string)
 	if org := c.OrgLookupFn(); org != "" {
 		params["org"] = org
 	}
 
 	requestURI := uri.CreateResourceURI("", "environment", envID, "kubeconfig", params)
-	body, err := c.Requester.Do(http.MethodGet, requestURI, "application/json", nil)
+	body, err := c.Requester.Do(ctx, http.MethodGet, requestURI, "application/json", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package k8s
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/shipyard/shipyard-cli/pkg/client"
@@ -12,11 +13,12 @@ import (
 type Client struct {
 	restConfig *rest.Config
 	clientSet  *kubernetes.Clientset
+	namespace  string
 	Path       string
 }
 
-func NewConfig(c client.Client, envid string) (*Client, error) {
-	if err := setupKubeconfig(c, envid); err != nil {
+func NewConfig(ctx context.Context, c client.Client, envid string) (*Client, error) {
+	if err := setupKubeconfig(ctx, c, envid); err != nil {
 		return nil, err
 	}
 
@@ -53,6 +55,7 @@ func NewConfig(c client.Client, envid string) (*Client, error) {
 	sc := Client{
 		restConfig: restConfig,
 		clientSet:  clientSet,
+		namespace:  contexts[rawConfig.CurrentContext].Namespace,
 		Path:       path,
 	}
 
@@ -0,0 +1,70 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/shipyard/shipyard-cli/pkg/types"
+)
+
+// PodStatus is the health of the pod that runs a service.
+type PodStatus struct {
+	Service         string `json:"service"`
+	Pod             string `json:"pod"`
+	Phase           string `json:"phase"`
+	Restarts        int32  `json:"restarts"`
+	LastTermination string `json:"last_termination,omitempty"`
+}
+
+// PodStatuses returns the status of the pod of every service, in the order of services.
+// A service without a pod is reported with an empty pod name and the phase "Missing".
+func (c *Client) PodStatuses(ctx context.Context, services []types.Service) ([]PodStatus, error) {
+	pods, err := c.clientSet.CoreV1().Pods(c.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]PodStatus, 0, len(services))
+	for i := range services {
+		statuses = append(statuses, podStatus(&services[i], pods.Items))
+	}
+	return statuses, nil
+}
+
+// podStatus finds the pod of a service by its component label, like podForService does.
+// When a service has several pods, e.g. during a rollout, the newest one is reported.
+func podStatus(svc *types.Service, pods []v1.Pod) PodStatus {
+	var pod *v1.Pod
+	for i := range pods {
+		if pods[i].Labels["component"] != svc.SanitizedName {
+			continue
+		}
+		if pod == nil || pod.CreationTimestamp.Before(&pods[i].CreationTimestamp) {
+			pod = &pods[i]
+		}
+	}
+	if pod == nil {
+		return PodStatus{Service: svc.Name, Phase: "Missing"}
+	}
+
+	status := PodStatus{Service: svc.Name, Pod: pod.Name, Phase: string(pod.Status.Phase)}
+	var last *v1.ContainerStateTerminated
+	for _, cs := range pod.Status.ContainerStatuses {
+		status.Restarts += cs.RestartCount
+		t := cs.LastTerminationState.Terminated
+		if t != nil && (last == nil || last.FinishedAt.Before(&t.FinishedAt)) {
+			last = t
+		}
+	}
+	if last != nil {
+		reason := last.Reason
+		if reason == "" {
+			reason = "Terminated"
+		}
+		status.LastTermination = fmt.Sprintf("%s (exit code %d)", reason, last.ExitCode)
+	}
+	return status
+}
@@ -0,0 +1,76 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/shipyard/shipyard-cli/pkg/types"
+)
+
+func TestPodStatus(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	pod := func(name, component string, age time.Duration, phase v1.PodPhase, containers ...v1.ContainerStatus) v1.Pod {
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Labels:            map[string]string{"component": component},
+				CreationTimestamp: metav1.NewTime(created.Add(-age)),
+			},
+			Status: v1.PodStatus{Phase: phase, ContainerStatuses: containers},
+		}
+	}
+	terminated := func(reason string, code int32, finished time.Time) v1.ContainerState {
+		return v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+			Reason:     reason,
+			ExitCode:   code,
+			FinishedAt: metav1.NewTime(finished),
+		}}
+	}
+
+	pods := []v1.Pod{
+		pod("web-old", "web", time.Hour, v1.PodFailed),
+		pod("web-new", "web", time.Minute, v1.PodRunning,
+			v1.ContainerStatus{RestartCount: 2, LastTerminationState: terminated("Error", 1, created.Add(-time.Hour))},
+			v1.ContainerStatus{RestartCount: 1, LastTerminationState: terminated("OOMKilled", 137, created)},
+		),
+		pod("worker-1", "worker", time.Minute, v1.PodPending,
+			v1.ContainerStatus{RestartCount: 1, LastTerminationState: terminated("", 2, created)},
+		),
+		pod("db-1", "postgres", time.Minute, v1.PodRunning, v1.ContainerStatus{}),
+	}
+
+	tests := []struct {
+		svc  types.Service
+		want PodStatus
+	}{
+		{
+			svc:  types.Service{Name: "web", SanitizedName: "web"},
+			want: PodStatus{Service: "web", Pod: "web-new", Phase: "Running", Restarts: 3, LastTermination: "OOMKilled (exit code 137)"},
+		},
+		{
+			svc:  types.Service{Name: "worker", SanitizedName: "worker"},
+			want: PodStatus{Service: "worker", Pod: "worker-1", Phase: "Pending", Restarts: 1, LastTermination: "Terminated (exit code 2)"},
+		},
+		{
+			svc:  types.Service{Name: "Postgres DB", SanitizedName: "postgres"},
+			want: PodStatus{Service: "Postgres DB", Pod: "db-1", Phase: "Running"},
+		},
+		{
+			svc:  types.Service{Name: "flower", SanitizedName: "flower"},
+			want: PodStatus{Service: "flower", Phase: "Missing"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.svc.Name, func(t *testing.T) {
+			got := podStatus(&test.svc, pods)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
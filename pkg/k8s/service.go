@@ -34,9 +34,9 @@ type Service struct {
 	pod        string
 }
 
-func New(c client.Client, id string, svc *types.Service) (*Service, error) {
+func New(ctx context.Context, c client.Client, id string, svc *types.Service) (*Service, error) {
 	s := Service{client: c}
-	if err := setupKubeconfig(c, id); err != nil {
+	if err := setupKubeconfig(ctx, c, id); err != nil {
 		return nil, err
 	}
 
@@ -72,7 +72,7 @@ func New(c client.Client, id string, svc *types.Service) (*Service, error) {
 	}
 	s.clientSet = clientSet
 
-	pod, err := s.podForService(svc)
+	pod, err := s.podForService(ctx, svc)
 	if err != nil {
 		return nil, err
 	}
@@ -112,14 +112,14 @@ func (c *Service) Exec(args []string) error {
 	})
 }
 
-func (c *Service) Logs(follow bool, tail int64) error {
+func (c *Service) Logs(ctx context.Context, follow bool, tail int64) error {
 	opts := v1.PodLogOptions{
 		Follow:    follow,
 		TailLines: &tail,
 	}
 	req := c.clientSet.CoreV1().Pods(c.namespace).GetLogs(c.pod, &opts)
 
-	podLogs, err := req.Stream(context.TODO())
+	podLogs, err := req.Stream(ctx)
 	if err != nil {
 		return err
 	}
@@ -155,14 +155,14 @@ func (c *Service) Logs(follow bool, tail int64) error {
 
 // GetLogsAsString returns logs as a string instead of printing them
 // This is used by the MCP logs service to capture log output
-func (c *Service) GetLogsAsString(follow bool, tail int64) (string, error) {
+func (c *Service) GetLogsAsString(ctx context.Context, follow bool, tail int64) (string, error) {
 	opts := v1.PodLogOptions{
 		Follow:    follow,
 		TailLines: &tail,
 	}
 	req := c.clientSet.CoreV1().Pods(c.namespace).GetLogs(c.pod, &opts)
 
-	podLogs, err := req.Stream(context.TODO())
+	podLogs, err := req.Stream(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -177,6 +177,28 @@ func (c *Service) GetLogsAsString(follow bool, tail int64) (string, error) {
 }
 
 func (c *Service) PortForward(ports []string) error {
+	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+	return c.forwardPorts(make(chan struct{}, 1), ports, out, errOut, func() {
+		if s := errOut.String(); s != "" {
+			display.Fail(s)
+		} else if s = out.String(); s != "" {
+			display.Print(s)
+		}
+	})
+}
+
+// PortForwardContext forwards the ports until ctx is done. Nothing is printed,
+// ready is called once the local ports accept connections.
+func (c *Service) PortForwardContext(ctx context.Context, ports []string, ready func()) error {
+	stopChan := make(chan struct{}, 1)
+	go func() {
+		<-ctx.Done()
+		close(stopChan)
+	}()
+	return c.forwardPorts(stopChan, ports, io.Discard, io.Discard, ready)
+}
+
+func (c *Service) forwardPorts(stopChan chan struct{}, ports []string, out, errOut io.Writer, ready func()) error {
 	roundTripper, upgrader, err := spdy.RoundTripperFor(c.restConfig)
 	if err != nil {
 		return err
@@ -187,8 +209,7 @@ func (c *Service) PortForward(ports []string) error {
 	serverURL := url.URL{Scheme: "https", Host: host, Path: path}
 
 	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: roundTripper}, http.MethodPost, &serverURL)
-	stopChan, readyChan := make(chan struct{}, 1), make(chan struct{}, 1)
-	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+	readyChan := make(chan struct{}, 1)
 
 	forwarder, err := portforward.New(dialer, ports, stopChan, readyChan, out, errOut)
 	if err != nil {
@@ -198,27 +219,19 @@ func (c *Service) PortForward(ports []string) error {
 	go func() {
 		for range readyChan {
 		}
-
-		if s := errOut.String(); s != "" {
-			display.Fail(s)
-		} else if s = out.String(); s != "" {
-			display.Print(s)
-		}
+		ready()
 	}()
 
-	if err := forwarder.ForwardPorts(); err != nil {
-		return err
-	}
-	return nil
+	return forwarder.ForwardPorts()
 }
 
 // podForService uses the service's sanitized name to find the pod in a given namespace.
-func (c *Service) podForService(svc *types.Service) (string, error) {
+func (c *Service) podForService(ctx context.Context, svc *types.Service) (string, error) {
 	options := metav1.ListOptions{
 		LabelSelector: "component=" + svc.SanitizedName,
 	}
 
-	pods, err := c.clientSet.CoreV1().Pods(c.namespace).List(context.TODO(), options)
+	pods, err := c.clientSet.CoreV1().Pods(c.namespace).List(ctx, options)
 	if err != nil {
 		return "", err
 	}
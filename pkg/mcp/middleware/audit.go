@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Finisher is a Middleware that also sees the response of a request, or nil if it got none
+type Finisher interface {
+	Middleware
+	Finish(ctx context.Context, req *JSONRPCRequest, response []byte)
+}
+
+// Audit records every tool call and resource read as a line of JSON
+type Audit struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// AuditRecord is a line of the audit log
+type AuditRecord struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Tool   string    `json:"tool,omitempty"`
+	URI    string    `json:"uri,omitempty"`
+	// User is the user of mcp.auth.users that sent the request, if any
+	User string `json:"user,omitempty"`
+	// Arguments are those of the tool, with the values of secrets replaced
+	Arguments  json.RawMessage `json:"arguments,omitempty"`
+	DurationMS int64           `json:"duration_ms"`
+	// Status is ok, error or cancelled
+	Status        string `json:"status"`
+	ErrorCategory string `json:"error_category,omitempty"`
+}
+
+// Create audit middleware writing to w
+func NewAudit(w io.Writer) *Audit {
+	return &Audit{w: w}
+}
+
+type auditStartKey struct{}
+
+// Process notes when an audited request started
+func (a *Audit) Process(ctx context.Context, req *JSONRPCRequest) (context.Context, error) {
+	if req.Method != "tools/call" && req.Method != "resources/read" {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, auditStartKey{}, time.Now()), nil
+}
+
+// Finish writes the record of an audited request
+func (a *Audit) Finish(ctx context.Context, req *JSONRPCRequest, response []byte) {
+	start, ok := ctx.Value(auditStartKey{}).(time.Time)
+	if !ok {
+		return
+	}
+
+	var params struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+		URI       string          `json:"uri"`
+	}
+	_ = json.Unmarshal(req.Params, &params)
+	record := AuditRecord{
+		Time:       start.UTC(),
+		Method:     req.Method,
+		Tool:       params.Name,
+		URI:        params.URI,
+		User:       User(ctx),
+		Arguments:  redact(params.Arguments),
+		DurationMS: time.Since(start).Milliseconds(),
+		Status:     "ok",
+	}
+
+	var result struct {
+		Error *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	switch {
+	case response == nil && ctx.Err() != nil:
+		record.Status = "cancelled"
+	case json.Unmarshal(response, &result) == nil && result.Error != nil:
+		record.Status = "error"
+		record.ErrorCategory = errorCategory(result.Error.Code)
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("Error encoding audit record: %v", err)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.w.Write(append(line, '\n')); err != nil {
+		log.Printf("Error writing audit record: %v", err)
+	}
+}
+
+// Close closes the audit log, if it can be closed
+func (a *Audit) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if c, ok := a.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Categories of the JSON-RPC error codes of the server
+var errorCategories = map[int]string{
+	-32600: "invalid_request",
+	-32601: "method_not_found",
+	-32602: "invalid_params",
+	-32603: "internal",
+	-32000: "server",
+	-32001: "unauthorized",
+	-32002: "forbidden",
+	-32003: "not_found",
+	-32004: "conflict",
+}
+
+func errorCategory(code int) string {
+	if category, ok := errorCategories[code]; ok {
+		return category
+	}
+	return "server"
+}
+
+// Names of arguments whose values are not written to the audit log
+var secretNames = []string{"token", "secret", "password", "passwd", "credential", "authorization", "api_key", "apikey", "private_key"}
+
+const redacted = "[REDACTED]"
+
+// Replace the values of secrets in the arguments of a tool
+func redact(args json.RawMessage) json.RawMessage {
+	if len(args) == 0 {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(args, &v); err != nil {
+		return nil
+	}
+	b, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if isSecretName(k) {
+				v[k] = redacted
+			} else {
+				v[k] = redactValue(value)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+	}
+	return v
+}
+
+func isSecretName(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range secretNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestAudit_Redact(t *testing.T) {
+	args := json.RawMessage(`{"environment_id":"env-1","Auth_Token":"a","items":[{"password":"b"}],"nested":{"client_secret":"c"}}`)
+
+	got := string(redact(args))
+	want := `{"Auth_Token":"[REDACTED]","environment_id":"env-1","items":[{"password":"[REDACTED]"}],"nested":{"client_secret":"[REDACTED]"}}`
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestAudit_Cancelled(t *testing.T) {
+	var log bytes.Buffer
+	audit := NewAudit(&log)
+	req := &JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "resources/read", Params: json.RawMessage(`{"uri":"shipyard://logs/env-1"}`)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, err := audit.Process(WithUser(ctx, "alice"), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	audit.Finish(ctx, req, nil)
+
+	var record AuditRecord
+	if err := json.Unmarshal(log.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to unmarshal record: %v", err)
+	}
+	if record.Status != "cancelled" || record.URI != "shipyard://logs/env-1" || record.User != "alice" {
+		t.Errorf("Expected a cancelled read by alice, got %s", log.String())
+	}
+}
+
+func TestAudit_SkipsOtherMethods(t *testing.T) {
+	var log bytes.Buffer
+	audit := NewAudit(&log)
+	req := &JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/list"}
+
+	ctx, _ := audit.Process(context.Background(), req)
+	audit.Finish(ctx, req, []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+	if log.Len() != 0 {
+		t.Errorf("Expected no record of tools/list, got %s", log.String())
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"github.com/shipyard/shipyard-cli/config"
+	"github.com/shipyard/shipyard-cli/pkg/mcp/transport"
+)
+
+// Auth accepts the requests sent with one of the bearer tokens of the config, and tells the
+// server which user sent them
+type Auth struct {
+	tokens []string
+	users  map[string]config.MCPUser
+}
+
+// Create auth middleware from the mcp.auth section of the config
+func NewAuth(config config.MCPAuth) *Auth {
+	return &Auth{tokens: config.Tokens, users: config.Users}
+}
+
+// Authenticate reports whether a token is valid, and the name of its user. The tokens of
+// mcp.auth.tokens have no user.
+func (a *Auth) Authenticate(token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	for name, user := range a.users {
+		if equalTokens(user.Token, token) {
+			return name, true
+		}
+	}
+	for _, t := range a.tokens {
+		if equalTokens(t, token) {
+			return "", true
+		}
+	}
+	return "", false
+}
+
+// Valid checks a token for the http transport
+func (a *Auth) Valid(token string) bool {
+	_, ok := a.Authenticate(token)
+	return ok
+}
+
+// Process rejects requests without a valid token and adds the user of the token to the context
+func (a *Auth) Process(ctx context.Context, req *JSONRPCRequest) (context.Context, error) {
+	user, ok := a.Authenticate(transport.BearerToken(ctx))
+	if !ok {
+		// -32001 is the authentication error of the MCP server
+		return ctx, &Error{Code: -32001, Message: "Unauthorized", Data: "a valid bearer token is required"}
+	}
+	if user == "" {
+		return ctx, nil
+	}
+	return WithUser(ctx, user), nil
+}
+
+// Compare tokens in constant time, so that the time taken does not give away a token
+func equalTokens(a, b string) bool {
+	return a != "" && subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+type userKey struct{}
+
+// WithUser returns a context of a request of a user of mcp.auth.users
+func WithUser(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, userKey{}, name)
+}
+
+// User returns the user a request was sent by, or an empty string
+func User(ctx context.Context) string {
+	name, _ := ctx.Value(userKey{}).(string)
+	return name
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shipyard/shipyard-cli/config"
+)
+
+func TestAuth_Authenticate(t *testing.T) {
+	auth := NewAuth(config.MCPAuth{
+		Tokens: []string{"shared"},
+		Users:  map[string]config.MCPUser{"alice": {Token: "alice-secret"}, "bob": {}},
+	})
+
+	tests := []struct {
+		token string
+		user  string
+		ok    bool
+	}{
+		{token: "shared", ok: true},
+		{token: "alice-secret", user: "alice", ok: true},
+		{token: "alice", ok: false},
+		{token: "", ok: false},
+	}
+	for _, tt := range tests {
+		user, ok := auth.Authenticate(tt.token)
+		if user != tt.user || ok != tt.ok {
+			t.Errorf("Expected %q, %v for token %q, got %q, %v", tt.user, tt.ok, tt.token, user, ok)
+		}
+	}
+}
+
+func TestAuth_ProcessWithoutToken(t *testing.T) {
+	auth := NewAuth(config.MCPAuth{Tokens: []string{"shared"}})
+
+	_, err := auth.Process(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/list"})
+	mwErr, ok := err.(*Error)
+	if !ok || mwErr.Code != -32001 {
+		t.Errorf("Expected an authentication error, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
 )
 
@@ -12,7 +13,19 @@ type JSONRPCRequest struct {
 	Params  json.RawMessage `json:"params,omitempty"`
 }
 
-// Middleware interface for processing requests
+// Middleware interface for processing requests. It returns the context to handle the request
+// with, which may carry what the middleware learned about it, such as its user.
 type Middleware interface {
-	Process(req *JSONRPCRequest) error
+	Process(ctx context.Context, req *JSONRPCRequest) (context.Context, error)
+}
+
+// Error rejects a request with a JSON-RPC error code of its own
+type Error struct {
+	Code    int
+	Message string
+	Data    interface{}
+}
+
+func (e *Error) Error() string {
+	return e.Message
 }
@@ -100,7 +100,7 @@ func (r *LogsResource) IsAvailable(ctx context.Context, uri string) bool {
 	serviceName := matches[2]
 
 	// Check if the service exists
-	_, err := r.client.FindService(serviceName, environmentID)
+	_, err := r.client.FindService(ctx, serviceName, environmentID)
 	return err == nil
 }
 
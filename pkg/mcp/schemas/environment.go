@@ -25,6 +25,10 @@ func ListEnvironmentsSchema() map[string]interface{} {
 				"type":    "integer",
 				"default": 20,
 			},
+			"all_pages": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Fetch every page and return all items in a single data array",
+			},
 		},
 	}
 }
@@ -160,6 +164,10 @@ func SnapshotsListSchema() map[string]interface{} {
 				"type":    "integer",
 				"default": 20,
 			},
+			"all_pages": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Fetch every page and return all items in a single data array",
+			},
 		},
 		"required": []string{"environment_id"},
 	}
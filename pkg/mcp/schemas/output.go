@@ -0,0 +1,142 @@
+package schemas
+
+// The output schemas describe the structuredContent of tools that return JSON. They leave out
+// additionalProperties, as the API may add fields, and let the values of fields be null, as the
+// API sends null for values it does not have.
+
+// EnvironmentListOutputSchema defines the output schema for listing environments
+func EnvironmentListOutputSchema() map[string]interface{} {
+	return object(map[string]interface{}{
+		"data":  array(environmentSchema()),
+		"links": linksSchema(),
+	}, "data")
+}
+
+// EnvironmentOutputSchema defines the output schema for getting an environment
+func EnvironmentOutputSchema() map[string]interface{} {
+	return object(map[string]interface{}{
+		"data": environmentSchema(),
+	}, "data")
+}
+
+// OrgListOutputSchema defines the output schema for listing organizations
+func OrgListOutputSchema() map[string]interface{} {
+	return object(map[string]interface{}{
+		"data": array(object(map[string]interface{}{
+			"attributes": object(map[string]interface{}{
+				"name": typed("string", "Organization name"),
+			}),
+		})),
+	}, "data")
+}
+
+// ServicesOutputSchema defines the output schema for listing the services of an environment
+func ServicesOutputSchema() map[string]interface{} {
+	return object(map[string]interface{}{
+		"environment_id": typed("string", "Environment ID"),
+		"service_count":  typed("integer", "Number of services"),
+		"services":       array(serviceSchema()),
+	}, "environment_id", "service_count", "services")
+}
+
+// VolumeListOutputSchema defines the output schema for listing volumes
+func VolumeListOutputSchema() map[string]interface{} {
+	return object(map[string]interface{}{
+		"data": array(object(map[string]interface{}{
+			"id":   typed("string", "Volume ID"),
+			"type": typed("string", ""),
+			"attributes": object(map[string]interface{}{
+				"volume_name":        typed("string", "Volume name"),
+				"service_name":       typed("string", "Service the volume belongs to"),
+				"volume_path":        typed("string", "Path of the volume in the service"),
+				"compose_path":       typed("string", "Compose file that defines the volume"),
+				"remote_compose_url": typed("string", ""),
+			}),
+		})),
+	}, "data")
+}
+
+// SnapshotListOutputSchema defines the output schema for listing volume snapshots
+func SnapshotListOutputSchema() map[string]interface{} {
+	return object(map[string]interface{}{
+		"data": array(object(map[string]interface{}{
+			"id":   typed("string", "Snapshot ID"),
+			"type": typed("string", ""),
+			"attributes": object(map[string]interface{}{
+				"sequence_number":      typed("integer", "Number of the snapshot, for load_snapshot"),
+				"from_snapshot_number": typed("integer", "Snapshot this one was made from"),
+				"status":               typed("string", "Snapshot status"),
+				"created_at":           typed("string", "Creation time"),
+				"total_size":           typed("integer", "Size in bytes"),
+			}),
+		})),
+		"links": linksSchema(),
+	}, "data")
+}
+
+func environmentSchema() map[string]interface{} {
+	return object(map[string]interface{}{
+		"id": typed("string", "Environment ID"),
+		"attributes": object(map[string]interface{}{
+			"name":             typed("string", "Environment name"),
+			"url":              typed("string", "Environment URL"),
+			"ready":            typed("boolean", "Whether the environment is running and ready"),
+			"stopped":          typed("boolean", "Whether the environment is stopped"),
+			"retired":          typed("boolean", "Whether the environment is deleted"),
+			"since_last_visit": typed("integer", "Minutes since the environment was last visited"),
+			"bypass_token":     typed("string", "Value of the shipyard_token URL parameter that skips the login"),
+			"projects": array(object(map[string]interface{}{
+				"repo_name":           typed("string", ""),
+				"repo_owner":          typed("string", ""),
+				"branch":              typed("string", ""),
+				"commit_hash":         typed("string", ""),
+				"pull_request_number": typed("integer", ""),
+			})),
+			"services": array(serviceSchema()),
+		}),
+	}, "id")
+}
+
+func serviceSchema() map[string]interface{} {
+	return object(map[string]interface{}{
+		"name":           typed("string", "Service name"),
+		"sanitized_name": typed("string", ""),
+		"url":            typed("string", "Service URL"),
+		"ports":          array(typed("string", "")),
+	}, "name")
+}
+
+func linksSchema() map[string]interface{} {
+	return object(map[string]interface{}{
+		"first": typed("string", ""),
+		"last":  typed("string", ""),
+		"next":  typed("string", "URL of the next page, if any"),
+		"prev":  typed("string", ""),
+	})
+}
+
+func object(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func array(items map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":  []string{"array", "null"},
+		"items": items,
+	}
+}
+
+func typed(t string, description string) map[string]interface{} {
+	schema := map[string]interface{}{"type": []string{t, "null"}}
+	if description != "" {
+		schema["description"] = description
+	}
+	return schema
+}
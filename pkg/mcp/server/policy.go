@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/shipyard/shipyard-cli/config"
+	"github.com/shipyard/shipyard-cli/pkg/mcp/errors"
+	"github.com/shipyard/shipyard-cli/pkg/mcp/middleware"
+	"github.com/shipyard/shipyard-cli/pkg/mcp/tools"
+)
+
+// The scope of mcp.tools.scopes that applies to every tool without one of its own
+const anyTool = "*"
+
+// Tools whose results list environments, which environment scopes filter
+var environmentLists = []string{"get_environments"}
+
+// Check whether mcp.read_only and mcp.tools offer a tool
+func (s *MCPServer) toolAllowed(def tools.ToolDefinition) bool {
+	if s.config.ReadOnly && !def.Annotations.ReadOnlyHint {
+		return false
+	}
+	if len(s.config.Tools.Allow) > 0 && !slices.Contains(s.config.Tools.Allow, def.Name) {
+		return false
+	}
+	return !slices.Contains(s.config.Tools.Deny, def.Name)
+}
+
+// Keep the tools the config offers
+func (s *MCPServer) allowedTools(t map[string]tools.Tool) map[string]tools.Tool {
+	for name, tool := range t {
+		if !s.toolAllowed(tool.Definition()) {
+			delete(t, name)
+		}
+	}
+	return t
+}
+
+// Check whether destructive tools need a confirm: true argument
+func (s *MCPServer) needsConfirm(def tools.ToolDefinition) bool {
+	return s.config.Tools.Confirm && def.Annotations.DestructiveHint
+}
+
+// Definition of a tool as tools/list shows it, with the confirm argument if it needs one
+func (s *MCPServer) listedDefinition(tool tools.Tool) tools.ToolDefinition {
+	def := tool.Definition()
+	if !s.needsConfirm(def) {
+		return def
+	}
+	schema, ok := def.InputSchema.(map[string]interface{})
+	if !ok {
+		return def
+	}
+	// Copy the schema, as tools share it
+	withConfirm := make(map[string]interface{}, len(schema)+1)
+	for k, v := range schema {
+		withConfirm[k] = v
+	}
+	properties := make(map[string]interface{})
+	if p, ok := schema["properties"].(map[string]interface{}); ok {
+		for k, v := range p {
+			properties[k] = v
+		}
+	}
+	properties["confirm"] = map[string]interface{}{
+		"type":        "boolean",
+		"description": "Set to true once the user has confirmed this action, which cannot be undone",
+	}
+	withConfirm["properties"] = properties
+	def.InputSchema = withConfirm
+	return def
+}
+
+// Check a tool call against the scope of the tool and the need to confirm it
+func (s *MCPServer) checkCall(ctx context.Context, def tools.ToolDefinition, arguments json.RawMessage) error {
+	var args struct {
+		EnvironmentID string `json:"environment_id"`
+		OrgName       string `json:"org_name"`
+		Confirm       bool   `json:"confirm"`
+	}
+	// Arguments the tool cannot parse are left for the tool to report
+	_ = json.Unmarshal(arguments, &args)
+
+	if err := s.checkScope(ctx, def.Name, args.OrgName, args.EnvironmentID); err != nil {
+		return err
+	}
+
+	if s.needsConfirm(def) && !args.Confirm {
+		return errors.NewMCPError(def.Name, "this action needs confirmation", nil).
+			WithSuggestion("Ask the user to confirm it, then call the tool again with confirm: true").
+			WithStatusCode(http.StatusBadRequest)
+	}
+	return nil
+}
+
+// Check the org of a request, an org to switch to and an environment against the scope of a tool
+func (s *MCPServer) checkScope(ctx context.Context, name, orgName, environmentID string) error {
+	scope, ok := s.scopeOf(name)
+	if !ok {
+		return nil
+	}
+	if len(scope.Orgs) > 0 {
+		if org := s.orgFor(ctx); !slices.Contains(scope.Orgs, org) {
+			return errors.PermissionError(name, "org", org)
+		}
+		// set_org may only switch to an org of the scope
+		if orgName != "" && !slices.Contains(scope.Orgs, orgName) {
+			return errors.PermissionError(name, "org", orgName)
+		}
+	}
+	if len(scope.Environments) > 0 && environmentID != "" && !slices.Contains(scope.Environments, environmentID) {
+		return errors.PermissionError(name, "environment", environmentID)
+	}
+	return nil
+}
+
+// Check a resource read against the scope of every tool. The environment of a resource is the
+// host of its URI, as in logs://{environment_id}/{service_name}.
+func (s *MCPServer) checkRead(ctx context.Context, uri string) error {
+	var environmentID string
+	if u, err := url.Parse(uri); err == nil {
+		environmentID = u.Host
+	}
+	return s.checkScope(ctx, anyTool, "", environmentID)
+}
+
+// Leave the environments out of scope out of the result of a tool that lists them
+func (s *MCPServer) filterResult(def tools.ToolDefinition, result string) (string, error) {
+	scope, ok := s.scopeOf(def.Name)
+	if !ok || len(scope.Environments) == 0 || !slices.Contains(environmentLists, def.Name) {
+		return result, nil
+	}
+	var list map[string]json.RawMessage
+	var envs []json.RawMessage
+	if err := json.Unmarshal([]byte(result), &list); err != nil {
+		return "", errors.NewMCPError(def.Name, "failed to apply the environment scope", err)
+	}
+	if err := json.Unmarshal(list["data"], &envs); err != nil {
+		return "", errors.NewMCPError(def.Name, "failed to apply the environment scope", err)
+	}
+	kept := make([]json.RawMessage, 0, len(envs))
+	for _, env := range envs {
+		var e struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(env, &e); err == nil && slices.Contains(scope.Environments, e.ID) {
+			kept = append(kept, env)
+		}
+	}
+	list["data"], _ = json.Marshal(kept)
+	b, err := json.Marshal(list)
+	if err != nil {
+		return "", errors.NewMCPError(def.Name, "failed to apply the environment scope", err)
+	}
+	return string(b), nil
+}
+
+// The scope of a tool in mcp.tools.scopes, or that of every tool
+func (s *MCPServer) scopeOf(name string) (config.MCPToolScope, bool) {
+	if scope, ok := s.config.Tools.Scopes[name]; ok {
+		return scope, true
+	}
+	scope, ok := s.config.Tools.Scopes[anyTool]
+	return scope, ok
+}
+
+// The org the tools act on for the user of a request, empty for the default org of the API token
+func (s *MCPServer) orgFor(ctx context.Context) string {
+	if user, ok := s.config.Auth.Users[middleware.User(ctx)]; ok && user.Org != "" {
+		return user.Org
+	}
+	if s.client.OrgLookupFn != nil {
+		return s.client.OrgLookupFn()
+	}
+	return ""
+}
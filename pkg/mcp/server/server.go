@@ -1,13 +1,21 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"sync"
+	"time"
 
+	"github.com/shipyard/shipyard-cli/config"
 	"github.com/shipyard/shipyard-cli/pkg/client"
 	"github.com/shipyard/shipyard-cli/pkg/mcp/errors"
 	"github.com/shipyard/shipyard-cli/pkg/mcp/middleware"
@@ -27,18 +35,21 @@ type JSONRPCResponse struct {
 	Error   *JSONRPCError `json:"error,omitempty"`
 }
 
+// A JSON-RPC notification, a message without an ID that gets no response
+type JSONRPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
 type JSONRPCError struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
 }
 
-// MCP Server configuration
-type MCPServerConfig struct {
-	Transport    string `yaml:"transport" mapstructure:"transport"`
-	Port         int    `yaml:"port" mapstructure:"port"`
-	AuditLogging bool   `yaml:"audit_logging" mapstructure:"audit_logging"`
-}
+// MCP Server configuration, the 'mcp' section of the config file
+type MCPServerConfig = config.MCP
 
 // MCP Server
 type MCPServer struct {
@@ -52,20 +63,49 @@ type MCPServer struct {
 	mu         sync.RWMutex
 	ctx        context.Context
 	cancel     context.CancelFunc
+	// auth checks the bearer tokens of the http transport, if mcp.auth sets any
+	auth *middleware.Auth
+	// audit records the tool calls and resource reads, if mcp.audit_logging is on
+	audit *middleware.Audit
+	// userTools and userResources act as the users of mcp.auth.users with an API token or org of their own
+	userTools     map[string]map[string]tools.Tool
+	userResources map[string][]resources.Resource
+	// slots bounds the number of requests handled at once
+	slots chan struct{}
+	// inflight holds the cancel functions of the requests being handled, for notifications/cancelled
+	inflight   map[string]context.CancelCauseFunc
+	inflightMu sync.Mutex
 }
 
+// The cause of the context of a request the client cancelled
+var errRequestCancelled = fmt.Errorf("request cancelled by the client")
+
+// Number of requests handled at once when the config does not set mcp.max_concurrency
+const defaultMaxConcurrency = 8
+
 // Create new MCP server
 func NewMCPServer(config MCPServerConfig, client client.Client) *MCPServer {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &MCPServer{
-		config:     config,
-		client:     client,
-		tools:      make(map[string]tools.Tool),
-		resources:  make([]resources.Resource, 0),
-		middleware: make([]middleware.Middleware, 0),
-		ctx:        ctx,
-		cancel:     cancel,
+		config:        config,
+		client:        client,
+		tools:         make(map[string]tools.Tool),
+		resources:     make([]resources.Resource, 0),
+		middleware:    make([]middleware.Middleware, 0),
+		ctx:           ctx,
+		cancel:        cancel,
+		slots:         make(chan struct{}, maxConcurrency(config)),
+		inflight:      make(map[string]context.CancelCauseFunc),
+		userTools:     make(map[string]map[string]tools.Tool),
+		userResources: make(map[string][]resources.Resource),
+	}
+}
+
+func maxConcurrency(config MCPServerConfig) int {
+	if config.MaxConcurrency > 0 {
+		return config.MaxConcurrency
 	}
+	return defaultMaxConcurrency
 }
 
 // Start the MCP server
@@ -77,22 +117,49 @@ func (s *MCPServer) Start() error {
 		return fmt.Errorf("server already running")
 	}
 
+	// Setup middleware
+	if err := s.setupMiddleware(); err != nil {
+		return err
+	}
+
 	// Initialize transport based on config
 	switch s.config.Transport {
 	case "stdio":
 		s.transport = transport.NewStdioTransport()
+	case "http":
+		httpConfig := transport.HTTPConfig{
+			Addr:               s.Addr(),
+			AllowedOrigins:     s.config.AllowedOrigins,
+			SessionIdleTimeout: time.Duration(s.config.SessionTimeout) * time.Minute,
+			MaxSessions:        s.config.MaxSessions,
+		}
+		if s.auth != nil {
+			httpConfig.Authenticate = s.auth.Valid
+			if s.audit != nil {
+				httpConfig.Rejected = s.auditRejected
+			}
+		}
+		s.transport = transport.NewHTTPTransport(httpConfig)
 	default:
 		return fmt.Errorf("unsupported transport: %s", s.config.Transport)
 	}
 
+	// Transports with several clients hand each message to the server, to answer the right client
+	handlerTransport, concurrent := s.transport.(transport.HandlerTransport)
+	if concurrent {
+		handlerTransport.SetHandler(s.handleMessage)
+	}
+
 	// Register tools
 	s.registerTools()
 
 	// Register resources
 	s.registerResources()
 
-	// Setup middleware
-	s.setupMiddleware()
+	// Register users with API tokens of their own
+	if err := s.registerUsers(); err != nil {
+		return err
+	}
 
 	// Start transport
 	if err := s.transport.Start(s.ctx); err != nil {
@@ -103,11 +170,18 @@ func (s *MCPServer) Start() error {
 	log.Printf("MCP server started with %s transport", s.config.Transport)
 
 	// Start message handling loop
-	go s.handleMessages()
+	if !concurrent {
+		go s.handleMessages()
+	}
 
 	return nil
 }
 
+// Addr returns the address the http transport listens on
+func (s *MCPServer) Addr() string {
+	return net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+}
+
 // Stop the MCP server
 func (s *MCPServer) Stop() error {
 	s.mu.Lock()
@@ -123,6 +197,11 @@ func (s *MCPServer) Stop() error {
 			log.Printf("Error stopping transport: %v", err)
 		}
 	}
+	if s.audit != nil {
+		if err := s.audit.Close(); err != nil {
+			log.Printf("Error closing audit log: %v", err)
+		}
+	}
 
 	s.running = false
 	log.Println("MCP server stopped")
@@ -155,22 +234,72 @@ func (s *MCPServer) handleMessages() {
 			continue
 		}
 
-		response := s.processMessage(msg)
-		if response != nil {
-			if err := s.transport.WriteMessage(response); err != nil {
-				log.Printf("Error writing response: %v", err)
-			}
+		// Notifications are handled right away, so that a cancellation does not wait for a slot
+		if isNotification(msg) {
+			s.processMessage(s.ctx, msg)
+			continue
 		}
+
+		ctx, done := s.track(transport.WithNotifier(s.ctx, s.transport.WriteMessage), msg)
+		go func() {
+			defer done()
+			// Wait for a free slot, so that a slow tool only holds up the others once all slots are taken.
+			// The messages are still read meanwhile, to see the cancellations.
+			select {
+			case s.slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-s.slots }()
+			response := s.processMessage(ctx, msg)
+			if response != nil {
+				if err := s.transport.WriteMessage(response); err != nil {
+					log.Printf("Error writing response: %v", err)
+				}
+			}
+		}()
+	}
+}
+
+// Handle a message of a transport with several clients, which calls it once per request
+func (s *MCPServer) handleMessage(ctx context.Context, msg []byte) []byte {
+	if isNotification(msg) {
+		return s.processMessage(ctx, msg)
+	}
+	ctx, done := s.track(ctx, msg)
+	defer done()
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil
 	}
+	defer func() { <-s.slots }()
+	return s.processMessage(ctx, msg)
 }
 
-// Process individual JSON-RPC message
-func (s *MCPServer) processMessage(data []byte) []byte {
+// Process individual JSON-RPC message. The context ends with the request.
+func (s *MCPServer) processMessage(ctx context.Context, data []byte) (response []byte) {
 	var req JSONRPCRequest
 	if err := json.Unmarshal(data, &req); err != nil {
 		return s.errorResponse(nil, -32700, "Parse error", nil)
 	}
 
+	// Middleware that processed the request see its response last, once it is final
+	var finishers []middleware.Finisher
+	defer func() {
+		for _, f := range finishers {
+			f.Finish(ctx, &req, response)
+		}
+	}()
+
+	// A panicking tool fails its request rather than the server and the requests running next to it
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("MCP server panic handling %s: %v", req.Method, r)
+			response = s.errorResponse(req.ID, -32603, "Internal error", nil)
+		}
+	}()
+
 	if err := s.validateRequest(&req); err != nil {
 		return s.errorResponse(req.ID, -32600, "Invalid Request", err.Error())
 	}
@@ -183,11 +312,26 @@ func (s *MCPServer) processMessage(data []byte) []byte {
 			Method:  req.Method,
 			Params:  req.Params,
 		}
-		if err := mw.Process(middlewareReq); err != nil {
+		if f, ok := mw.(middleware.Finisher); ok {
+			finishers = append(finishers, f)
+		}
+		next, err := mw.Process(ctx, middlewareReq)
+		if err != nil {
+			if mwErr, ok := err.(*middleware.Error); ok {
+				return s.errorResponse(req.ID, mwErr.Code, mwErr.Message, mwErr.Data)
+			}
 			return s.errorResponse(req.ID, -32000, "Middleware error", err.Error())
 		}
+		ctx = next
 	}
 
+	// A cancelled request gets no response, the client has stopped waiting for it
+	defer func() {
+		if context.Cause(ctx) == errRequestCancelled {
+			response = nil
+		}
+	}()
+
 	// Handle MCP methods
 	switch req.Method {
 	case "initialize":
@@ -195,16 +339,19 @@ func (s *MCPServer) processMessage(data []byte) []byte {
 	case "notifications/initialized":
 		// Client notification - no response needed
 		return nil
+	case "notifications/cancelled":
+		s.handleCancelled(ctx, &req)
+		return nil
 	case "tools/list":
 		return s.handleListTools(&req)
 	case "tools/call":
-		return s.handleCallTool(&req)
+		return s.handleCallTool(ctx, &req)
 	case "prompts/list":
 		return s.handleListPrompts(&req)
 	case "resources/list":
 		return s.handleListResources(&req)
 	case "resources/read":
-		return s.handleReadResource(&req)
+		return s.handleReadResource(ctx, &req)
 	default:
 		return s.errorResponse(req.ID, -32601, "Method not found", nil)
 	}
@@ -213,7 +360,7 @@ func (s *MCPServer) processMessage(data []byte) []byte {
 // Handle initialize request
 func (s *MCPServer) handleInitialize(req *JSONRPCRequest) []byte {
 	result := map[string]interface{}{
-		"protocolVersion": "2024-11-05",
+		"protocolVersion": protocolVersion(req),
 		"capabilities": map[string]interface{}{
 			"tools":     map[string]interface{}{},
 			"resources": map[string]interface{}{},
@@ -228,11 +375,27 @@ func (s *MCPServer) handleInitialize(req *JSONRPCRequest) []byte {
 	return s.successResponse(req.ID, result)
 }
 
+// Protocol versions the server speaks, the first for clients that ask for none of them.
+// Tool annotations and structuredContent are sent to every client, as older ones ignore them.
+var protocolVersions = []string{"2024-11-05", "2025-03-26", "2025-06-18"}
+
+// The protocol version the client asked for, if the server speaks it
+func protocolVersion(req *JSONRPCRequest) string {
+	var params struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	_ = json.Unmarshal(req.Params, &params)
+	if slices.Contains(protocolVersions, params.ProtocolVersion) {
+		return params.ProtocolVersion
+	}
+	return protocolVersions[0]
+}
+
 // Handle list tools request
 func (s *MCPServer) handleListTools(req *JSONRPCRequest) []byte {
 	toolsList := make([]interface{}, 0, len(s.tools))
 	for _, tool := range s.tools {
-		toolsList = append(toolsList, tool.Definition())
+		toolsList = append(toolsList, s.listedDefinition(tool))
 	}
 
 	result := map[string]interface{}{
@@ -252,45 +415,147 @@ func (s *MCPServer) handleListPrompts(req *JSONRPCRequest) []byte {
 	return s.successResponse(req.ID, result)
 }
 
+// Check whether a message is a notification, which has no ID
+func isNotification(msg []byte) bool {
+	var envelope struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(msg, &envelope); err != nil {
+		return false
+	}
+	return envelope.ID == nil
+}
+
+// Register a request as in flight, so that notifications/cancelled can cancel its context,
+// also while it waits for a slot. The returned function ends the request.
+func (s *MCPServer) track(ctx context.Context, msg []byte) (context.Context, func()) {
+	var envelope struct {
+		ID interface{} `json:"id"`
+	}
+	if err := json.Unmarshal(msg, &envelope); err != nil || envelope.ID == nil {
+		return ctx, func() {}
+	}
+	key := requestKey(ctx, envelope.ID)
+	ctx, cancel := context.WithCancelCause(ctx)
+	s.inflightMu.Lock()
+	s.inflight[key] = cancel
+	s.inflightMu.Unlock()
+	return ctx, func() {
+		s.inflightMu.Lock()
+		delete(s.inflight, key)
+		s.inflightMu.Unlock()
+		cancel(nil)
+	}
+}
+
+// Key of an in-flight request. Request IDs are only unique within a session of the HTTP transport.
+func requestKey(ctx context.Context, id interface{}) string {
+	return fmt.Sprintf("%s %#v", transport.SessionID(ctx), id)
+}
+
+// Handle cancelled notification, sent by the client to stop a request it no longer waits for
+func (s *MCPServer) handleCancelled(ctx context.Context, req *JSONRPCRequest) {
+	var params struct {
+		RequestID interface{} `json:"requestId"`
+		Reason    string      `json:"reason,omitempty"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.RequestID == nil {
+		log.Printf("MCP server ignoring invalid cancellation: %s", req.Params)
+		return
+	}
+
+	s.inflightMu.Lock()
+	cancel, ok := s.inflight[requestKey(ctx, params.RequestID)]
+	s.inflightMu.Unlock()
+	// The request may have finished already
+	if !ok {
+		return
+	}
+	log.Printf("MCP server cancelling request %v: %s", params.RequestID, params.Reason)
+	cancel(errRequestCancelled)
+}
+
 // Handle call tool request
-func (s *MCPServer) handleCallTool(req *JSONRPCRequest) []byte {
+func (s *MCPServer) handleCallTool(ctx context.Context, req *JSONRPCRequest) []byte {
 	var params struct {
 		Name      string          `json:"name"`
 		Arguments json.RawMessage `json:"arguments"`
+		Meta      struct {
+			// ProgressToken asks for notifications/progress about the call
+			ProgressToken interface{} `json:"progressToken"`
+		} `json:"_meta"`
 	}
 
 	if err := json.Unmarshal(req.Params, &params); err != nil {
 		return s.errorResponse(req.ID, -32602, "Invalid params", err.Error())
 	}
+	if params.Meta.ProgressToken != nil {
+		ctx = tools.WithProgress(ctx, s.progress(ctx, params.Meta.ProgressToken))
+	}
 
-	tool, exists := s.tools[params.Name]
+	tool, exists := s.toolsFor(ctx)[params.Name]
 	if !exists {
 		return s.errorResponse(req.ID, -32000, "Tool not found", params.Name)
 	}
 
-	result, err := tool.Execute(s.ctx, params.Arguments)
+	if err := s.checkCall(ctx, tool.Definition(), params.Arguments); err != nil {
+		return s.toolErrorResponse(req.ID, params.Name, err)
+	}
+
+	result, err := tool.Execute(ctx, params.Arguments)
 	if err != nil {
 		log.Printf("MCP server tool execution error for %s: %v", params.Name, err)
-
-		// Check if error is already an MCPError to avoid double-processing
-		var mcpErr *errors.MCPError
-		if mcpError, ok := err.(*errors.MCPError); ok {
-			mcpErr = mcpError
-		} else {
-			// Use improved error handling for non-MCP errors
-			mcpErr = errors.ParseHTTPError(params.Name, err, "")
-		}
-		return s.errorResponse(req.ID, mcpErr.ToJSONRPCCode(), mcpErr.Error(), nil)
+		return s.toolErrorResponse(req.ID, params.Name, err)
+	}
+	if result, err = s.filterResult(tool.Definition(), result); err != nil {
+		return s.toolErrorResponse(req.ID, params.Name, err)
 	}
 
-	return s.successResponse(req.ID, map[string]interface{}{
+	response := map[string]interface{}{
 		"content": []interface{}{
 			map[string]interface{}{
 				"type": "text",
 				"text": result,
 			},
 		},
-	})
+	}
+	// Tools with an output schema return JSON, which clients also get as typed data
+	if tool.Definition().OutputSchema != nil {
+		if structured := bytes.TrimSpace([]byte(result)); bytes.HasPrefix(structured, []byte("{")) && json.Valid(structured) {
+			response["structuredContent"] = json.RawMessage(structured)
+		}
+	}
+	return s.successResponse(req.ID, response)
+}
+
+// Error response for a tool call that failed
+func (s *MCPServer) toolErrorResponse(id interface{}, name string, err error) []byte {
+	// Check if error is already an MCPError to avoid double-processing
+	var mcpErr *errors.MCPError
+	if mcpError, ok := err.(*errors.MCPError); ok {
+		mcpErr = mcpError
+	} else {
+		// Use improved error handling for non-MCP errors
+		mcpErr = errors.ParseHTTPError(name, err, "")
+	}
+	return s.errorResponse(id, mcpErr.ToJSONRPCCode(), mcpErr.Error(), nil)
+}
+
+// Progress that sends notifications/progress for the token of a request to its client
+func (s *MCPServer) progress(ctx context.Context, token interface{}) tools.Progress {
+	return func(progress, total float64, message string) {
+		params := map[string]interface{}{"progressToken": token, "progress": progress}
+		if total > 0 {
+			params["total"] = total
+		}
+		if message != "" {
+			params["message"] = message
+		}
+		data, _ := json.Marshal(JSONRPCNotification{JSONRPC: "2.0", Method: "notifications/progress", Params: params})
+		if err := transport.Notify(ctx, data); err != nil {
+			log.Printf("Error sending progress: %v", err)
+		}
+	}
 }
 
 // Handle list resources request
@@ -308,7 +573,7 @@ func (s *MCPServer) handleListResources(req *JSONRPCRequest) []byte {
 }
 
 // Handle read resource request
-func (s *MCPServer) handleReadResource(req *JSONRPCRequest) []byte {
+func (s *MCPServer) handleReadResource(ctx context.Context, req *JSONRPCRequest) []byte {
 	var params struct {
 		URI string `json:"uri"`
 	}
@@ -321,10 +586,15 @@ func (s *MCPServer) handleReadResource(req *JSONRPCRequest) []byte {
 		return s.errorResponse(req.ID, -32602, "Missing URI parameter", nil)
 	}
 
+	// Check the scope first, as finding the resource already looks up the environment
+	if err := s.checkRead(ctx, params.URI); err != nil {
+		return s.toolErrorResponse(req.ID, "read_resource", err)
+	}
+
 	// Find resource that can handle this URI
 	var targetResource resources.Resource
-	for _, resource := range s.resources {
-		if resource.IsAvailable(s.ctx, params.URI) {
+	for _, resource := range s.resourcesFor(ctx) {
+		if resource.IsAvailable(ctx, params.URI) {
 			targetResource = resource
 			break
 		}
@@ -335,7 +605,7 @@ func (s *MCPServer) handleReadResource(req *JSONRPCRequest) []byte {
 	}
 
 	// Get resource content
-	reader, mimeType, err := targetResource.GetContent(s.ctx, params.URI)
+	reader, mimeType, err := targetResource.GetContent(ctx, params.URI)
 	if err != nil {
 		log.Printf("MCP server resource read error for %s: %v", params.URI, err)
 		mcpErr := errors.ParseHTTPError("read_resource", err, params.URI)
@@ -373,49 +643,134 @@ func (s *MCPServer) validateRequest(req *JSONRPCRequest) error {
 	return nil
 }
 
-// Register available tools
+// Register the tools the config offers
 func (s *MCPServer) registerTools() {
+	for name, tool := range s.allowedTools(newTools(s.client)) {
+		s.tools[name] = tool
+	}
+}
+
+// Create the tools, acting through a client
+func newTools(c client.Client) map[string]tools.Tool {
+	t := make(map[string]tools.Tool)
 	// Register environment tools
-	s.tools["get_environments"] = tools.NewEnvironmentTool(s.client, "get_environments")
-	s.tools["get_environment"] = tools.NewEnvironmentTool(s.client, "get_environment")
-	s.tools["restart_environment"] = tools.NewEnvironmentTool(s.client, "restart_environment")
-	s.tools["stop_environment"] = tools.NewEnvironmentTool(s.client, "stop_environment")
-	s.tools["cancel_environment"] = tools.NewEnvironmentTool(s.client, "cancel_environment")
-	s.tools["rebuild_environment"] = tools.NewEnvironmentTool(s.client, "rebuild_environment")
-	s.tools["revive_environment"] = tools.NewEnvironmentTool(s.client, "revive_environment")
+	t["get_environments"] = tools.NewEnvironmentTool(c, "get_environments")
+	t["get_environment"] = tools.NewEnvironmentTool(c, "get_environment")
+	t["restart_environment"] = tools.NewEnvironmentTool(c, "restart_environment")
+	t["stop_environment"] = tools.NewEnvironmentTool(c, "stop_environment")
+	t["cancel_environment"] = tools.NewEnvironmentTool(c, "cancel_environment")
+	t["rebuild_environment"] = tools.NewEnvironmentTool(c, "rebuild_environment")
+	t["revive_environment"] = tools.NewEnvironmentTool(c, "revive_environment")
 
 	// Register organization tools
-	s.tools["get_orgs"] = tools.NewOrgTool(s.client, "get_orgs")
-	s.tools["get_org"] = tools.NewOrgTool(s.client, "get_org")
-	s.tools["set_org"] = tools.NewOrgTool(s.client, "set_org")
+	t["get_orgs"] = tools.NewOrgTool(c, "get_orgs")
+	t["get_org"] = tools.NewOrgTool(c, "get_org")
+	t["set_org"] = tools.NewOrgTool(c, "set_org")
 
 	// Register logs tool
-	s.tools["get_logs"] = tools.NewLogsTool(s.client, "get_logs")
+	t["get_logs"] = tools.NewLogsTool(c, "get_logs")
 
 	// Register service tools
-	s.tools["get_services"] = tools.NewServiceTool(s.client, "get_services")
-	s.tools["exec_service"] = tools.NewServiceTool(s.client, "exec_service")
-	s.tools["port_forward"] = tools.NewServiceTool(s.client, "port_forward")
+	t["get_services"] = tools.NewServiceTool(c, "get_services")
+	t["exec_service"] = tools.NewServiceTool(c, "exec_service")
+	t["port_forward"] = tools.NewServiceTool(c, "port_forward")
 
 	// Register volume tools
-	s.tools["get_volumes"] = tools.NewVolumeTool(s.client, "get_volumes")
-	s.tools["get_snapshots"] = tools.NewVolumeTool(s.client, "get_snapshots")
-	s.tools["reset_volume"] = tools.NewVolumeTool(s.client, "reset_volume")
-	s.tools["create_snapshot"] = tools.NewVolumeTool(s.client, "create_snapshot")
-	s.tools["load_snapshot"] = tools.NewVolumeTool(s.client, "load_snapshot")
+	t["get_volumes"] = tools.NewVolumeTool(c, "get_volumes")
+	t["get_snapshots"] = tools.NewVolumeTool(c, "get_snapshots")
+	t["reset_volume"] = tools.NewVolumeTool(c, "reset_volume")
+	t["create_snapshot"] = tools.NewVolumeTool(c, "create_snapshot")
+	t["load_snapshot"] = tools.NewVolumeTool(c, "load_snapshot")
 
 	// Register telepresence tools
-	s.tools["telepresence_connect"] = tools.NewTelepresenceTool(s.client, "telepresence_connect")
+	t["telepresence_connect"] = tools.NewTelepresenceTool(c, "telepresence_connect")
+
+	return t
+}
+
+// Register the tools and resources of the users that act with an API token or org of their own
+func (s *MCPServer) registerUsers() error {
+	for name, user := range s.config.Auth.Users {
+		if user.APIToken == "" && user.Org == "" {
+			continue
+		}
+		c, err := s.client.As(user.APIToken, user.Org)
+		if err != nil {
+			return fmt.Errorf("failed to set up user %s: %w", name, err)
+		}
+		s.userTools[name] = s.allowedTools(newTools(c))
+		s.userResources[name] = newResources(c)
+	}
+	return nil
+}
+
+// Tools acting as the user of a request
+func (s *MCPServer) toolsFor(ctx context.Context) map[string]tools.Tool {
+	if t, ok := s.userTools[middleware.User(ctx)]; ok {
+		return t
+	}
+	return s.tools
+}
+
+// Resources acting as the user of a request
+func (s *MCPServer) resourcesFor(ctx context.Context) []resources.Resource {
+	if r, ok := s.userResources[middleware.User(ctx)]; ok {
+		return r
+	}
+	return s.resources
 }
 
 // Register available resources
 func (s *MCPServer) registerResources() {
-	// Register logs resource
-	s.resources = append(s.resources, resources.NewLogsResource(s.client))
+	s.resources = append(s.resources, newResources(s.client)...)
+}
+
+// Create the resources, acting through a client
+func newResources(c client.Client) []resources.Resource {
+	// Logs resource
+	return []resources.Resource{resources.NewLogsResource(c)}
 }
 
 // Setup middleware chain
-func (s *MCPServer) setupMiddleware() {
+func (s *MCPServer) setupMiddleware() error {
+	// Audit comes first, to also record the requests the others reject. The HTTP transport
+	// rejects requests without a valid bearer token itself, and hands them to auditRejected.
+	if s.config.AuditLogging {
+		file, err := middleware.OpenRotatingFile(auditLogPath(s.config), int64(s.config.AuditLogMaxSize)<<20, s.config.AuditLogMaxFiles)
+		if err != nil {
+			return fmt.Errorf("failed to open the audit log: %w", err)
+		}
+		s.audit = middleware.NewAudit(file)
+		s.middleware = append(s.middleware, s.audit)
+	}
+	// Only the http transport takes requests from other people, stdio serves whoever started it
+	if s.config.Transport == "http" && s.config.Auth.Enabled() {
+		s.auth = middleware.NewAuth(s.config.Auth)
+		s.middleware = append(s.middleware, s.auth)
+	}
+	return nil
+}
+
+// Record a request the HTTP transport rejected for its bearer token, which the middleware never see
+func (s *MCPServer) auditRejected(msg []byte) {
+	var req middleware.JSONRPCRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return
+	}
+	ctx, _ := s.audit.Process(context.Background(), &req)
+	s.audit.Finish(ctx, &req, s.errorResponse(req.ID, -32001, "Unauthorized", "a valid bearer token is required"))
+}
+
+// Path of the audit log, mcp-audit.log next to the config file unless mcp.audit_log sets one
+func auditLogPath(config MCPServerConfig) string {
+	if config.AuditLog != "" {
+		return config.AuditLog
+	}
+	if cfg := viper.ConfigFileUsed(); cfg != "" {
+		return filepath.Join(filepath.Dir(cfg), "mcp-audit.log")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".shipyard", "mcp-audit.log")
 }
 
 // Create success response
@@ -446,15 +801,26 @@ func (s *MCPServer) errorResponse(id interface{}, code int, message string, data
 
 // Load configuration from Viper
 func LoadMCPServerConfig() MCPServerConfig {
-	var config MCPServerConfig
-
 	// Set defaults
 	viper.SetDefault("mcp.transport", "stdio")
+	viper.SetDefault("mcp.host", "127.0.0.1")
 	viper.SetDefault("mcp.port", 8080)
 	viper.SetDefault("mcp.audit_logging", true)
+	viper.SetDefault("mcp.audit_log_max_size", 10)
+	viper.SetDefault("mcp.audit_log_max_files", 5)
+	viper.SetDefault("mcp.max_concurrency", defaultMaxConcurrency)
+	viper.SetDefault("mcp.session_timeout", int(transport.DefaultSessionIdleTimeout/time.Minute))
+	viper.SetDefault("mcp.max_sessions", transport.DefaultMaxSessions)
+
+	// Bearer tokens may come from the environment, comma-separated, rather than the config file
+	_ = viper.BindEnv("mcp.auth.tokens", "SHIPYARD_MCP_AUTH_TOKENS")
 
-	// Unmarshal config
-	viper.UnmarshalKey("mcp", &config)
+	// Unmarshal config. UnmarshalKey would miss the flags and environment variables of the keys,
+	// which only the settings of the whole config include.
+	var settings struct {
+		MCP MCPServerConfig `mapstructure:"mcp"`
+	}
+	_ = viper.Unmarshal(&settings)
 
-	return config
+	return settings.MCP
 }
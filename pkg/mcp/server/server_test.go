@@ -3,17 +3,84 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/shipyard/shipyard-cli/config"
 	"github.com/shipyard/shipyard-cli/pkg/client"
+	"github.com/shipyard/shipyard-cli/pkg/mcp/middleware"
+	"github.com/shipyard/shipyard-cli/pkg/mcp/tools"
+	"github.com/shipyard/shipyard-cli/pkg/mcp/transport"
+	"github.com/shipyard/shipyard-cli/pkg/requests"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
+// fakeTransport hands the messages of a test to the server and collects the responses
+type fakeTransport struct {
+	in  chan []byte
+	out chan []byte
+	ctx context.Context
+}
+
+func newFakeTransport(ctx context.Context) *fakeTransport {
+	return &fakeTransport{in: make(chan []byte), out: make(chan []byte, 10), ctx: ctx}
+}
+
+func (f *fakeTransport) Start(ctx context.Context) error { return nil }
+func (f *fakeTransport) Stop() error                     { return nil }
+
+func (f *fakeTransport) ReadMessage() ([]byte, error) {
+	select {
+	case msg := <-f.in:
+		return msg, nil
+	case <-f.ctx.Done():
+		return nil, f.ctx.Err()
+	}
+}
+
+func (f *fakeTransport) WriteMessage(data []byte) error {
+	f.out <- data
+	return nil
+}
+
+// slowTool blocks until release is closed or its request ends
+type slowTool struct {
+	release chan struct{}
+}
+
+func (slowTool) Definition() tools.ToolDefinition {
+	return tools.ToolDefinition{Name: "slow"}
+}
+
+func (s slowTool) Execute(ctx context.Context, params json.RawMessage) (string, error) {
+	select {
+	case <-s.release:
+		return "slow done", nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+type fastTool struct{}
+
+func (fastTool) Definition() tools.ToolDefinition {
+	return tools.ToolDefinition{Name: "fast"}
+}
+
+func (fastTool) Execute(ctx context.Context, params json.RawMessage) (string, error) {
+	return "fast done", nil
+}
+
 // Mock requester for testing
 type mockRequester struct{}
 
-func (m *mockRequester) Do(method string, uri string, contentType string, body any) ([]byte, error) {
+func (m *mockRequester) Do(_ context.Context, method string, uri string, contentType string, body any) ([]byte, error) {
 	// Return mock response for successful calls
 	return []byte(`{"data": {"environments": []}}`), nil
 }
@@ -121,7 +188,7 @@ func TestMCPServer_HandleCallTool(t *testing.T) {
 		Params:  paramsJSON,
 	}
 
-	response := server.handleCallTool(req)
+	response := server.handleCallTool(context.Background(), req)
 
 	var result map[string]interface{}
 	if err := json.Unmarshal(response, &result); err != nil {
@@ -149,7 +216,7 @@ func TestMCPServer_HandleCallTool_InvalidTool(t *testing.T) {
 		Params:  paramsJSON,
 	}
 
-	response := server.handleCallTool(req)
+	response := server.handleCallTool(context.Background(), req)
 
 	var result map[string]interface{}
 	if err := json.Unmarshal(response, &result); err != nil {
@@ -171,7 +238,7 @@ func TestMCPServer_HandleCallTool_InvalidParams(t *testing.T) {
 		Params:  json.RawMessage(`{invalid json}`),
 	}
 
-	response := server.handleCallTool(req)
+	response := server.handleCallTool(context.Background(), req)
 
 	var result map[string]interface{}
 	if err := json.Unmarshal(response, &result); err != nil {
@@ -297,6 +364,33 @@ func TestMCPServer_Start(t *testing.T) {
 	}
 }
 
+func TestMCPServer_StartHTTP(t *testing.T) {
+	server := NewMCPServer(MCPServerConfig{Transport: "http", Host: "127.0.0.1"}, newMockClient())
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Stop()
+
+	addr := server.transport.(*transport.HTTPTransport).Addr()
+	resp, err := http.Post("http://"+addr.String()+"/mcp", "application/json",
+		strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`))
+	if err != nil {
+		t.Fatalf("Failed to send initialize: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get(transport.SessionHeader) == "" {
+		t.Error("Expected a session ID in the initialize response")
+	}
+
+	var result JSONRPCResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Error != nil || result.ID != float64(1) {
+		t.Errorf("Expected a successful initialize response, got %+v", result)
+	}
+}
+
 func TestMCPServer_Stop(t *testing.T) {
 	server := NewMCPServer(MCPServerConfig{}, newMockClient())
 
@@ -452,31 +546,85 @@ func TestMCPServer_WithShipyardClient(t *testing.T) {
 func TestMCPServer_WithAuditLogging(t *testing.T) {
 	config := MCPServerConfig{
 		AuditLogging: true,
+		AuditLog:     filepath.Join(t.TempDir(), "audit.log"),
 	}
 	server := NewMCPServer(config, newMockClient())
-	server.setupMiddleware()
+	if err := server.setupMiddleware(); err != nil {
+		t.Fatalf("Failed to set up middleware: %v", err)
+	}
+	defer server.audit.Close()
 
-	// Audit middleware was removed as unused, so no middleware is expected
-	if len(server.middleware) != 0 {
-		t.Error("Expected no middleware when audit logging is configured (audit middleware was removed)")
+	if len(server.middleware) != 1 || server.audit == nil {
+		t.Error("Expected the audit middleware when audit logging is configured")
 	}
 
 	// Test without audit logging
 	config.AuditLogging = false
 	server2 := NewMCPServer(config, newMockClient())
-	server2.setupMiddleware()
+	_ = server2.setupMiddleware()
 
 	if len(server2.middleware) != 0 {
 		t.Error("Expected no middleware when audit logging is disabled")
 	}
 }
 
+func TestMCPServer_AuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	server := NewMCPServer(MCPServerConfig{AuditLogging: true, AuditLog: path}, newMockClient())
+	if err := server.setupMiddleware(); err != nil {
+		t.Fatalf("Failed to set up middleware: %v", err)
+	}
+	server.tools["fast"] = fastTool{}
+	server.tools["panic"] = panicTool{}
+
+	ctx := context.Background()
+	server.processMessage(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	server.processMessage(ctx, []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"fast","arguments":{"environment_id":"env-1","api_token":"secret"}}}`))
+	server.processMessage(ctx, []byte(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"missing"}}`))
+	server.processMessage(ctx, []byte(`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"panic"}}`))
+	if err := server.audit.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the audit log: %v", err)
+	}
+	if strings.Contains(string(b), "secret") {
+		t.Errorf("Expected secrets to be redacted, got %s", b)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a record of each tool call and none of tools/list, got %d: %s", len(lines), b)
+	}
+
+	expected := []middleware.AuditRecord{
+		{Method: "tools/call", Tool: "fast", Arguments: json.RawMessage(`{"api_token":"[REDACTED]","environment_id":"env-1"}`), Status: "ok"},
+		{Method: "tools/call", Tool: "missing", Status: "error", ErrorCategory: "server"},
+		{Method: "tools/call", Tool: "panic", Status: "error", ErrorCategory: "internal"},
+	}
+	for i, line := range lines {
+		var record middleware.AuditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Failed to unmarshal record: %v", err)
+		}
+		if record.Time.IsZero() {
+			t.Errorf("Expected a timestamp in %s", line)
+		}
+		want := expected[i]
+		if record.Method != want.Method || record.Tool != want.Tool || string(record.Arguments) != string(want.Arguments) ||
+			record.Status != want.Status || record.ErrorCategory != want.ErrorCategory {
+			t.Errorf("Expected record %+v, got %s", want, line)
+		}
+	}
+}
+
 // Error Handling Tests
 func TestMCPServer_HandleMalformedJSON(t *testing.T) {
 	server := NewMCPServer(MCPServerConfig{}, newMockClient())
 
 	malformedJSON := []byte(`{"jsonrpc": "2.0", "method": "test", invalid}`)
-	response := server.processMessage(malformedJSON)
+	response := server.processMessage(context.Background(), malformedJSON)
 
 	var result map[string]interface{}
 	if err := json.Unmarshal(response, &result); err != nil {
@@ -499,7 +647,7 @@ func TestMCPServer_HandleInvalidJSONRPCVersion(t *testing.T) {
 	server := NewMCPServer(MCPServerConfig{}, newMockClient())
 
 	invalidVersionJSON := []byte(`{"jsonrpc": "1.0", "method": "test", "id": 1}`)
-	response := server.processMessage(invalidVersionJSON)
+	response := server.processMessage(context.Background(), invalidVersionJSON)
 
 	var result map[string]interface{}
 	if err := json.Unmarshal(response, &result); err != nil {
@@ -519,7 +667,7 @@ func TestMCPServer_HandleMissingMethod(t *testing.T) {
 	server := NewMCPServer(MCPServerConfig{}, newMockClient())
 
 	missingMethodJSON := []byte(`{"jsonrpc": "2.0", "id": 1}`)
-	response := server.processMessage(missingMethodJSON)
+	response := server.processMessage(context.Background(), missingMethodJSON)
 
 	var result map[string]interface{}
 	if err := json.Unmarshal(response, &result); err != nil {
@@ -556,7 +704,7 @@ func TestMCPServer_HandleInvalidID(t *testing.T) {
 				ID:      tt.id,
 			}
 			reqJSON, _ := json.Marshal(req)
-			response := server.processMessage(reqJSON)
+			response := server.processMessage(context.Background(), reqJSON)
 
 			var result map[string]interface{}
 			if err := json.Unmarshal(response, &result); err != nil {
@@ -588,7 +736,7 @@ func TestMCPServer_ConcurrentRequests(t *testing.T) {
 				ID:      id,
 			}
 			reqJSON, _ := json.Marshal(req)
-			response := server.processMessage(reqJSON)
+			response := server.processMessage(context.Background(), reqJSON)
 
 			var result map[string]interface{}
 			if err := json.Unmarshal(response, &result); err != nil {
@@ -651,3 +799,639 @@ func TestMCPServer_ContextTimeout(t *testing.T) {
 		t.Error("Expected context to be timed out")
 	}
 }
+
+func TestMCPServer_SlowToolDoesNotBlockFastTool(t *testing.T) {
+	server := NewMCPServer(MCPServerConfig{MaxConcurrency: 2}, newMockClient())
+	defer server.cancel()
+	release := make(chan struct{})
+	server.tools["slow"] = slowTool{release: release}
+	server.tools["fast"] = fastTool{}
+	fake := newFakeTransport(server.ctx)
+	server.transport = fake
+	go server.handleMessages()
+
+	fake.in <- []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow"}}`)
+	fake.in <- []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"fast"}}`)
+
+	for _, want := range []float64{2, 1} {
+		select {
+		case data := <-fake.out:
+			var response JSONRPCResponse
+			if err := json.Unmarshal(data, &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if response.ID != want {
+				t.Fatalf("Expected the response to request %v, got %v", want, response.ID)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timeout waiting for the response to request %v", want)
+		}
+		// The slow tool finishes once the fast one has answered
+		if want == 2 {
+			close(release)
+		}
+	}
+}
+
+func TestMCPServer_MaxConcurrency(t *testing.T) {
+	server := NewMCPServer(MCPServerConfig{MaxConcurrency: 1}, newMockClient())
+	defer server.cancel()
+	release := make(chan struct{})
+	server.tools["slow"] = slowTool{release: release}
+	server.tools["fast"] = fastTool{}
+	fake := newFakeTransport(server.ctx)
+	server.transport = fake
+	go server.handleMessages()
+
+	fake.in <- []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow"}}`)
+	fake.in <- []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"fast"}}`)
+
+	select {
+	case data := <-fake.out:
+		t.Fatalf("Expected the fast tool to wait for the only slot, got response %s", data)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-fake.out:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timeout waiting for the responses once the slot is free")
+		}
+	}
+}
+
+func TestMCPServer_RecoverToolPanic(t *testing.T) {
+	server := NewMCPServer(MCPServerConfig{}, newMockClient())
+	server.tools["panic"] = panicTool{}
+
+	response := server.processMessage(context.Background(),
+		[]byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"panic"}}`))
+
+	var result JSONRPCResponse
+	if err := json.Unmarshal(response, &result); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if result.Error == nil || result.Error.Code != -32603 {
+		t.Errorf("Expected an internal error, got %s", response)
+	}
+}
+
+type panicTool struct{}
+
+func (panicTool) Definition() tools.ToolDefinition {
+	return tools.ToolDefinition{Name: "panic"}
+}
+
+func (panicTool) Execute(ctx context.Context, params json.RawMessage) (string, error) {
+	panic("boom")
+}
+
+func TestMCPServer_Cancellation(t *testing.T) {
+	// A single slot shows that the cancellation does not wait for the request it cancels
+	server := NewMCPServer(MCPServerConfig{MaxConcurrency: 1}, newMockClient())
+	defer server.cancel()
+	server.tools["slow"] = slowTool{release: make(chan struct{})}
+	server.tools["fast"] = fastTool{}
+	fake := newFakeTransport(server.ctx)
+	server.transport = fake
+	go server.handleMessages()
+
+	fake.in <- []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow"}}`)
+	fake.in <- []byte(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1,"reason":"user abort"}}`)
+	fake.in <- []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"fast"}}`)
+
+	select {
+	case data := <-fake.out:
+		var response JSONRPCResponse
+		if err := json.Unmarshal(data, &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response.ID != float64(2) {
+			t.Fatalf("Expected only the response to request 2, got %s", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for the request after the cancelled one")
+	}
+	select {
+	case data := <-fake.out:
+		t.Errorf("Expected no response to the cancelled request, got %s", data)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	server.inflightMu.Lock()
+	defer server.inflightMu.Unlock()
+	if len(server.inflight) != 0 {
+		t.Errorf("Expected no requests in flight, got %d", len(server.inflight))
+	}
+}
+
+func TestMCPServer_CancelUnknownRequest(t *testing.T) {
+	server := NewMCPServer(MCPServerConfig{}, newMockClient())
+
+	response := server.processMessage(context.Background(),
+		[]byte(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"gone"}}`))
+	if response != nil {
+		t.Errorf("Expected no response to a notification, got %s", response)
+	}
+}
+
+// progressTool reports two steps
+type progressTool struct{}
+
+func (progressTool) Definition() tools.ToolDefinition {
+	return tools.ToolDefinition{Name: "progress"}
+}
+
+func (progressTool) Execute(ctx context.Context, params json.RawMessage) (string, error) {
+	tools.ReportProgress(ctx, 1, 2, "First step")
+	tools.ReportProgress(ctx, 2, 2, "")
+	return "done", nil
+}
+
+func TestMCPServer_Progress(t *testing.T) {
+	server := NewMCPServer(MCPServerConfig{}, newMockClient())
+	server.tools["progress"] = progressTool{}
+
+	var notifications []string
+	ctx := transport.WithNotifier(context.Background(), func(msg []byte) error {
+		notifications = append(notifications, string(msg))
+		return nil
+	})
+
+	response := server.processMessage(ctx,
+		[]byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"progress","_meta":{"progressToken":"tok-1"}}}`))
+	if response == nil {
+		t.Fatal("Expected a response")
+	}
+	expected := []string{
+		`{"jsonrpc":"2.0","method":"notifications/progress","params":{"message":"First step","progress":1,"progressToken":"tok-1","total":2}}`,
+		`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progress":2,"progressToken":"tok-1","total":2}}`,
+	}
+	if strings.Join(notifications, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected notifications %v, got %v", expected, notifications)
+	}
+
+	// Without a progress token the client gets no notifications
+	notifications = nil
+	server.processMessage(ctx, []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"progress"}}`))
+	if len(notifications) != 0 {
+		t.Errorf("Expected no notifications without a progress token, got %v", notifications)
+	}
+}
+
+func TestMCPServerConfig_AuthTokensFromEnv(t *testing.T) {
+	t.Setenv("SHIPYARD_MCP_AUTH_TOKENS", "first,second")
+
+	config := LoadMCPServerConfig()
+	if strings.Join(config.Auth.Tokens, ",") != "first,second" {
+		t.Errorf("Expected the tokens of the environment, got %v", config.Auth.Tokens)
+	}
+}
+
+func TestMCPServerConfig_Flags(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	flags := (&cobra.Command{}).Flags()
+	flags.Int("port", 8080, "")
+	if err := flags.Parse([]string{"--port", "9090"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = viper.BindPFlag("mcp.port", flags.Lookup("port"))
+
+	config := LoadMCPServerConfig()
+	if config.Port != 9090 {
+		t.Errorf("Expected the port of the flag, got %d", config.Port)
+	}
+}
+
+// tokenRequester records the API token and URI of every request
+type tokenRequester struct {
+	token string
+	mu    *sync.Mutex
+	calls *[]string
+}
+
+func (r tokenRequester) Do(_ context.Context, method string, uri string, contentType string, body any) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	*r.calls = append(*r.calls, r.token+" "+uri)
+	return []byte(`{"data": []}`), nil
+}
+
+func (r tokenRequester) WithToken(token string) requests.Requester {
+	r.token = token
+	return r
+}
+
+func TestMCPServer_Auth(t *testing.T) {
+	var calls []string
+	requester := tokenRequester{token: "server-token", mu: &sync.Mutex{}, calls: &calls}
+	server := NewMCPServer(MCPServerConfig{
+		Transport: "http",
+		Host:      "127.0.0.1",
+		Auth: config.MCPAuth{
+			Tokens: []string{"shared-secret"},
+			Users: map[string]config.MCPUser{
+				"alice": {Token: "alice-secret", APIToken: "alice-api-token", Org: "alice-org"},
+			},
+		},
+	}, client.New(requester, func() string { return "test-org" }))
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Stop()
+	url := "http://" + server.transport.(*transport.HTTPTransport).Addr().String() + "/mcp"
+
+	post := func(token, session, body string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if session != "" {
+			req.Header.Set(transport.SessionHeader, session)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize"}`
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_environments"}}`
+
+	for _, token := range []string{"", "wrong"} {
+		resp := post(token, "", initialize)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for token %q, got %d", token, resp.StatusCode)
+		}
+		if resp.Header.Get("WWW-Authenticate") == "" {
+			t.Error("Expected a WWW-Authenticate header")
+		}
+		var result JSONRPCResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || result.Error == nil || result.Error.Code != -32001 {
+			t.Errorf("Expected a JSON-RPC authentication error, got %+v", result)
+		}
+	}
+
+	for _, token := range []string{"shared-secret", "alice-secret"} {
+		session := post(token, "", initialize).Header.Get(transport.SessionHeader)
+		if session == "" {
+			t.Fatalf("Expected a session for token %q", token)
+		}
+		if resp := post(token, session, call); resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200 for token %q, got %d", token, resp.StatusCode)
+		}
+	}
+
+	// The shared token acts as the server, alice with alice's own API token and org
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 API calls, got %v", calls)
+	}
+	if !strings.HasPrefix(calls[0], "server-token ") || !strings.Contains(calls[0], "org=test-org") {
+		t.Errorf("Expected the call of the shared token to use the server's token and org, got %s", calls[0])
+	}
+	if !strings.HasPrefix(calls[1], "alice-api-token ") || !strings.Contains(calls[1], "org=alice-org") {
+		t.Errorf("Expected the call of alice to use alice's token and org, got %s", calls[1])
+	}
+
+	// A session only serves the token that opened it
+	session := post("alice-secret", "", initialize).Header.Get(transport.SessionHeader)
+	if resp := post("shared-secret", session, call); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for the session of another token, got %d", resp.StatusCode)
+	}
+}
+
+func TestMCPServer_AuditRejectedToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	server := NewMCPServer(MCPServerConfig{
+		Transport:    "http",
+		Host:         "127.0.0.1",
+		Auth:         config.MCPAuth{Tokens: []string{"shared-secret"}},
+		AuditLogging: true,
+		AuditLog:     path,
+	}, newMockClient())
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	url := "http://" + server.transport.(*transport.HTTPTransport).Addr().String() + "/mcp"
+
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_environments"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer wrong")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401, got %d", resp.StatusCode)
+	}
+	if err := server.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The transport rejects the request before the middleware, and still has it audited
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the audit log: %v", err)
+	}
+	var record middleware.AuditRecord
+	if err := json.Unmarshal(b, &record); err != nil {
+		t.Fatalf("Expected one record, got %s", b)
+	}
+	if record.Method != "tools/call" || record.Tool != "get_environments" || record.Status != "error" || record.ErrorCategory != "unauthorized" {
+		t.Errorf("Expected an unauthorized record of the tool call, got %s", b)
+	}
+}
+
+func TestMCPServer_AuthOnlyForHTTP(t *testing.T) {
+	server := NewMCPServer(MCPServerConfig{
+		Transport: "stdio",
+		Auth:      config.MCPAuth{Tokens: []string{"secret"}},
+	}, newMockClient())
+	_ = server.setupMiddleware()
+
+	if len(server.middleware) != 0 {
+		t.Error("Expected no auth middleware for the stdio transport")
+	}
+}
+
+// Call a tool of the server and decode the response
+func callTool(t *testing.T, server *MCPServer, name, arguments string) JSONRPCResponse {
+	t.Helper()
+	params, _ := json.Marshal(map[string]interface{}{"name": name, "arguments": json.RawMessage(arguments)})
+	response := server.handleCallTool(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: params})
+	var result JSONRPCResponse
+	if err := json.Unmarshal(response, &result); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	return result
+}
+
+// The definitions tools/list shows, by name
+func listedTools(t *testing.T, server *MCPServer) map[string]tools.ToolDefinition {
+	t.Helper()
+	var result struct {
+		Result struct {
+			Tools []struct {
+				tools.ToolDefinition
+				InputSchema map[string]interface{} `json:"inputSchema"`
+			} `json:"tools"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(server.handleListTools(&JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/list"}), &result); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	defs := make(map[string]tools.ToolDefinition)
+	for _, tool := range result.Result.Tools {
+		tool.ToolDefinition.InputSchema = tool.InputSchema
+		defs[tool.Name] = tool.ToolDefinition
+	}
+	return defs
+}
+
+func TestMCPServer_ReadOnly(t *testing.T) {
+	server := NewMCPServer(MCPServerConfig{ReadOnly: true}, newMockClient())
+	server.registerTools()
+
+	listed := listedTools(t, server)
+	for _, name := range []string{"get_environments", "get_environment", "get_logs", "get_snapshots"} {
+		if _, ok := listed[name]; !ok {
+			t.Errorf("Expected %s to be listed", name)
+		}
+	}
+	for _, name := range []string{"stop_environment", "create_snapshot", "set_org", "exec_service", "port_forward"} {
+		if _, ok := listed[name]; ok {
+			t.Errorf("Expected %s not to be listed", name)
+		}
+	}
+
+	result := callTool(t, server, "stop_environment", `{"environment_id":"env-1"}`)
+	if result.Error == nil || result.Error.Message != "Tool not found" {
+		t.Errorf("Expected Tool not found, got %+v", result.Error)
+	}
+}
+
+func TestMCPServer_AllowDenyTools(t *testing.T) {
+	server := NewMCPServer(MCPServerConfig{Tools: config.MCPTools{
+		Allow: []string{"get_environments", "get_environment", "stop_environment"},
+		Deny:  []string{"stop_environment"},
+	}}, newMockClient())
+	server.registerTools()
+
+	listed := listedTools(t, server)
+	if len(listed) != 2 {
+		t.Errorf("Expected 2 tools, got %d", len(listed))
+	}
+	for _, name := range []string{"get_environments", "get_environment"} {
+		if _, ok := listed[name]; !ok {
+			t.Errorf("Expected %s to be listed", name)
+		}
+	}
+	if result := callTool(t, server, "stop_environment", `{"environment_id":"env-1"}`); result.Error == nil {
+		t.Error("Expected a denied tool to fail")
+	}
+}
+
+func TestMCPServer_ToolScopes(t *testing.T) {
+	server := NewMCPServer(MCPServerConfig{Tools: config.MCPTools{
+		Scopes: map[string]config.MCPToolScope{
+			"*":        {Orgs: []string{"test-org"}},
+			"get_logs": {Orgs: []string{"other-org"}},
+			"get_environment": {
+				Environments: []string{"env-1"},
+			},
+		},
+	}}, newMockClient())
+	server.registerTools()
+
+	tests := []struct {
+		name      string
+		tool      string
+		arguments string
+		code      int
+	}{
+		{name: "org in scope", tool: "get_environments", arguments: `{}`},
+		{name: "environment in scope", tool: "get_environment", arguments: `{"environment_id":"env-1"}`},
+		{name: "environment out of scope", tool: "get_environment", arguments: `{"environment_id":"env-2"}`, code: -32002},
+		{name: "org out of scope", tool: "get_logs", arguments: `{"environment_id":"env-1","service_name":"web"}`, code: -32002},
+		{name: "switch to an org out of scope", tool: "set_org", arguments: `{"org_name":"other-org"}`, code: -32002},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := callTool(t, server, tt.tool, tt.arguments)
+			if tt.code == 0 {
+				if result.Error != nil && result.Error.Code == -32002 {
+					t.Errorf("Expected the call to be allowed, got %+v", result.Error)
+				}
+				return
+			}
+			if result.Error == nil || result.Error.Code != tt.code {
+				t.Errorf("Expected error code %d, got %+v", tt.code, result.Error)
+			}
+		})
+	}
+}
+
+func TestMCPServer_EnvironmentScopeLimitsResults(t *testing.T) {
+	server := NewMCPServer(MCPServerConfig{Tools: config.MCPTools{
+		Scopes: map[string]config.MCPToolScope{
+			"*": {Environments: []string{"env-1"}},
+		},
+	}}, newMockClient())
+	server.registerTools()
+	server.registerResources()
+
+	result, err := server.filterResult(server.tools["get_environments"].Definition(),
+		`{"data":[{"id":"env-1"},{"id":"env-2"}],"links":{"next":""}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"data":[{"id":"env-1"}],"links":{"next":""}}`; result != want {
+		t.Errorf("Expected %s, got %s", want, result)
+	}
+
+	read := func(uri string) JSONRPCResponse {
+		params, _ := json.Marshal(map[string]string{"uri": uri})
+		var response JSONRPCResponse
+		if err := json.Unmarshal(server.handleReadResource(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "resources/read", Params: params}), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return response
+	}
+	if response := read("logs://env-2/web"); response.Error == nil || response.Error.Code != -32002 {
+		t.Errorf("Expected the logs of an environment out of scope to be denied, got %+v", response.Error)
+	}
+	if response := read("logs://env-1/web"); response.Error != nil && response.Error.Code == -32002 {
+		t.Errorf("Expected the logs of an environment in scope to be allowed, got %+v", response.Error)
+	}
+}
+
+func TestMCPServer_ConfirmDestructiveTools(t *testing.T) {
+	server := NewMCPServer(MCPServerConfig{Tools: config.MCPTools{Confirm: true}}, newMockClient())
+	server.registerTools()
+
+	listed := listedTools(t, server)
+	properties, _ := listed["stop_environment"].InputSchema.(map[string]interface{})["properties"].(map[string]interface{})
+	if _, ok := properties["confirm"]; !ok {
+		t.Error("Expected stop_environment to take a confirm argument")
+	}
+	properties, _ = listed["get_environment"].InputSchema.(map[string]interface{})["properties"].(map[string]interface{})
+	if _, ok := properties["confirm"]; ok {
+		t.Error("Expected get_environment not to take a confirm argument")
+	}
+	// The definitions of the tools themselves are left as they were
+	if _, ok := server.tools["stop_environment"].Definition().InputSchema.(map[string]interface{})["properties"].(map[string]interface{})["confirm"]; ok {
+		t.Error("Expected the schema of the tool to be copied")
+	}
+
+	result := callTool(t, server, "stop_environment", `{"environment_id":"env-1"}`)
+	if result.Error == nil || result.Error.Code != -32602 || !strings.Contains(result.Error.Message, "confirm: true") {
+		t.Errorf("Expected a confirmation error, got %+v", result.Error)
+	}
+	if result := callTool(t, server, "get_environment", `{"environment_id":"env-1"}`); result.Error != nil && result.Error.Code == -32602 {
+		t.Errorf("Expected a read-only tool to need no confirmation, got %+v", result.Error)
+	}
+	if result := callTool(t, server, "stop_environment", `{"environment_id":"env-1","confirm":true}`); result.Error != nil && result.Error.Code == -32602 {
+		t.Errorf("Expected a confirmed call to go ahead, got %+v", result.Error)
+	}
+}
+
+func TestMCPServer_HandleInitialize_ProtocolVersion(t *testing.T) {
+	server := NewMCPServer(MCPServerConfig{}, newMockClient())
+	tests := []struct {
+		requested string
+		expected  string
+	}{
+		{requested: "2025-06-18", expected: "2025-06-18"},
+		{requested: "2025-03-26", expected: "2025-03-26"},
+		{requested: "2024-11-05", expected: "2024-11-05"},
+		{requested: "1999-01-01", expected: "2024-11-05"},
+	}
+	for _, tt := range tests {
+		params, _ := json.Marshal(map[string]string{"protocolVersion": tt.requested})
+		var result struct {
+			Result struct {
+				ProtocolVersion string `json:"protocolVersion"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(server.handleInitialize(&JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "initialize", Params: params}), &result); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if result.Result.ProtocolVersion != tt.expected {
+			t.Errorf("Expected protocol version %s for %s, got %s", tt.expected, tt.requested, result.Result.ProtocolVersion)
+		}
+	}
+}
+
+func TestMCPServer_ToolAnnotations(t *testing.T) {
+	server := NewMCPServer(MCPServerConfig{}, newMockClient())
+	server.registerTools()
+
+	var result struct {
+		Result struct {
+			Tools []map[string]interface{} `json:"tools"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(server.handleListTools(&JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/list"}), &result); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	outputs := 0
+	for _, tool := range result.Result.Tools {
+		annotations, ok := tool["annotations"].(map[string]interface{})
+		if !ok {
+			t.Errorf("Expected annotations for %v", tool["name"])
+			continue
+		}
+		// A missing destructiveHint or openWorldHint counts as true, so every hint is sent
+		for _, hint := range []string{"readOnlyHint", "destructiveHint", "idempotentHint", "openWorldHint"} {
+			if _, ok := annotations[hint].(bool); !ok {
+				t.Errorf("Expected %s for %v", hint, tool["name"])
+			}
+		}
+		if _, ok := tool["outputSchema"]; ok {
+			outputs++
+		}
+	}
+	if outputs != 6 {
+		t.Errorf("Expected 6 tools with an output schema, got %d", outputs)
+	}
+}
+
+func TestMCPServer_StructuredContent(t *testing.T) {
+	server := NewMCPServer(MCPServerConfig{}, newMockClient())
+	server.registerTools()
+
+	decode := func(name, arguments string) map[string]interface{} {
+		t.Helper()
+		result := callTool(t, server, name, arguments)
+		if result.Error != nil {
+			t.Fatalf("Expected %s to succeed, got %+v", name, result.Error)
+		}
+		b, _ := json.Marshal(result.Result)
+		var content map[string]interface{}
+		_ = json.Unmarshal(b, &content)
+		return content
+	}
+
+	content := decode("get_environments", `{}`)
+	structured, ok := content["structuredContent"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected structuredContent for get_environments, got %v", content)
+	}
+	if _, ok := structured["data"]; !ok {
+		t.Errorf("Expected the data of the environments, got %v", structured)
+	}
+	text := content["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	var fromText map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &fromText); err != nil || len(fromText) != len(structured) {
+		t.Errorf("Expected the text to hold the same JSON, got %s", text)
+	}
+
+	if content := decode("exec_service", `{"environment_id":"env-1","service_name":"web","command":["ls"]}`); content["structuredContent"] != nil {
+		t.Errorf("Expected no structuredContent for a tool without an output schema, got %v", content["structuredContent"])
+	}
+}
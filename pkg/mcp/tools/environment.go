@@ -13,44 +13,54 @@ import (
 	"github.com/shipyard/shipyard-cli/pkg/mcp/validation"
 	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
 	"github.com/shipyard/shipyard-cli/pkg/services/environment"
+	"github.com/shipyard/shipyard-cli/pkg/types"
 )
 
 // toolDefinitions maps tool names to their definitions
 var toolDefinitions = map[string]ToolDefinition{
 	"get_environments": {
-		Name:        "get_environments",
-		Description: "List Shipyard environments with optional filtering",
-		InputSchema: schemas.ListEnvironmentsSchema(),
+		Name:         "get_environments",
+		Description:  "List Shipyard environments with optional filtering",
+		InputSchema:  schemas.ListEnvironmentsSchema(),
+		OutputSchema: schemas.EnvironmentListOutputSchema(),
+		Annotations:  ToolAnnotations{ReadOnlyHint: true},
 	},
 	"get_environment": {
-		Name:        "get_environment",
-		Description: "Get details for a specific environment by ID. The bypass_token in the response can be used as 'shipyard_token' URL parameter to access protected environments without login, example: https://my-environment.myorg.shipyard.host?shipyard_token=[bypass-token]",
-		InputSchema: schemas.EnvironmentIDSchema(),
+		Name:         "get_environment",
+		Description:  "Get details for a specific environment by ID. The bypass_token in the response can be used as 'shipyard_token' URL parameter to access protected environments without login, example: https://my-environment.myorg.shipyard.host?shipyard_token=[bypass-token]",
+		InputSchema:  schemas.EnvironmentIDSchema(),
+		OutputSchema: schemas.EnvironmentOutputSchema(),
+		Annotations:  ToolAnnotations{ReadOnlyHint: true},
 	},
 	"restart_environment": {
 		Name:        "restart_environment",
 		Description: "Restart a stopped environment",
 		InputSchema: schemas.EnvironmentIDSchema(),
+		Annotations: ToolAnnotations{DestructiveHint: true, IdempotentHint: true},
 	},
 	"stop_environment": {
 		Name:        "stop_environment",
 		Description: "Stop a running environment",
 		InputSchema: schemas.EnvironmentIDSchema(),
+		Annotations: ToolAnnotations{DestructiveHint: true, IdempotentHint: true},
 	},
 	"cancel_environment": {
 		Name:        "cancel_environment",
 		Description: "Cancel an environment's latest build",
 		InputSchema: schemas.EnvironmentIDSchema(),
+		Annotations: ToolAnnotations{DestructiveHint: true, IdempotentHint: true},
 	},
 	"rebuild_environment": {
 		Name:        "rebuild_environment",
 		Description: "Rebuild an environment with the latest commit",
 		InputSchema: schemas.EnvironmentIDSchema(),
+		Annotations: ToolAnnotations{DestructiveHint: true},
 	},
 	"revive_environment": {
 		Name:        "revive_environment",
 		Description: "Revive a deleted environment",
 		InputSchema: schemas.EnvironmentIDSchema(),
+		Annotations: ToolAnnotations{IdempotentHint: true},
 	},
 }
 
@@ -88,25 +98,25 @@ func (t *EnvironmentTool) Execute(ctx context.Context, params json.RawMessage) (
 
 	switch t.name {
 	case "get_environments":
-		return t.executeGetEnvironments(params)
+		return t.executeGetEnvironments(ctx, params)
 	case "get_environment":
-		return t.executeGetEnvironment(params)
+		return t.executeGetEnvironment(ctx, params)
 	case "restart_environment":
-		return t.executeRestartEnvironment(params)
+		return t.executeRestartEnvironment(ctx, params)
 	case "stop_environment":
-		return t.executeStopEnvironment(params)
+		return t.executeStopEnvironment(ctx, params)
 	case "cancel_environment":
-		return t.executeCancelEnvironment(params)
+		return t.executeCancelEnvironment(ctx, params)
 	case "rebuild_environment":
-		return t.executeRebuildEnvironment(params)
+		return t.executeRebuildEnvironment(ctx, params)
 	case "revive_environment":
-		return t.executeReviveEnvironment(params)
+		return t.executeReviveEnvironment(ctx, params)
 	default:
 		return "", fmt.Errorf("unknown operation: %s", t.name)
 	}
 }
 
-func (t *EnvironmentTool) executeGetEnvironments(params json.RawMessage) (string, error) {
+func (t *EnvironmentTool) executeGetEnvironments(ctx context.Context, params json.RawMessage) (string, error) {
 	// Parse parameters
 	var toolParams struct {
 		Branch   string `json:"branch,omitempty"`
@@ -114,6 +124,7 @@ func (t *EnvironmentTool) executeGetEnvironments(params json.RawMessage) (string
 		Deleted  bool   `json:"deleted,omitempty"`
 		Page     int    `json:"page,omitempty"`
 		PageSize int    `json:"page_size,omitempty"`
+		AllPages bool   `json:"all_pages,omitempty"`
 	}
 
 	if len(params) > 0 {
@@ -172,7 +183,31 @@ func (t *EnvironmentTool) executeGetEnvironments(params json.RawMessage) (string
 			WithSuggestion("Please ensure the MCP server is configured correctly")
 	}
 
-	body, err := t.client.Requester.Do(http.MethodGet, uri.CreateResourceURI("", "environment", "", "", apiParams), "application/json", nil)
+	if toolParams.AllPages {
+		envs := []types.Environment{}
+		for env, err := range t.client.ListEnvironments(ctx, client.EnvironmentFilter{
+			RepoName: toolParams.RepoName,
+			Branch:   toolParams.Branch,
+			Deleted:  toolParams.Deleted,
+			PageSize: toolParams.PageSize,
+		}) {
+			if err != nil {
+				log.Printf("MCP get_environments error: %v", err)
+				return "", errors.ParseHTTPError("get_environments", err, "")
+			}
+			envs = append(envs, env)
+			if len(envs)%toolParams.PageSize == 0 {
+				ReportProgress(ctx, float64(len(envs)), 0, fmt.Sprintf("Fetched %d environments", len(envs)))
+			}
+		}
+		b, err := json.Marshal(types.RespManyEnvs{Data: envs})
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
+	body, err := t.client.Requester.Do(ctx, http.MethodGet, uri.CreateResourceURI("", "environment", "", "", apiParams), "application/json", nil)
 	if err != nil {
 		log.Printf("MCP get_environments error: %v", err)
 		return "", errors.ParseHTTPError("get_environments", err, "")
@@ -182,7 +217,7 @@ func (t *EnvironmentTool) executeGetEnvironments(params json.RawMessage) (string
 	return string(body), nil
 }
 
-func (t *EnvironmentTool) executeGetEnvironment(params json.RawMessage) (string, error) {
+func (t *EnvironmentTool) executeGetEnvironment(ctx context.Context, params json.RawMessage) (string, error) {
 	var toolParams struct {
 		EnvironmentID string `json:"environment_id"`
 	}
@@ -210,7 +245,7 @@ func (t *EnvironmentTool) executeGetEnvironment(params json.RawMessage) (string,
 			WithSuggestion("Please ensure the MCP server is configured correctly")
 	}
 
-	body, err := t.client.Requester.Do(http.MethodGet, uri.CreateResourceURI("", "environment", toolParams.EnvironmentID, "", apiParams), "application/json", nil)
+	body, err := t.client.Requester.Do(ctx, http.MethodGet, uri.CreateResourceURI("", "environment", toolParams.EnvironmentID, "", apiParams), "application/json", nil)
 	if err != nil {
 		log.Printf("MCP get_environment error: %v", err)
 		return "", errors.ParseHTTPError("get_environment", err, toolParams.EnvironmentID)
@@ -220,7 +255,7 @@ func (t *EnvironmentTool) executeGetEnvironment(params json.RawMessage) (string,
 	return string(body), nil
 }
 
-func (t *EnvironmentTool) executeRestartEnvironment(params json.RawMessage) (string, error) {
+func (t *EnvironmentTool) executeRestartEnvironment(ctx context.Context, params json.RawMessage) (string, error) {
 	var toolParams struct {
 		EnvironmentID string `json:"environment_id"`
 	}
@@ -234,9 +269,15 @@ func (t *EnvironmentTool) executeRestartEnvironment(params json.RawMessage) (str
 		return "", errors.ValidationError("restart_environment", "environment_id", err.Error())
 	}
 
+	done, err := startOperation(ctx, fmt.Sprintf("Restarting environment %s", toolParams.EnvironmentID))
+	if err != nil {
+		return "", err
+	}
+
 	// Use service layer for business logic
 	svc := environment.NewEnvironmentManager(t.client)
-	err := svc.Restart(toolParams.EnvironmentID)
+	err = svc.Restart(ctx, toolParams.EnvironmentID)
+	done()
 	if err != nil {
 		log.Printf("MCP restart_environment info: %v", err)
 		// Return the API error as informational text instead of failing
@@ -246,7 +287,7 @@ func (t *EnvironmentTool) executeRestartEnvironment(params json.RawMessage) (str
 	return fmt.Sprintf("Environment %s queued for restart.", toolParams.EnvironmentID), nil
 }
 
-func (t *EnvironmentTool) executeStopEnvironment(params json.RawMessage) (string, error) {
+func (t *EnvironmentTool) executeStopEnvironment(ctx context.Context, params json.RawMessage) (string, error) {
 	var toolParams struct {
 		EnvironmentID string `json:"environment_id"`
 	}
@@ -260,9 +301,15 @@ func (t *EnvironmentTool) executeStopEnvironment(params json.RawMessage) (string
 		return "", errors.ValidationError("stop_environment", "environment_id", err.Error())
 	}
 
+	done, err := startOperation(ctx, fmt.Sprintf("Stopping environment %s", toolParams.EnvironmentID))
+	if err != nil {
+		return "", err
+	}
+
 	// Use service layer for business logic
 	svc := environment.NewEnvironmentManager(t.client)
-	err := svc.Stop(toolParams.EnvironmentID)
+	err = svc.Stop(ctx, toolParams.EnvironmentID)
+	done()
 	if err != nil {
 		log.Printf("MCP stop_environment info: %v", err)
 		// Return the API error as informational text instead of failing
@@ -272,7 +319,7 @@ func (t *EnvironmentTool) executeStopEnvironment(params json.RawMessage) (string
 	return fmt.Sprintf("Environment %s stopped.", toolParams.EnvironmentID), nil
 }
 
-func (t *EnvironmentTool) executeCancelEnvironment(params json.RawMessage) (string, error) {
+func (t *EnvironmentTool) executeCancelEnvironment(ctx context.Context, params json.RawMessage) (string, error) {
 	var toolParams struct {
 		EnvironmentID string `json:"environment_id"`
 	}
@@ -286,9 +333,15 @@ func (t *EnvironmentTool) executeCancelEnvironment(params json.RawMessage) (stri
 		return "", errors.ValidationError("cancel_environment", "environment_id", err.Error())
 	}
 
+	done, err := startOperation(ctx, fmt.Sprintf("Cancelling the build of environment %s", toolParams.EnvironmentID))
+	if err != nil {
+		return "", err
+	}
+
 	// Use service layer for business logic
 	svc := environment.NewEnvironmentManager(t.client)
-	err := svc.Cancel(toolParams.EnvironmentID)
+	err = svc.Cancel(ctx, toolParams.EnvironmentID)
+	done()
 	if err != nil {
 		log.Printf("MCP cancel_environment info: %v", err)
 		// Return the API error as informational text instead of failing
@@ -298,7 +351,7 @@ func (t *EnvironmentTool) executeCancelEnvironment(params json.RawMessage) (stri
 	return fmt.Sprintf("Environment %s build canceled.", toolParams.EnvironmentID), nil
 }
 
-func (t *EnvironmentTool) executeRebuildEnvironment(params json.RawMessage) (string, error) {
+func (t *EnvironmentTool) executeRebuildEnvironment(ctx context.Context, params json.RawMessage) (string, error) {
 	var toolParams struct {
 		EnvironmentID string `json:"environment_id"`
 	}
@@ -312,9 +365,15 @@ func (t *EnvironmentTool) executeRebuildEnvironment(params json.RawMessage) (str
 		return "", errors.ValidationError("rebuild_environment", "environment_id", err.Error())
 	}
 
+	done, err := startOperation(ctx, fmt.Sprintf("Rebuilding environment %s", toolParams.EnvironmentID))
+	if err != nil {
+		return "", err
+	}
+
 	// Use service layer for business logic
 	svc := environment.NewEnvironmentManager(t.client)
-	err := svc.Rebuild(toolParams.EnvironmentID)
+	err = svc.Rebuild(ctx, toolParams.EnvironmentID)
+	done()
 	if err != nil {
 		log.Printf("MCP rebuild_environment info: %v", err)
 		// Return the API error as informational text instead of failing
@@ -324,7 +383,7 @@ func (t *EnvironmentTool) executeRebuildEnvironment(params json.RawMessage) (str
 	return fmt.Sprintf("Environment %s queued for rebuild.", toolParams.EnvironmentID), nil
 }
 
-func (t *EnvironmentTool) executeReviveEnvironment(params json.RawMessage) (string, error) {
+func (t *EnvironmentTool) executeReviveEnvironment(ctx context.Context, params json.RawMessage) (string, error) {
 	var toolParams struct {
 		EnvironmentID string `json:"environment_id"`
 	}
@@ -338,9 +397,15 @@ func (t *EnvironmentTool) executeReviveEnvironment(params json.RawMessage) (stri
 		return "", errors.ValidationError("revive_environment", "environment_id", err.Error())
 	}
 
+	done, err := startOperation(ctx, fmt.Sprintf("Reviving environment %s", toolParams.EnvironmentID))
+	if err != nil {
+		return "", err
+	}
+
 	// Use service layer for business logic
 	svc := environment.NewEnvironmentManager(t.client)
-	err := svc.Revive(toolParams.EnvironmentID)
+	err = svc.Revive(ctx, toolParams.EnvironmentID)
+	done()
 	if err != nil {
 		log.Printf("MCP revive_environment info: %v", err)
 		// Return the API error as informational text instead of failing
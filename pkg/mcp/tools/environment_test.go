@@ -12,7 +12,7 @@ import (
 // Mock requester for testing
 type mockRequester struct{}
 
-func (m *mockRequester) Do(method, uri string, contentType string, body interface{}) ([]byte, error) {
+func (m *mockRequester) Do(_ context.Context, method, uri string, contentType string, body interface{}) ([]byte, error) {
 	// Return mock JSON response for single environment
 	if strings.Contains(uri, "environment/env-") && method == "GET" {
 		return []byte(`{
@@ -480,6 +480,25 @@ func TestEnvironmentTool_RebuildEnvironment_Success(t *testing.T) {
 	}
 }
 
+func TestEnvironmentTool_RebuildEnvironment_Progress(t *testing.T) {
+	client := newMockClient()
+	tool := NewEnvironmentTool(client, "rebuild_environment")
+
+	var messages []string
+	ctx := WithProgress(context.Background(), func(progress, total float64, message string) {
+		messages = append(messages, message)
+	})
+	if _, err := tool.Execute(ctx, []byte(`{"environment_id":"env-12345"}`)); err != nil {
+		t.Fatalf("Expected no error for rebuild, got: %v", err)
+	}
+
+	// The call returns before the first report of a call still waiting on the API is due.
+	expected := []string{"Rebuilding environment env-12345"}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected progress %q, got %q", expected, messages)
+	}
+}
+
 func TestEnvironmentTool_RebuildEnvironment_NonExistent(t *testing.T) {
 	client := newMockClient()
 	tool := NewEnvironmentTool(client, "rebuild_environment")
@@ -560,6 +579,32 @@ func TestEnvironmentTool_Registration(t *testing.T) {
 	}
 }
 
+func TestEnvironmentTool_Annotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations ToolAnnotations
+		output      bool
+	}{
+		{name: "get_environments", annotations: ToolAnnotations{ReadOnlyHint: true}, output: true},
+		{name: "get_environment", annotations: ToolAnnotations{ReadOnlyHint: true}, output: true},
+		{name: "stop_environment", annotations: ToolAnnotations{DestructiveHint: true, IdempotentHint: true}},
+		{name: "rebuild_environment", annotations: ToolAnnotations{DestructiveHint: true}},
+		{name: "revive_environment", annotations: ToolAnnotations{IdempotentHint: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition := NewEnvironmentTool(newMockClient(), tt.name).Definition()
+			if definition.Annotations != tt.annotations {
+				t.Errorf("Expected annotations %+v, got %+v", tt.annotations, definition.Annotations)
+			}
+			if (definition.OutputSchema != nil) != tt.output {
+				t.Errorf("Expected an output schema: %v, got %v", tt.output, definition.OutputSchema)
+			}
+		})
+	}
+}
+
 func TestEnvironmentTool_Schema_Validation(t *testing.T) {
 	client := newMockClient()
 	tool := NewEnvironmentTool(client, "get_environments")
@@ -35,6 +35,7 @@ func (t *LogsTool) Definition() ToolDefinition {
 		Name:        t.name,
 		Description: "Get logs from a service in an environment",
 		InputSchema: schemas.LogsSchema(),
+		Annotations: ToolAnnotations{ReadOnlyHint: true},
 	}
 }
 
@@ -17,19 +17,23 @@ import (
 // orgToolDefinitions maps tool names to their definitions
 var orgToolDefinitions = map[string]ToolDefinition{
 	"get_orgs": {
-		Name:        "get_orgs",
-		Description: "List all organizations that the user has access to",
-		InputSchema: schemas.EmptySchema(),
+		Name:         "get_orgs",
+		Description:  "List all organizations that the user has access to",
+		InputSchema:  schemas.EmptySchema(),
+		OutputSchema: schemas.OrgListOutputSchema(),
+		Annotations:  ToolAnnotations{ReadOnlyHint: true},
 	},
 	"get_org": {
 		Name:        "get_org",
 		Description: "Get the currently configured default organization",
 		InputSchema: schemas.EmptySchema(),
+		Annotations: ToolAnnotations{ReadOnlyHint: true},
 	},
 	"set_org": {
 		Name:        "set_org",
 		Description: "Set the default organization in config",
 		InputSchema: schemas.OrgNameSchema(),
+		Annotations: ToolAnnotations{IdempotentHint: true},
 	},
 }
 
@@ -67,7 +71,7 @@ func (t *OrgTool) Execute(ctx context.Context, params json.RawMessage) (string,
 
 	switch t.name {
 	case "get_orgs":
-		return t.executeGetOrgs(params)
+		return t.executeGetOrgs(ctx, params)
 	case "get_org":
 		return t.executeGetOrg(params)
 	case "set_org":
@@ -77,9 +81,9 @@ func (t *OrgTool) Execute(ctx context.Context, params json.RawMessage) (string,
 	}
 }
 
-func (t *OrgTool) executeGetOrgs(params json.RawMessage) (string, error) {
+func (t *OrgTool) executeGetOrgs(ctx context.Context, params json.RawMessage) (string, error) {
 	// Call API directly to get raw JSON response (same as --json flag)
-	body, err := t.client.Requester.Do(http.MethodGet, uri.CreateResourceURI("", "org", "", "", nil), "application/json", nil)
+	body, err := t.client.Requester.Do(ctx, http.MethodGet, uri.CreateResourceURI("", "org", "", "", nil), "application/json", nil)
 	if err != nil {
 		log.Printf("MCP get_orgs error: %v", err)
 		return "", errors.ParseHTTPError("get_orgs", err, "")
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Progress reports how far a tool call has got, as a notifications/progress message to the client.
+// total is 0 when it is not known.
+type Progress func(progress, total float64, message string)
+
+type progressKey struct{}
+
+// WithProgress returns a context whose ReportProgress calls reach p.
+func WithProgress(ctx context.Context, p Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+// ReportProgress reports the progress of the tool call ctx belongs to.
+// It does nothing if the client did not ask for progress.
+func ReportProgress(ctx context.Context, progress, total float64, message string) {
+	if p, ok := ctx.Value(progressKey{}).(Progress); ok {
+		p(progress, total, message)
+	}
+}
+
+// progressInterval is how often a tool call waiting on the API reports that it is still going.
+var progressInterval = 2 * time.Second
+
+// startOperation reports that a tool call sends its change to the API, unless the call was
+// cancelled before, so that a cancelled call changes nothing. Until done is called, it keeps
+// reporting that the call is waiting on the API, with no known total.
+func startOperation(ctx context.Context, message string) (done func(), err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	ReportProgress(ctx, 0, 0, message)
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		start := time.Now()
+		for step := 1; ; step++ {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ReportProgress(ctx, float64(step), 0, fmt.Sprintf("%s, waiting for the API for %s", message, time.Since(start).Round(time.Second)))
+			}
+		}
+	}()
+	// No report is sent once done returns, the result follows it.
+	return func() {
+		close(stop)
+		<-stopped
+	}, nil
+}
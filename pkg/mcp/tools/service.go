@@ -15,14 +15,17 @@ import (
 // serviceToolDefinitions maps service tool names to their definitions
 var serviceToolDefinitions = map[string]ToolDefinition{
 	"get_services": {
-		Name:        "get_services",
-		Description: "List services in an environment",
-		InputSchema: schemas.EnvironmentIDSchema(),
+		Name:         "get_services",
+		Description:  "List services in an environment",
+		InputSchema:  schemas.EnvironmentIDSchema(),
+		OutputSchema: schemas.ServicesOutputSchema(),
+		Annotations:  ToolAnnotations{ReadOnlyHint: true},
 	},
 	"exec_service": {
 		Name:        "exec_service",
 		Description: "Execute commands in service containers",
 		InputSchema: schemas.ServiceExecSchema(),
+		Annotations: ToolAnnotations{DestructiveHint: true},
 	},
 	"port_forward": {
 		Name:        "port_forward",
@@ -65,7 +68,7 @@ func (t *ServiceTool) Execute(ctx context.Context, params json.RawMessage) (stri
 
 	switch t.name {
 	case "get_services":
-		return t.executeGetServices(params)
+		return t.executeGetServices(ctx, params)
 	case "exec_service":
 		return t.executeExecService(params)
 	case "port_forward":
@@ -75,7 +78,7 @@ func (t *ServiceTool) Execute(ctx context.Context, params json.RawMessage) (stri
 	}
 }
 
-func (t *ServiceTool) executeGetServices(params json.RawMessage) (string, error) {
+func (t *ServiceTool) executeGetServices(ctx context.Context, params json.RawMessage) (string, error) {
 	var toolParams struct {
 		EnvironmentID string `json:"environment_id"`
 	}
@@ -90,7 +93,7 @@ func (t *ServiceTool) executeGetServices(params json.RawMessage) (string, error)
 	}
 
 	// Get services from the client
-	services, err := t.client.AllServices(toolParams.EnvironmentID)
+	services, err := t.client.AllServices(ctx, toolParams.EnvironmentID)
 	if err != nil {
 		log.Printf("MCP get_services error: %v", err)
 		return "", errors.ParseHTTPError("get_services", err, toolParams.EnvironmentID)
@@ -56,7 +56,7 @@ func TestServiceTool_Definition(t *testing.T) {
 // Mock requester for testing services
 type servicesMockRequester struct{}
 
-func (m *servicesMockRequester) Do(method, uri string, contentType string, body interface{}) ([]byte, error) {
+func (m *servicesMockRequester) Do(_ context.Context, method, uri string, contentType string, body interface{}) ([]byte, error) {
 	// Return mock JSON response for environment with services
 	if strings.Contains(uri, "environment/env-") && method == "GET" {
 		return []byte(`{
@@ -20,6 +20,7 @@ var telepresenceToolDefinitions = map[string]ToolDefinition{
 		Name:        "telepresence_connect",
 		Description: "Connect to an environment via telepresence",
 		InputSchema: schemas.EnvironmentIDSchema(),
+		Annotations: ToolAnnotations{OpenWorldHint: true},
 	},
 }
 
@@ -57,13 +58,13 @@ func (t *TelepresenceTool) Execute(ctx context.Context, params json.RawMessage)
 
 	switch t.name {
 	case "telepresence_connect":
-		return t.executeTelepresenceConnect(params)
+		return t.executeTelepresenceConnect(ctx, params)
 	default:
 		return "", fmt.Errorf("unknown operation: %s", t.name)
 	}
 }
 
-func (t *TelepresenceTool) executeTelepresenceConnect(params json.RawMessage) (string, error) {
+func (t *TelepresenceTool) executeTelepresenceConnect(ctx context.Context, params json.RawMessage) (string, error) {
 	// Parse parameters
 	var toolParams struct {
 		EnvironmentID string `json:"environment_id"`
@@ -86,7 +87,7 @@ func (t *TelepresenceTool) executeTelepresenceConnect(params json.RawMessage) (s
 	}
 
 	// Get kubeconfig for the environment
-	k, err := k8s.NewConfig(t.client, toolParams.EnvironmentID)
+	k, err := k8s.NewConfig(ctx, t.client, toolParams.EnvironmentID)
 	if err != nil {
 		return "", errors.ParseHTTPError("telepresence_connect", err, toolParams.EnvironmentID)
 	}
@@ -16,4 +16,20 @@ type ToolDefinition struct {
 	Name        string      `json:"name"`
 	Description string      `json:"description"`
 	InputSchema interface{} `json:"inputSchema"`
+	// OutputSchema describes the structuredContent of tools that return JSON
+	OutputSchema interface{}     `json:"outputSchema,omitempty"`
+	Annotations  ToolAnnotations `json:"annotations"`
+}
+
+// ToolAnnotations tell clients what a tool does. The hints are always sent, as a missing
+// destructiveHint or openWorldHint counts as true.
+type ToolAnnotations struct {
+	// ReadOnlyHint tools change nothing, so they are the only ones offered by mcp.read_only
+	ReadOnlyHint bool `json:"readOnlyHint"`
+	// DestructiveHint tools can lose work or data, so mcp.tools.confirm makes them ask first
+	DestructiveHint bool `json:"destructiveHint"`
+	// IdempotentHint tools change nothing more when called again with the same arguments
+	IdempotentHint bool `json:"idempotentHint"`
+	// OpenWorldHint tools reach beyond Shipyard, such as into the containers of an environment
+	OpenWorldHint bool `json:"openWorldHint"`
 }
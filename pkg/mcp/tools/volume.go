@@ -13,24 +13,30 @@ import (
 	"github.com/shipyard/shipyard-cli/pkg/mcp/schemas"
 	"github.com/shipyard/shipyard-cli/pkg/mcp/validation"
 	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
+	"github.com/shipyard/shipyard-cli/pkg/types"
 )
 
 // volumeToolDefinitions maps volume tool names to their definitions
 var volumeToolDefinitions = map[string]ToolDefinition{
 	"get_volumes": {
-		Name:        "get_volumes",
-		Description: "List volumes in an environment",
-		InputSchema: schemas.EnvironmentIDSchema(),
+		Name:         "get_volumes",
+		Description:  "List volumes in an environment",
+		InputSchema:  schemas.EnvironmentIDSchema(),
+		OutputSchema: schemas.VolumeListOutputSchema(),
+		Annotations:  ToolAnnotations{ReadOnlyHint: true},
 	},
 	"get_snapshots": {
-		Name:        "get_snapshots",
-		Description: "List volume snapshots in an environment",
-		InputSchema: schemas.SnapshotsListSchema(),
+		Name:         "get_snapshots",
+		Description:  "List volume snapshots in an environment",
+		InputSchema:  schemas.SnapshotsListSchema(),
+		OutputSchema: schemas.SnapshotListOutputSchema(),
+		Annotations:  ToolAnnotations{ReadOnlyHint: true},
 	},
 	"reset_volume": {
 		Name:        "reset_volume",
 		Description: "Reset volume to initial state",
 		InputSchema: schemas.VolumeResetSchema(),
+		Annotations: ToolAnnotations{DestructiveHint: true, IdempotentHint: true},
 	},
 	"create_snapshot": {
 		Name:        "create_snapshot",
@@ -41,6 +47,7 @@ var volumeToolDefinitions = map[string]ToolDefinition{
 		Name:        "load_snapshot",
 		Description: "Load volume snapshot",
 		InputSchema: schemas.SnapshotLoadSchema(),
+		Annotations: ToolAnnotations{DestructiveHint: true, IdempotentHint: true},
 	},
 }
 
@@ -78,21 +85,21 @@ func (t *VolumeTool) Execute(ctx context.Context, params json.RawMessage) (strin
 
 	switch t.name {
 	case "get_volumes":
-		return t.executeGetVolumes(params)
+		return t.executeGetVolumes(ctx, params)
 	case "get_snapshots":
-		return t.executeGetSnapshots(params)
+		return t.executeGetSnapshots(ctx, params)
 	case "reset_volume":
-		return t.executeResetVolume(params)
+		return t.executeResetVolume(ctx, params)
 	case "create_snapshot":
-		return t.executeCreateSnapshot(params)
+		return t.executeCreateSnapshot(ctx, params)
 	case "load_snapshot":
-		return t.executeLoadSnapshot(params)
+		return t.executeLoadSnapshot(ctx, params)
 	default:
 		return "", fmt.Errorf("unknown volume operation: %s", t.name)
 	}
 }
 
-func (t *VolumeTool) executeGetVolumes(params json.RawMessage) (string, error) {
+func (t *VolumeTool) executeGetVolumes(ctx context.Context, params json.RawMessage) (string, error) {
 	var toolParams struct {
 		EnvironmentID string `json:"environment_id"`
 	}
@@ -116,6 +123,7 @@ func (t *VolumeTool) executeGetVolumes(params json.RawMessage) (string, error) {
 
 	// Call API directly to get raw JSON response (same as --json flag)
 	body, err := t.client.Requester.Do(
+		ctx,
 		http.MethodGet,
 		uri.CreateResourceURI("", "environment", toolParams.EnvironmentID, "volumes", requestParams),
 		"application/json",
@@ -130,11 +138,12 @@ func (t *VolumeTool) executeGetVolumes(params json.RawMessage) (string, error) {
 	return string(body), nil
 }
 
-func (t *VolumeTool) executeGetSnapshots(params json.RawMessage) (string, error) {
+func (t *VolumeTool) executeGetSnapshots(ctx context.Context, params json.RawMessage) (string, error) {
 	var toolParams struct {
 		EnvironmentID string `json:"environment_id"`
 		Page          int    `json:"page,omitempty"`
 		PageSize      int    `json:"page_size,omitempty"`
+		AllPages      bool   `json:"all_pages,omitempty"`
 	}
 
 	if err := json.Unmarshal(params, &toolParams); err != nil {
@@ -159,6 +168,25 @@ func (t *VolumeTool) executeGetSnapshots(params json.RawMessage) (string, error)
 		return "", errors.ValidationError("get_snapshots", "pagination", err.Error())
 	}
 
+	if toolParams.AllPages {
+		snapshots := []types.Snapshot{}
+		for snapshot, err := range t.client.ListSnapshots(ctx, toolParams.EnvironmentID, toolParams.PageSize) {
+			if err != nil {
+				log.Printf("MCP get_snapshots error: %v", err)
+				return "", errors.ParseHTTPError("get_snapshots", err, toolParams.EnvironmentID)
+			}
+			snapshots = append(snapshots, snapshot)
+			if len(snapshots)%toolParams.PageSize == 0 {
+				ReportProgress(ctx, float64(len(snapshots)), 0, fmt.Sprintf("Fetched %d snapshots", len(snapshots)))
+			}
+		}
+		b, err := json.Marshal(types.SnapshotsResponse{Data: snapshots})
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
 	// Build request parameters
 	requestParams := make(map[string]string)
 	if t.client.OrgLookupFn != nil {
@@ -171,6 +199,7 @@ func (t *VolumeTool) executeGetSnapshots(params json.RawMessage) (string, error)
 
 	// Call API directly to get raw JSON response (same as --json flag)
 	body, err := t.client.Requester.Do(
+		ctx,
 		http.MethodGet,
 		uri.CreateResourceURI("", "environment", toolParams.EnvironmentID, "volume-snapshots", requestParams),
 		"application/json",
@@ -185,7 +214,7 @@ func (t *VolumeTool) executeGetSnapshots(params json.RawMessage) (string, error)
 	return string(body), nil
 }
 
-func (t *VolumeTool) executeResetVolume(params json.RawMessage) (string, error) {
+func (t *VolumeTool) executeResetVolume(ctx context.Context, params json.RawMessage) (string, error) {
 	var toolParams struct {
 		EnvironmentID string `json:"environment_id"`
 		VolumeName    string `json:"volume_name"`
@@ -210,14 +239,21 @@ func (t *VolumeTool) executeResetVolume(params json.RawMessage) (string, error)
 		requestParams["org"] = org
 	}
 
+	done, err := startOperation(ctx, fmt.Sprintf("Resetting volume '%s' in environment %s", toolParams.VolumeName, toolParams.EnvironmentID))
+	if err != nil {
+		return "", err
+	}
+
 	// Make API call
 	subresource := fmt.Sprintf("volume/%s/volume-reset", toolParams.VolumeName)
-	_, err := t.client.Requester.Do(
+	_, err = t.client.Requester.Do(
+		ctx,
 		http.MethodPost,
 		uri.CreateResourceURI("", "environment", toolParams.EnvironmentID, subresource, requestParams),
 		"application/json",
 		nil,
 	)
+	done()
 	if err != nil {
 		log.Printf("MCP reset_volume error: %v", err)
 		return "", errors.ParseHTTPError("reset_volume", err, toolParams.EnvironmentID)
@@ -226,7 +262,7 @@ func (t *VolumeTool) executeResetVolume(params json.RawMessage) (string, error)
 	return fmt.Sprintf("Volume '%s' in environment %s has been reset to its initial state.", toolParams.VolumeName, toolParams.EnvironmentID), nil
 }
 
-func (t *VolumeTool) executeCreateSnapshot(params json.RawMessage) (string, error) {
+func (t *VolumeTool) executeCreateSnapshot(ctx context.Context, params json.RawMessage) (string, error) {
 	var toolParams struct {
 		EnvironmentID string `json:"environment_id"`
 		Note          string `json:"note,omitempty"`
@@ -252,13 +288,20 @@ func (t *VolumeTool) executeCreateSnapshot(params json.RawMessage) (string, erro
 		"note": toolParams.Note,
 	}
 
+	done, err := startOperation(ctx, fmt.Sprintf("Creating a snapshot of environment %s", toolParams.EnvironmentID))
+	if err != nil {
+		return "", err
+	}
+
 	// Make API call
-	_, err := t.client.Requester.Do(
+	_, err = t.client.Requester.Do(
+		ctx,
 		http.MethodPost,
 		uri.CreateResourceURI("", "environment", toolParams.EnvironmentID, "snapshot-create", requestParams),
 		"application/json",
 		requestBody,
 	)
+	done()
 	if err != nil {
 		log.Printf("MCP create_snapshot error: %v", err)
 		return "", errors.ParseHTTPError("create_snapshot", err, toolParams.EnvironmentID)
@@ -272,7 +315,7 @@ func (t *VolumeTool) executeCreateSnapshot(params json.RawMessage) (string, erro
 	return result, nil
 }
 
-func (t *VolumeTool) executeLoadSnapshot(params json.RawMessage) (string, error) {
+func (t *VolumeTool) executeLoadSnapshot(ctx context.Context, params json.RawMessage) (string, error) {
 	var toolParams struct {
 		EnvironmentID       string `json:"environment_id"`
 		SequenceNumber      int    `json:"sequence_number"`
@@ -313,13 +356,20 @@ func (t *VolumeTool) executeLoadSnapshot(params json.RawMessage) (string, error)
 		attrs["source_application_id"] = toolParams.SourceApplicationID
 	}
 
+	done, err := startOperation(ctx, fmt.Sprintf("Loading snapshot %d into environment %s", toolParams.SequenceNumber, toolParams.EnvironmentID))
+	if err != nil {
+		return "", err
+	}
+
 	// Make API call
-	_, err := t.client.Requester.Do(
+	_, err = t.client.Requester.Do(
+		ctx,
 		http.MethodPost,
 		uri.CreateResourceURI("", "environment", toolParams.EnvironmentID, "snapshot-load", requestParams),
 		"application/json",
 		requestBody,
 	)
+	done()
 	if err != nil {
 		log.Printf("MCP load_snapshot error: %v", err)
 		return "", errors.ParseHTTPError("load_snapshot", err, toolParams.EnvironmentID)
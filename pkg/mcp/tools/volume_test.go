@@ -3,8 +3,10 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/shipyard/shipyard-cli/pkg/client"
 )
@@ -12,7 +14,7 @@ import (
 // Mock requester for testing volumes
 type volumesMockRequester struct{}
 
-func (m *volumesMockRequester) Do(method, uri string, contentType string, body interface{}) ([]byte, error) {
+func (m *volumesMockRequester) Do(_ context.Context, method, uri string, contentType string, body interface{}) ([]byte, error) {
 	// Return mock JSON response for volumes
 	if strings.Contains(uri, "volumes") && method == "GET" {
 		return []byte(`{
@@ -236,6 +238,88 @@ func TestVolumeTool_Execute_CreateSnapshot(t *testing.T) {
 	}
 }
 
+func TestVolumeTool_Execute_CreateSnapshotProgress(t *testing.T) {
+	defer func(interval time.Duration) { progressInterval = interval }(progressInterval)
+	progressInterval = 10 * time.Millisecond
+
+	tool := NewVolumeTool(client.New(&slowRequester{delay: 55 * time.Millisecond}, func() string { return "test-org" }), "create_snapshot")
+
+	var reports []string
+	ctx := WithProgress(context.Background(), func(progress, total float64, message string) {
+		reports = append(reports, fmt.Sprintf("%v/%v %s", progress, total, message))
+	})
+	if _, err := tool.Execute(ctx, []byte(`{"environment_id":"env-123"}`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The call keeps reporting while it waits on the API, with progress going up.
+	if len(reports) < 3 {
+		t.Fatalf("Expected progress while the API call is in flight, got %q", reports)
+	}
+	if reports[0] != "0/0 Creating a snapshot of environment env-123" {
+		t.Errorf("Expected the call to report its start first, got %q", reports[0])
+	}
+	for i, report := range reports[1:] {
+		expected := fmt.Sprintf("%d/0 Creating a snapshot of environment env-123, waiting for the API for 0s", i+1)
+		if report != expected {
+			t.Errorf("Expected progress %q, got %q", expected, report)
+		}
+	}
+}
+
+func TestVolumeTool_Execute_CreateSnapshotCancelledInFlight(t *testing.T) {
+	tool := NewVolumeTool(client.New(&slowRequester{delay: time.Minute}, func() string { return "test-org" }), "create_snapshot")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	if _, err := tool.Execute(ctx, []byte(`{"environment_id":"env-123"}`)); err == nil {
+		t.Error("Expected the cancelled call to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected cancelling to stop the API call, it took %s", elapsed)
+	}
+}
+
+// slowRequester answers after delay, or when the context of the request is done.
+type slowRequester struct {
+	delay time.Duration
+}
+
+func (r *slowRequester) Do(ctx context.Context, method, uri string, contentType string, body interface{}) ([]byte, error) {
+	select {
+	case <-time.After(r.delay):
+		return []byte(`{}`), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestVolumeTool_Execute_CreateSnapshotCancelled(t *testing.T) {
+	requester := &countingRequester{}
+	tool := NewVolumeTool(client.New(requester, func() string { return "test-org" }), "create_snapshot")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := tool.Execute(ctx, []byte(`{"environment_id":"env-123"}`)); err != context.Canceled {
+		t.Errorf("Expected the cancelled call to fail, got: %v", err)
+	}
+	if requester.calls != 0 {
+		t.Errorf("Expected no API call for a cancelled call, got %d", requester.calls)
+	}
+}
+
+// countingRequester counts the API calls of a tool
+type countingRequester struct {
+	calls int
+}
+
+func (r *countingRequester) Do(_ context.Context, method, uri string, contentType string, body interface{}) ([]byte, error) {
+	r.calls++
+	return []byte(`{}`), nil
+}
+
 func TestVolumeTool_Execute_LoadSnapshot(t *testing.T) {
 	mockClient := client.New(&volumesMockRequester{}, func() string { return "test-org" })
 	tool := NewVolumeTool(mockClient, "load_snapshot")
@@ -0,0 +1,653 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SessionHeader carries the session ID of the Streamable HTTP transport.
+	SessionHeader = "Mcp-Session-Id"
+
+	// maxMessageSize limits the body of a POST request.
+	maxMessageSize = 4 << 20
+	// shutdownTimeout bounds how long Stop waits for the requests in flight.
+	shutdownTimeout = 10 * time.Second
+	// DefaultSessionIdleTimeout and DefaultMaxSessions apply when HTTPConfig does not set them.
+	DefaultSessionIdleTimeout = 30 * time.Minute
+	DefaultMaxSessions        = 100
+)
+
+// Handler processes a JSON-RPC message and returns the response, or nil for a notification.
+// The context ends when the client goes away, and carries a Notifier for messages to the client
+// before the response.
+type Handler func(ctx context.Context, msg []byte) []byte
+
+// HandlerTransport is a Transport with several clients at once, such as HTTP. The server gives it
+// a Handler instead of reading the messages with ReadMessage, so each response reaches its client.
+type HandlerTransport interface {
+	Transport
+	SetHandler(h Handler)
+}
+
+// Notifier sends a message to the client of a request, such as a progress notification.
+type Notifier func(msg []byte) error
+
+type notifierKey struct{}
+
+// WithNotifier returns a context whose Notify calls reach n.
+func WithNotifier(ctx context.Context, n Notifier) context.Context {
+	return context.WithValue(ctx, notifierKey{}, n)
+}
+
+// Notify sends a message to the client of the request ctx belongs to.
+// It does nothing if the transport cannot reach the client before the response.
+func Notify(ctx context.Context, msg []byte) error {
+	if n, ok := ctx.Value(notifierKey{}).(Notifier); ok {
+		return n(msg)
+	}
+	return nil
+}
+
+type sessionKey struct{}
+
+type tokenKey struct{}
+
+// BearerToken returns the bearer token a request was sent with, or an empty string.
+func BearerToken(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey{}).(string)
+	return token
+}
+
+// WithSession returns a context of a request of the session id.
+func WithSession(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, id)
+}
+
+// SessionID returns the session a request belongs to, or an empty string for a transport with a
+// single client. The IDs of requests are only unique within a session.
+func SessionID(ctx context.Context) string {
+	id, _ := ctx.Value(sessionKey{}).(string)
+	return id
+}
+
+// HTTPConfig configures the HTTP transport.
+type HTTPConfig struct {
+	// Addr is the host and port to listen on, e.g. 127.0.0.1:8080.
+	Addr string
+	// AllowedOrigins are the browser origins allowed to call the server, e.g. https://app.example.com.
+	// Without any, only pages served from the loopback interface are, to prevent DNS rebinding.
+	// Requests without an Origin header, from other programs, are always allowed.
+	AllowedOrigins []string
+	// Authenticate checks the bearer token of every request, if set. Requests without a valid
+	// one are rejected with 401 Unauthorized.
+	Authenticate func(token string) bool
+	// Rejected is given the message of every POST request that Authenticate rejected, if set,
+	// e.g. to audit it, since the server never handles it.
+	Rejected func(msg []byte)
+	// SessionIdleTimeout ends the sessions that sent no request for that long, unless they have
+	// an open stream. Clients that come back after it start a new session.
+	SessionIdleTimeout time.Duration
+	// MaxSessions limits the sessions open at once. New ones are refused with 503 Service
+	// Unavailable until others end or expire.
+	MaxSessions int
+}
+
+// HTTPTransport serves the MCP Streamable HTTP transport on /mcp, and the HTTP+SSE transport
+// of the 2024-11-05 protocol on /sse and /messages for older clients.
+type HTTPTransport struct {
+	config   HTTPConfig
+	handler  Handler
+	server   *http.Server
+	listener net.Listener
+	ctx      context.Context
+	cancel   context.CancelFunc
+	mu       sync.RWMutex
+	sessions map[string]*session
+}
+
+// session is a client that sent initialize, or opened a legacy SSE stream.
+type session struct {
+	id string
+	// events is read by the SSE stream of the session, if one is open.
+	events chan []byte
+	// streaming is set while an SSE stream reads events.
+	streaming bool
+	// legacy sessions get the responses on their SSE stream rather than in the POST response.
+	legacy bool
+	// token is the bearer token that opened the session, the only one that may use it.
+	token string
+	// lastUsed is the time of the last request of the session, to expire idle ones.
+	lastUsed time.Time
+	// requests counts the requests of the session in flight, which keep it from expiring.
+	requests int
+	done     chan struct{}
+	once     sync.Once
+}
+
+func (s *session) close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// errTooManySessions is returned by newSession when MaxSessions are open.
+var errTooManySessions = errors.New("too many sessions")
+
+// NewHTTPTransport creates a new HTTP transport
+func NewHTTPTransport(config HTTPConfig) *HTTPTransport {
+	if config.SessionIdleTimeout <= 0 {
+		config.SessionIdleTimeout = DefaultSessionIdleTimeout
+	}
+	if config.MaxSessions <= 0 {
+		config.MaxSessions = DefaultMaxSessions
+	}
+	return &HTTPTransport{
+		config:   config,
+		sessions: make(map[string]*session),
+	}
+}
+
+// SetHandler sets the handler of the messages, before Start.
+func (t *HTTPTransport) SetHandler(h Handler) {
+	t.handler = h
+}
+
+// Start listens on the configured address and serves the requests in the background.
+func (t *HTTPTransport) Start(ctx context.Context) error {
+	if t.handler == nil {
+		return errors.New("no handler is set for the HTTP transport")
+	}
+	ln, err := net.Listen("tcp", t.config.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", t.config.Addr, err)
+	}
+
+	t.mu.Lock()
+	t.ctx, t.cancel = context.WithCancel(ctx)
+	t.listener = ln
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", t.handleMCP)
+	mux.HandleFunc("GET /sse", t.handleSSE)
+	mux.HandleFunc("POST /messages", t.handleLegacyMessage)
+	t.server = &http.Server{
+		Handler:           t.checkOrigin(t.checkToken(mux)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	t.mu.Unlock()
+
+	go func() {
+		if err := t.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("MCP HTTP server error: %v", err)
+		}
+	}()
+	go t.expireSessions(t.ctx)
+	log.Printf("MCP HTTP transport listening on %s", ln.Addr())
+	return nil
+}
+
+// Addr returns the address the transport listens on, once started.
+func (t *HTTPTransport) Addr() net.Addr {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.listener == nil {
+		return nil
+	}
+	return t.listener.Addr()
+}
+
+// Stop closes the SSE streams, then waits for the requests in flight to finish.
+func (t *HTTPTransport) Stop() error {
+	t.mu.Lock()
+	if t.cancel != nil {
+		t.cancel()
+	}
+	for id, s := range t.sessions {
+		s.close()
+		delete(t.sessions, id)
+	}
+	srv := t.server
+	t.mu.Unlock()
+
+	if srv == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(ctx)
+}
+
+// ReadMessage blocks until the transport stops: the messages go to the handler instead.
+func (t *HTTPTransport) ReadMessage() ([]byte, error) {
+	t.mu.RLock()
+	ctx := t.ctx
+	t.mu.RUnlock()
+	if ctx == nil {
+		return nil, errors.New("transport not started")
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// WriteMessage sends a server-initiated message to every session with an open SSE stream.
+func (t *HTTPTransport) WriteMessage(data []byte) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, s := range t.sessions {
+		if s.streaming {
+			t.send(s, data)
+		}
+	}
+	return nil
+}
+
+// send queues a message on the SSE stream of a session, dropping it if the client does not keep up.
+func (t *HTTPTransport) send(s *session, data []byte) {
+	select {
+	case s.events <- data:
+	case <-s.done:
+	default:
+		log.Printf("Dropped a message for MCP session %s, its stream is full", s.id)
+	}
+}
+
+func (t *HTTPTransport) newSession(r *http.Request, legacy bool) (*session, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	s := &session{
+		id:       hex.EncodeToString(b),
+		events:   make(chan []byte, 64),
+		legacy:   legacy,
+		token:    BearerToken(r.Context()),
+		lastUsed: time.Now(),
+		done:     make(chan struct{}),
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.sessions) >= t.config.MaxSessions {
+		// Idle sessions make room before a new one is refused
+		t.endIdleSessions(time.Now())
+		if len(t.sessions) >= t.config.MaxSessions {
+			return nil, errTooManySessions
+		}
+	}
+	t.sessions[s.id] = s
+	return s, nil
+}
+
+// session returns an open session and marks it as used, or nil.
+func (t *HTTPTransport) session(id string) *session {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.sessions[id]
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	if t.idle(s, now) {
+		s.close()
+		delete(t.sessions, id)
+		return nil
+	}
+	s.lastUsed = now
+	return s
+}
+
+// idle reports whether a session expired, the caller holding t.mu.
+func (t *HTTPTransport) idle(s *session, now time.Time) bool {
+	return !s.streaming && s.requests == 0 && now.Sub(s.lastUsed) > t.config.SessionIdleTimeout
+}
+
+// endIdleSessions ends the sessions that expired, the caller holding t.mu.
+func (t *HTTPTransport) endIdleSessions(now time.Time) {
+	for id, s := range t.sessions {
+		if t.idle(s, now) {
+			s.close()
+			delete(t.sessions, id)
+		}
+	}
+}
+
+// expireSessions ends idle sessions until ctx ends, so that clients that went away without
+// ending theirs do not keep them open.
+func (t *HTTPTransport) expireSessions(ctx context.Context) {
+	ticker := time.NewTicker(max(t.config.SessionIdleTimeout/2, 10*time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			t.mu.Lock()
+			t.endIdleSessions(now)
+			t.mu.Unlock()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *HTTPTransport) endSession(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.sessions[id]
+	if ok {
+		s.close()
+		delete(t.sessions, id)
+	}
+	return ok
+}
+
+// checkOrigin rejects requests from browser pages of origins that are not allowed.
+func (t *HTTPTransport) checkOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && !t.originAllowed(origin) {
+			http.Error(w, "Forbidden: origin not allowed", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkToken rejects requests without a valid bearer token, if the transport requires one, and
+// passes the token on in the context of the request.
+func (t *HTTPTransport) checkToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if t.config.Authenticate == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || !t.config.Authenticate(token) {
+			if t.config.Rejected != nil && r.Method == http.MethodPost {
+				if msg, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize)); err == nil {
+					t.config.Rejected(msg)
+				}
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="shipyard-mcp"`)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			// -32001 is the authentication error of the MCP server
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32001,"message":"Unauthorized","data":"a valid bearer token is required"}}`))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tokenKey{}, token)))
+	})
+}
+
+func (t *HTTPTransport) originAllowed(origin string) bool {
+	if len(t.config.AllowedOrigins) > 0 {
+		return slices.Contains(t.config.AllowedOrigins, "*") || slices.Contains(t.config.AllowedOrigins, origin)
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	switch u.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// handleMCP serves the Streamable HTTP transport: POST sends a message, GET opens a stream
+// for messages from the server and DELETE ends the session.
+func (t *HTTPTransport) handleMCP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		t.handlePost(w, r)
+	case http.MethodGet:
+		if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			http.Error(w, "Not Acceptable: the client must accept text/event-stream", http.StatusNotAcceptable)
+			return
+		}
+		s, ok := t.requireSession(w, r)
+		if !ok {
+			return
+		}
+		t.stream(w, r, s, "")
+	case http.MethodDelete:
+		id := r.Header.Get(SessionHeader)
+		if id == "" {
+			http.Error(w, "Bad Request: missing "+SessionHeader+" header", http.StatusBadRequest)
+			return
+		}
+		if !t.endSession(id) {
+			http.Error(w, "Not Found: unknown session", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (t *HTTPTransport) requireSession(w http.ResponseWriter, r *http.Request) (*session, bool) {
+	id := r.Header.Get(SessionHeader)
+	if id == "" {
+		http.Error(w, "Bad Request: missing "+SessionHeader+" header", http.StatusBadRequest)
+		return nil, false
+	}
+	s := t.session(id)
+	if s == nil || s.token != BearerToken(r.Context()) {
+		// The client starts a new session with initialize when it sees 404.
+		http.Error(w, "Not Found: unknown session", http.StatusNotFound)
+		return nil, false
+	}
+	return s, true
+}
+
+func (t *HTTPTransport) handlePost(w http.ResponseWriter, r *http.Request) {
+	msg, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize))
+	if err != nil {
+		http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var envelope struct {
+		Method string `json:"method"`
+	}
+	_ = json.Unmarshal(msg, &envelope)
+	var s *session
+	if envelope.Method == "initialize" {
+		if s, err = t.newSession(r, false); err != nil {
+			sessionError(w, err)
+			return
+		}
+		w.Header().Set(SessionHeader, s.id)
+	} else {
+		var ok bool
+		if s, ok = t.requireSession(w, r); !ok {
+			return
+		}
+	}
+	// A long request keeps its session from expiring meanwhile
+	t.mu.Lock()
+	s.requests++
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		s.requests--
+		s.lastUsed = time.Now()
+		t.mu.Unlock()
+	}()
+
+	// Notifications before the response turn the response into an SSE stream.
+	var mu sync.Mutex
+	var sse *eventWriter
+	var finished bool
+	ctx := WithNotifier(WithSession(r.Context(), s.id), func(data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if finished {
+			return errors.New("the request is already answered")
+		}
+		if sse == nil {
+			sse = newEventWriter(w)
+		}
+		return sse.write("message", data)
+	})
+
+	response := t.handler(ctx, msg)
+
+	mu.Lock()
+	defer mu.Unlock()
+	finished = true
+	switch {
+	case sse != nil:
+		if response != nil {
+			_ = sse.write("message", response)
+		}
+	case response == nil:
+		// A notification or a response from the client.
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(response)
+	}
+}
+
+// sessionError answers a request whose session could not be opened.
+func sessionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errTooManySessions) {
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "Service Unavailable: too many sessions are open", http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+}
+
+// handleSSE opens a session of the HTTP+SSE transport, whose first event tells where to post messages.
+func (t *HTTPTransport) handleSSE(w http.ResponseWriter, r *http.Request) {
+	s, err := t.newSession(r, true)
+	if err != nil {
+		sessionError(w, err)
+		return
+	}
+	defer t.endSession(s.id)
+	t.stream(w, r, s, "/messages?sessionId="+s.id)
+}
+
+// handleLegacyMessage takes a message of an HTTP+SSE session. The response goes to its stream.
+func (t *HTTPTransport) handleLegacyMessage(w http.ResponseWriter, r *http.Request) {
+	s := t.session(r.URL.Query().Get("sessionId"))
+	if s == nil || !s.legacy || s.token != BearerToken(r.Context()) {
+		http.Error(w, "Not Found: unknown session", http.StatusNotFound)
+		return
+	}
+	msg, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize))
+	if err != nil {
+		http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+
+	// The POST request ends now, so the message is handled within the lifetime of the stream.
+	go func() {
+		ctx, cancel := context.WithCancel(t.ctx)
+		defer cancel()
+		go func() {
+			select {
+			case <-s.done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		notifyCtx := WithNotifier(WithSession(ctx, s.id), func(data []byte) error {
+			t.send(s, data)
+			return nil
+		})
+		if response := t.handler(notifyCtx, msg); response != nil {
+			t.send(s, response)
+		}
+	}()
+}
+
+// stream writes the events of a session as Server-Sent Events until the client, the session or
+// the transport goes away. A non-empty endpoint is sent first, for the HTTP+SSE transport.
+func (t *HTTPTransport) stream(w http.ResponseWriter, r *http.Request, s *session, endpoint string) {
+	t.mu.Lock()
+	if s.streaming {
+		t.mu.Unlock()
+		http.Error(w, "Conflict: the session already has a stream", http.StatusConflict)
+		return
+	}
+	s.streaming = true
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		s.streaming = false
+		s.lastUsed = time.Now()
+		t.mu.Unlock()
+	}()
+
+	sse := newEventWriter(w)
+	if endpoint != "" {
+		if err := sse.write("endpoint", []byte(endpoint)); err != nil {
+			return
+		}
+	} else {
+		sse.flush()
+	}
+	for {
+		select {
+		case data := <-s.events:
+			if err := sse.write("message", data); err != nil {
+				return
+			}
+		case <-s.done:
+			return
+		case <-r.Context().Done():
+			return
+		case <-t.ctx.Done():
+			return
+		}
+	}
+}
+
+// eventWriter writes Server-Sent Events.
+type eventWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func newEventWriter(w http.ResponseWriter) *eventWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	f, _ := w.(http.Flusher)
+	return &eventWriter{w: w, flusher: f}
+}
+
+func (e *eventWriter) write(event string, data []byte) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "event: %s\n", event)
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	if _, err := e.w.Write(b.Bytes()); err != nil {
+		return err
+	}
+	e.flush()
+	return nil
+}
+
+func (e *eventWriter) flush() {
+	if e.flusher != nil {
+		e.flusher.Flush()
+	}
+}
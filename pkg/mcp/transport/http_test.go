@@ -0,0 +1,325 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// echoHandler answers every request with its method, and sends a notification first for "notify".
+func echoHandler(ctx context.Context, msg []byte) []byte {
+	var req struct {
+		ID     any    `json:"id"`
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(msg, &req); err != nil || req.ID == nil {
+		return nil
+	}
+	if req.Method == "notify" {
+		_ = Notify(ctx, []byte(`{"jsonrpc":"2.0","method":"notifications/progress"}`))
+	}
+	b, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": req.Method})
+	return b
+}
+
+func startHTTPTransport(t *testing.T, config HTTPConfig) (*HTTPTransport, string) {
+	t.Helper()
+	config.Addr = "127.0.0.1:0"
+	transport := NewHTTPTransport(config)
+	transport.SetHandler(echoHandler)
+	if err := transport.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start transport: %v", err)
+	}
+	t.Cleanup(func() { _ = transport.Stop() })
+	return transport, "http://" + transport.Addr().String()
+}
+
+func post(t *testing.T, url, session, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if session != "" {
+		req.Header.Set(SessionHeader, session)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestHTTPTransport_Sessions(t *testing.T) {
+	_, base := startHTTPTransport(t, HTTPConfig{})
+
+	resp := post(t, base+"/mcp", "", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	session := resp.Header.Get(SessionHeader)
+	if session == "" {
+		t.Fatal("Expected a session ID in the initialize response")
+	}
+	if got := readBody(t, resp); got != `{"id":1,"jsonrpc":"2.0","result":"initialize"}` {
+		t.Errorf("Unexpected initialize response: %s", got)
+	}
+
+	resp = post(t, base+"/mcp", session, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	if got := readBody(t, resp); got != `{"id":2,"jsonrpc":"2.0","result":"tools/list"}` {
+		t.Errorf("Unexpected response: %s", got)
+	}
+
+	resp = post(t, base+"/mcp", session, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected status 202 for a notification, got %d", resp.StatusCode)
+	}
+
+	resp = post(t, base+"/mcp", "", `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without a session, got %d", resp.StatusCode)
+	}
+	resp = post(t, base+"/mcp", "unknown", `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown session, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, base+"/mcp", nil)
+	req.Header.Set(SessionHeader, session)
+	del, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = del.Body.Close()
+	if del.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected status 204 when ending the session, got %d", del.StatusCode)
+	}
+	resp = post(t, base+"/mcp", session, `{"jsonrpc":"2.0","id":4,"method":"tools/list"}`)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 after the session ended, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPTransport_ConcurrentSessions(t *testing.T) {
+	_, base := startHTTPTransport(t, HTTPConfig{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp := post(t, base+"/mcp", "", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+			session := resp.Header.Get(SessionHeader)
+			method := fmt.Sprintf("call-%d", i)
+			resp = post(t, base+"/mcp", session, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":%q}`, i, method))
+			want := fmt.Sprintf(`{"id":%d,"jsonrpc":"2.0","result":%q}`, i, method)
+			if got := readBody(t, resp); got != want {
+				t.Errorf("Expected %s, got %s", want, got)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestHTTPTransport_SessionIdleTimeout(t *testing.T) {
+	transport, base := startHTTPTransport(t, HTTPConfig{SessionIdleTimeout: 100 * time.Millisecond})
+
+	session := post(t, base+"/mcp", "", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`).Header.Get(SessionHeader)
+	// Requests within the timeout keep the session open
+	for i := 0; i < 3; i++ {
+		time.Sleep(60 * time.Millisecond)
+		if resp := post(t, base+"/mcp", session, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`); resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 for a session in use, got %d", resp.StatusCode)
+		}
+	}
+
+	// The sweep ends the session once it is idle, without a request looking it up
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		transport.mu.RLock()
+		n := len(transport.sessions)
+		transport.mu.RUnlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the idle session to expire, but %d are open", n)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if resp := post(t, base+"/mcp", session, `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an expired session, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPTransport_MaxSessions(t *testing.T) {
+	_, base := startHTTPTransport(t, HTTPConfig{MaxSessions: 2})
+
+	var sessions []string
+	for i := 0; i < 2; i++ {
+		resp := post(t, base+"/mcp", "", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		sessions = append(sessions, resp.Header.Get(SessionHeader))
+	}
+	resp := post(t, base+"/mcp", "", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503 past the session limit, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+	sse, err := http.Get(base + "/sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = sse.Body.Close()
+	if sse.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 for a legacy stream past the session limit, got %d", sse.StatusCode)
+	}
+
+	// Ending a session makes room for another
+	req, _ := http.NewRequest(http.MethodDelete, base+"/mcp", nil)
+	req.Header.Set(SessionHeader, sessions[0])
+	del, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = del.Body.Close()
+	if resp := post(t, base+"/mcp", "", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 once a session ended, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPTransport_NotificationsStream(t *testing.T) {
+	_, base := startHTTPTransport(t, HTTPConfig{})
+	session := post(t, base+"/mcp", "", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`).Header.Get(SessionHeader)
+
+	resp := post(t, base+"/mcp", session, `{"jsonrpc":"2.0","id":2,"method":"notify"}`)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %s", ct)
+	}
+	want := "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n" +
+		"event: message\ndata: {\"id\":2,\"jsonrpc\":\"2.0\",\"result\":\"notify\"}\n\n"
+	if got := readBody(t, resp); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestHTTPTransport_Origin(t *testing.T) {
+	_, base := startHTTPTransport(t, HTTPConfig{AllowedOrigins: []string{"https://app.example.com"}})
+
+	for origin, status := range map[string]int{
+		"https://app.example.com":  http.StatusOK,
+		"https://evil.example.com": http.StatusForbidden,
+		"":                         http.StatusOK,
+	} {
+		req, _ := http.NewRequest(http.MethodPost, base+"/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`))
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("Expected status %d for origin %q, got %d", status, origin, resp.StatusCode)
+		}
+	}
+
+	local := NewHTTPTransport(HTTPConfig{})
+	if !local.originAllowed("http://localhost:3000") || local.originAllowed("http://rebind.example.com") {
+		t.Error("Expected only local origins to be allowed by default")
+	}
+}
+
+func TestHTTPTransport_LegacySSE(t *testing.T) {
+	_, base := startHTTPTransport(t, HTTPConfig{})
+
+	resp, err := http.Get(base + "/sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	events := bufio.NewReader(resp.Body)
+	readEvent := func() (string, string) {
+		var event, data string
+		for {
+			line, err := events.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Failed to read event: %v", err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "":
+				return event, data
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+
+	event, endpoint := readEvent()
+	if event != "endpoint" || !strings.HasPrefix(endpoint, "/messages?sessionId=") {
+		t.Fatalf("Expected the endpoint event, got %s %s", event, endpoint)
+	}
+	msg := post(t, base+endpoint, "", `{"jsonrpc":"2.0","id":7,"method":"tools/list"}`)
+	if msg.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", msg.StatusCode)
+	}
+	event, data := readEvent()
+	if event != "message" || data != `{"id":7,"jsonrpc":"2.0","result":"tools/list"}` {
+		t.Errorf("Unexpected event: %s %s", event, data)
+	}
+}
+
+func TestHTTPTransport_Stop(t *testing.T) {
+	transport, base := startHTTPTransport(t, HTTPConfig{})
+	session := post(t, base+"/mcp", "", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`).Header.Get(SessionHeader)
+
+	req, _ := http.NewRequest(http.MethodGet, base+"/mcp", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(SessionHeader, session)
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Body.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- transport.Stop() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected a graceful shutdown, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the open stream not to hold up the shutdown")
+	}
+	if _, err := transport.ReadMessage(); err != context.Canceled {
+		t.Errorf("Expected ReadMessage to end with the transport, got: %v", err)
+	}
+}
@@ -11,11 +11,13 @@ import (
 
 // StdioTransport implements Transport interface for stdio communication
 type StdioTransport struct {
-	reader  *bufio.Reader
-	writer  *bufio.Writer
-	ctx     context.Context
-	cancel  context.CancelFunc
-	mu      sync.RWMutex
+	reader *bufio.Reader
+	writer *bufio.Writer
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.RWMutex
+	// writeMu keeps the responses of requests handled at once from interleaving
+	writeMu sync.Mutex
 	msgChan chan []byte
 	errChan chan error
 }
@@ -119,6 +121,9 @@ func (t *StdioTransport) WriteMessage(data []byte) error {
 		return t.ctx.Err()
 	}
 
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
 	// Write message with newline
 	_, err := t.writer.Write(append(data, '\n'))
 	if err != nil {
@@ -390,3 +390,37 @@ func TestStdioTransport_Cleanup(t *testing.T) {
 		t.Errorf("Expected no error stopping already stopped transport, got: %v", err)
 	}
 }
+
+func TestStdioTransport_ConcurrentWrites(t *testing.T) {
+	var output bytes.Buffer
+	transport := &StdioTransport{
+		reader: bufio.NewReader(strings.NewReader("")),
+		writer: bufio.NewWriter(&output),
+	}
+	if err := transport.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start transport: %v", err)
+	}
+
+	message := strings.Repeat("x", 8192)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := transport.WriteMessage([]byte(message)); err != nil {
+				t.Errorf("Error in concurrent write: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	if len(lines) != 20 {
+		t.Fatalf("Expected 20 messages, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if line != message {
+			t.Fatal("Expected every message on a line of its own, got interleaved output")
+		}
+	}
+}
@@ -20,11 +20,30 @@ import (
 )
 
 type Requester interface {
-	Do(method string, uri string, contentType string, body any) ([]byte, error)
+	Do(ctx context.Context, method string, uri string, contentType string, body any) ([]byte, error)
+}
+
+// StatusError is returned for a response with a non-2xx status code.
+// Its message is the error returned by the API.
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return e.Message
+}
+
+// IsUnauthorized reports whether err is the API rejecting the token.
+func IsUnauthorized(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.Code == http.StatusUnauthorized
 }
 
 type HTTPClient struct {
 	userAgentType string
+	// token replaces the token of the CLI, if set.
+	token string
 }
 
 func New() HTTPClient {
@@ -35,13 +54,21 @@ func NewWithUserAgent(userAgentType string) HTTPClient {
 	return HTTPClient{userAgentType: userAgentType}
 }
 
-func (c HTTPClient) Do(method, uri, contentType string, body any) ([]byte, error) {
-	var token string
-	var err error
-	// TODO: refactor the CLI initialization process this to make the client not depend on global state.
-	token, err = auth.APIToken()
-	if err != nil {
-		return nil, err
+// WithToken returns a client that sends its requests with token rather than the token of the CLI.
+func (c HTTPClient) WithToken(token string) Requester {
+	c.token = token
+	return c
+}
+
+func (c HTTPClient) Do(ctx context.Context, method, uri, contentType string, body any) ([]byte, error) {
+	token := c.token
+	if token == "" {
+		var err error
+		// TODO: refactor the CLI initialization process this to make the client not depend on global state.
+		token, err = auth.APIToken()
+		if err != nil {
+			return nil, err
+		}
 	}
 	start := time.Now()
 	defer func() {
@@ -63,7 +90,7 @@ func (c HTTPClient) Do(method, uri, contentType string, body any) ([]byte, error
 		reqBody = bytes.NewReader(serialized)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*20)
+	ctx, cancel := context.WithTimeout(ctx, time.Second*20)
 	defer cancel()
 	req, err := http.NewRequestWithContext(ctx, method, uri, reqBody)
 	if err != nil {
@@ -91,15 +118,15 @@ func (c HTTPClient) Do(method, uri, contentType string, body any) ([]byte, error
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		if len(b) == 0 {
-			return nil, fmt.Errorf("empty response")
+			return nil, &StatusError{Code: resp.StatusCode, Message: "empty response"}
 		}
 		errString := types.ErrorFromResponse(b)
 		if errString == "" {
-			return nil, errors.New(string(b))
+			return nil, &StatusError{Code: resp.StatusCode, Message: string(b)}
 		}
 		// Force the first character of the error string from the API to be lower-case.
 		errString = strings.ToLower(errString[:1]) + errString[1:]
-		return nil, errors.New(errString)
+		return nil, &StatusError{Code: resp.StatusCode, Message: errString}
 	}
 
 	return b, nil
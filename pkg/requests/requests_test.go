@@ -1,11 +1,14 @@
 package requests
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/shipyard/shipyard-cli/version"
 )
@@ -57,7 +60,7 @@ func TestUserAgentTypes(t *testing.T) {
 			// For this test, we'll modify the client to avoid the auth dependency
 
 			// Make a request
-			_, err := client.Do("GET", server.URL, "application/json", nil)
+			_, err := client.Do(context.Background(), "GET", server.URL, "application/json", nil)
 
 			// We expect an error because auth.APIToken() will fail in test environment
 			// but we're mainly testing that the user agent is set correctly in the server handler
@@ -68,3 +71,27 @@ func TestUserAgentTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestDoStopsWhenCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := New().WithToken("test").Do(ctx, http.MethodGet, server.URL, "application/json", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the request to be cancelled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the request to stop when cancelled, it took %s", elapsed)
+	}
+}
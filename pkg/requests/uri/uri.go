@@ -8,11 +8,16 @@ import (
 	"github.com/spf13/viper"
 )
 
-func CreateResourceURI(action, resource, id, subresource string, params map[string]string) string {
-	baseURL := "https://shipyard.build/api/v1"
+// BaseURL returns the API URL in use: the 'api_url' config value, or the production API.
+func BaseURL() string {
 	if value := viper.GetString("api_url"); value != "" {
-		baseURL = value
+		return value
 	}
+	return "https://shipyard.build/api/v1"
+}
+
+func CreateResourceURI(action, resource, id, subresource string, params map[string]string) string {
+	baseURL := BaseURL()
 
 	var uri string
 
@@ -0,0 +1,123 @@
+package resolve
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Checkout describes the git checkout a command runs in.
+type Checkout struct {
+	RepoName          string
+	Branch            string
+	PullRequestNumber string
+}
+
+func (c Checkout) String() string {
+	s := fmt.Sprintf("repo %s, branch %s", c.RepoName, c.Branch)
+	if c.PullRequestNumber != "" {
+		s += ", PR #" + c.PullRequestNumber
+	}
+	return s
+}
+
+var errNotGitRepo = errors.New("the current directory is not a git repository")
+
+// DetectCheckout reads the repo name from the "origin" remote, or from the 'repo_name' config value,
+// and the branch currently checked out.
+// CI variables fill in the branch on a detached HEAD and provide the PR number, when available.
+func DetectCheckout() (Checkout, error) {
+	// A project config names the repo for apps of a monorepo, whose remote is the same.
+	repo := viper.GetString("repo_name")
+	if repo != "" {
+		if _, err := git("rev-parse", "--git-dir"); err != nil {
+			return Checkout{}, errNotGitRepo
+		}
+	} else {
+		remote, err := git("remote", "get-url", "origin")
+		if err != nil {
+			if _, statErr := git("rev-parse", "--git-dir"); statErr != nil {
+				return Checkout{}, errNotGitRepo
+			}
+			return Checkout{}, fmt.Errorf("the repository has no origin remote: %w", err)
+		}
+		repo = RepoNameFromURL(remote)
+		if repo == "" {
+			return Checkout{}, fmt.Errorf("could not read the repo name from the origin remote %q", remote)
+		}
+	}
+
+	// symbolic-ref fails on a detached HEAD, which is common in CI.
+	branch, err := git("symbolic-ref", "--short", "-q", "HEAD")
+	if err != nil || branch == "" {
+		branch = ciBranch()
+	}
+	if branch == "" {
+		return Checkout{}, errors.New("could not determine the current branch, HEAD is detached")
+	}
+
+	return Checkout{RepoName: repo, Branch: branch, PullRequestNumber: ciPullRequestNumber()}, nil
+}
+
+// RepoNameFromURL returns the repository name of a git remote URL, e.g. "flask-backend"
+// for both git@github.com:org/flask-backend.git and https://github.com/org/flask-backend.
+func RepoNameFromURL(remote string) string {
+	remote = strings.TrimSpace(remote)
+	remote = strings.TrimSuffix(remote, "/")
+	remote = strings.TrimSuffix(remote, ".git")
+	// scp-like syntax: git@host:org/repo
+	if i := strings.LastIndex(remote, ":"); i >= 0 && !strings.Contains(remote, "://") {
+		remote = remote[i+1:]
+	}
+	name := path.Base(remote)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+func git(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// ciBranch returns the source branch from the variables set by common CI providers.
+func ciBranch() string {
+	for _, name := range []string{"GITHUB_HEAD_REF", "GITHUB_REF_NAME", "CI_COMMIT_REF_NAME", "CIRCLE_BRANCH", "BRANCH_NAME"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+var githubPullRef = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// ciPullRequestNumber returns the PR number from the variables set by common CI providers.
+func ciPullRequestNumber() string {
+	if m := githubPullRef.FindStringSubmatch(os.Getenv("GITHUB_REF")); m != nil {
+		return m[1]
+	}
+	for _, name := range []string{"CI_MERGE_REQUEST_IID", "CIRCLE_PR_NUMBER", "CHANGE_ID"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,210 @@
+// Package resolve infers the environment a command acts on when no environment ID is given,
+// by matching the current git checkout against the environments in the org.
+package resolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/viper"
+
+	"github.com/shipyard/shipyard-cli/pkg/client"
+	"github.com/shipyard/shipyard-cli/pkg/tui"
+	"github.com/shipyard/shipyard-cli/pkg/types"
+)
+
+// Resolver finds the environment that belongs to the current git checkout.
+type Resolver struct {
+	client client.Client
+	detect func() (Checkout, error)
+	// out is used for reporting the resolved environment.
+	out io.Writer
+	// interactive allows asking the user to pick an environment or a service.
+	interactive bool
+	// deleted looks among the deleted environments instead, for commands that revive one.
+	deleted     bool
+	pickEnv     func(prompt string, envs []types.Environment) (types.Environment, error)
+	pickService func(prompt string, svcs []types.Service) (types.Service, error)
+}
+
+// New returns a resolver that reads the checkout in the working directory
+// and offers a picker when stdin and stderr are terminals, unless --no-input is set.
+func New(c client.Client) *Resolver {
+	return &Resolver{
+		client:      c,
+		detect:      DetectCheckout,
+		out:         os.Stderr,
+		interactive: Interactive(),
+		pickEnv:     tui.PickEnvironment,
+		pickService: tui.PickService,
+	}
+}
+
+// Interactive reports whether the user can be asked to choose, i.e. stdin and stderr
+// are terminals and --no-input is not set.
+func Interactive() bool {
+	return !viper.GetBool("no-input") && isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stderr.Fd())
+}
+
+// EnvironmentID returns the environment id stands for, which is either an ID or an alias.
+// If id is empty, the environment is resolved from the checkout.
+func EnvironmentID(ctx context.Context, c client.Client, id string) (string, error) {
+	return New(c).EnvironmentID(ctx, id)
+}
+
+// WithDeleted makes the resolver look among the deleted environments instead of the others.
+func (r *Resolver) WithDeleted(deleted bool) *Resolver {
+	r.deleted = deleted
+	return r
+}
+
+// EnvironmentID returns the environment id stands for, which is either an ID or an alias.
+// If id is empty, the environment is resolved from the checkout.
+func (r *Resolver) EnvironmentID(ctx context.Context, id string) (string, error) {
+	if id != "" {
+		if aliased, ok := Alias(id); ok {
+			return aliased, nil
+		}
+		return id, nil
+	}
+	env, err := r.Resolve(ctx)
+	if err != nil {
+		return "", err
+	}
+	return env.ID, nil
+}
+
+// Resolve lists the environments of the checkout's repo and branch, and of its PR if known.
+// A single match is used right away. Several matches are offered in a picker,
+// or reported as an error when there is no terminal to ask in.
+// If the checkout matches nothing, the picker offers every environment in the org.
+func (r *Resolver) Resolve(ctx context.Context) (types.Environment, error) {
+	checkout, err := r.detect()
+	if err != nil {
+		if r.interactive {
+			return r.pickFromOrg(ctx)
+		}
+		return types.Environment{}, fmt.Errorf("no environment ID given and it could not be inferred from git: %w", err)
+	}
+
+	filter := client.EnvironmentFilter{
+		RepoName:          checkout.RepoName,
+		Branch:            checkout.Branch,
+		PullRequestNumber: checkout.PullRequestNumber,
+		Deleted:           r.deleted,
+	}
+	envs, err := r.list(ctx, filter)
+	if err != nil {
+		return types.Environment{}, err
+	}
+
+	var env types.Environment
+	switch {
+	case len(envs) == 0 && r.interactive:
+		return r.pickFromOrg(ctx)
+	case len(envs) == 0:
+		if r.deleted {
+			return types.Environment{}, fmt.Errorf("no deleted environment found for %s, pass an environment ID", checkout)
+		}
+		return types.Environment{}, fmt.Errorf("no environment found for %s, pass an environment ID", checkout)
+	case len(envs) == 1:
+		env = envs[0]
+	case !r.interactive:
+		return types.Environment{}, fmt.Errorf("%d environments found for %s: %s; pass an environment ID", len(envs), checkout, describe(envs))
+	default:
+		if env, err = r.pickEnv(fmt.Sprintf("Several environments found for %s, select one:", checkout), envs); err != nil {
+			return types.Environment{}, err
+		}
+	}
+
+	_, _ = fmt.Fprintf(r.out, "Using environment %s (%s) for %s.\n", env.Attributes.Name, env.ID, checkout)
+	return env, nil
+}
+
+// pickFromOrg offers every environment in the org in a picker.
+func (r *Resolver) pickFromOrg(ctx context.Context) (types.Environment, error) {
+	envs, err := r.list(ctx, client.EnvironmentFilter{Deleted: r.deleted})
+	if err != nil {
+		return types.Environment{}, err
+	}
+	if len(envs) == 0 {
+		return types.Environment{}, errors.New("no environments found in the org")
+	}
+	return r.pickEnv("Select an environment:", envs)
+}
+
+func (r *Resolver) list(ctx context.Context, filter client.EnvironmentFilter) ([]types.Environment, error) {
+	var envs []types.Environment
+	for env, err := range r.client.ListEnvironments(ctx, filter) {
+		if err != nil {
+			return nil, err
+		}
+		envs = append(envs, env)
+	}
+	return envs, nil
+}
+
+// Service returns the service called name in an environment.
+// If name is empty, the user picks one of its services.
+func Service(ctx context.Context, c client.Client, envID, name string) (*types.Service, error) {
+	return New(c).Service(ctx, envID, name)
+}
+
+// Service returns the service called name in an environment.
+// If name is empty, the user picks one of its services.
+func (r *Resolver) Service(ctx context.Context, envID, name string) (*types.Service, error) {
+	if name != "" {
+		return r.client.FindService(ctx, name, envID)
+	}
+	if !r.interactive {
+		return nil, errors.New("no service given, pass one with --service")
+	}
+
+	svcs, err := r.client.AllServices(ctx, envID)
+	if err != nil {
+		return nil, err
+	}
+	switch len(svcs) {
+	case 0:
+		return nil, fmt.Errorf("environment %s has no services", envID)
+	case 1:
+		_, _ = fmt.Fprintf(r.out, "Using service %s, the only one in the environment.\n", svcs[0].Name)
+		return &svcs[0], nil
+	}
+	svc, err := r.pickService("Select a service:", svcs)
+	if err != nil {
+		return nil, err
+	}
+	return &svc, nil
+}
+
+// Alias returns the environment ID that name is an alias for.
+// Alias names are case-insensitive.
+func Alias(name string) (string, bool) {
+	id, ok := viper.GetStringMapString("aliases")[strings.ToLower(name)]
+	return id, ok && id != ""
+}
+
+func describe(envs []types.Environment) string {
+	list := make([]string, 0, len(envs))
+	for i := range envs {
+		list = append(list, describeOne(envs[i]))
+	}
+	return strings.Join(list, ", ")
+}
+
+func describeOne(env types.Environment) string {
+	s := fmt.Sprintf("%s (%s", env.Attributes.Name, env.ID)
+	for _, p := range env.Attributes.Projects {
+		if p.PullRequestNumber != 0 {
+			s += fmt.Sprintf(", PR #%d", p.PullRequestNumber)
+			break
+		}
+	}
+	return s + ")"
+}
@@ -3,13 +3,13 @@ package environment
 // TODO: Add interface for testability and mocking
 // TODO: Consolidate Restart/Stop/Cancel/Rebuild/Revive into single executeAction method
 // TODO: Move URI construction to client/repository layer
-// TODO: Add context.Context to all methods for timeout/cancellation
 // TODO: Add proper input validation beyond empty string checks
 // TODO: Replace string-based error parsing with HTTP status code handling
 // TODO: Add caching for GetByID operations
 // TODO: Implement retry logic and circuit breaker patterns
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
@@ -49,7 +49,7 @@ type ListResponse struct {
 }
 
 // List retrieves environments based on the provided filters
-func (s *EnvironmentManager) List(req ListRequest) (*ListResponse, error) {
+func (s *EnvironmentManager) List(ctx context.Context, req ListRequest) (*ListResponse, error) {
 	// Build query parameters
 	params := make(map[string]string)
 
@@ -85,7 +85,7 @@ func (s *EnvironmentManager) List(req ListRequest) (*ListResponse, error) {
 
 	// Make API call
 	apiURI := uri.CreateResourceURI("", "environment", "", "", params)
-	body, err := s.client.Requester.Do(http.MethodGet, apiURI, "application/json", nil)
+	body, err := s.client.Requester.Do(ctx, http.MethodGet, apiURI, "application/json", nil)
 	if err != nil {
 		context := map[string]interface{}{
 			"operation": "list_environments",
@@ -119,12 +119,12 @@ func (s *EnvironmentManager) List(req ListRequest) (*ListResponse, error) {
 }
 
 // GetByID retrieves a single environment by its ID
-func (s *EnvironmentManager) GetByID(id string) (*types.Environment, error) {
+func (s *EnvironmentManager) GetByID(ctx context.Context, id string) (*types.Environment, error) {
 	if id == "" {
 		return nil, fmt.Errorf("environment ID is required")
 	}
 
-	resp, err := s.client.EnvByID(id)
+	resp, err := s.client.EnvByID(ctx, id)
 	if err != nil {
 		context := map[string]interface{}{
 			"operation":      "get_environment",
@@ -137,7 +137,7 @@ func (s *EnvironmentManager) GetByID(id string) (*types.Environment, error) {
 }
 
 // Restart restarts a stopped environment
-func (s *EnvironmentManager) Restart(id string) error {
+func (s *EnvironmentManager) Restart(ctx context.Context, id string) error {
 	if id == "" {
 		return fmt.Errorf("environment ID is required")
 	}
@@ -150,7 +150,7 @@ func (s *EnvironmentManager) Restart(id string) error {
 	}
 
 	restartURI := uri.CreateResourceURI("restart", "environment", id, "", params)
-	_, err := s.client.Requester.Do(http.MethodPost, restartURI, "application/json", nil)
+	_, err := s.client.Requester.Do(ctx, http.MethodPost, restartURI, "application/json", nil)
 	if err != nil {
 		context := map[string]interface{}{
 			"operation":      "restart_environment",
@@ -163,7 +163,7 @@ func (s *EnvironmentManager) Restart(id string) error {
 }
 
 // Stop stops a running environment
-func (s *EnvironmentManager) Stop(id string) error {
+func (s *EnvironmentManager) Stop(ctx context.Context, id string) error {
 	if id == "" {
 		return fmt.Errorf("environment ID is required")
 	}
@@ -176,7 +176,7 @@ func (s *EnvironmentManager) Stop(id string) error {
 	}
 
 	stopURI := uri.CreateResourceURI("stop", "environment", id, "", params)
-	_, err := s.client.Requester.Do(http.MethodPost, stopURI, "application/json", nil)
+	_, err := s.client.Requester.Do(ctx, http.MethodPost, stopURI, "application/json", nil)
 	if err != nil {
 		context := map[string]interface{}{
 			"operation":      "stop_environment",
@@ -189,7 +189,7 @@ func (s *EnvironmentManager) Stop(id string) error {
 }
 
 // Cancel cancels an environment's latest build
-func (s *EnvironmentManager) Cancel(id string) error {
+func (s *EnvironmentManager) Cancel(ctx context.Context, id string) error {
 	if id == "" {
 		return fmt.Errorf("environment ID is required")
 	}
@@ -202,7 +202,7 @@ func (s *EnvironmentManager) Cancel(id string) error {
 	}
 
 	cancelURI := uri.CreateResourceURI("cancel", "environment", id, "", params)
-	_, err := s.client.Requester.Do(http.MethodPost, cancelURI, "application/json", nil)
+	_, err := s.client.Requester.Do(ctx, http.MethodPost, cancelURI, "application/json", nil)
 	if err != nil {
 		context := map[string]interface{}{
 			"operation":      "cancel_environment",
@@ -215,7 +215,7 @@ func (s *EnvironmentManager) Cancel(id string) error {
 }
 
 // Rebuild rebuilds an environment
-func (s *EnvironmentManager) Rebuild(id string) error {
+func (s *EnvironmentManager) Rebuild(ctx context.Context, id string) error {
 	if id == "" {
 		return fmt.Errorf("environment ID is required")
 	}
@@ -228,7 +228,7 @@ func (s *EnvironmentManager) Rebuild(id string) error {
 	}
 
 	rebuildURI := uri.CreateResourceURI("rebuild", "environment", id, "", params)
-	_, err := s.client.Requester.Do(http.MethodPost, rebuildURI, "application/json", nil)
+	_, err := s.client.Requester.Do(ctx, http.MethodPost, rebuildURI, "application/json", nil)
 	if err != nil {
 		context := map[string]interface{}{
 			"operation":      "rebuild_environment",
@@ -241,7 +241,7 @@ func (s *EnvironmentManager) Rebuild(id string) error {
 }
 
 // Revive revives a deleted environment
-func (s *EnvironmentManager) Revive(id string) error {
+func (s *EnvironmentManager) Revive(ctx context.Context, id string) error {
 	if id == "" {
 		return fmt.Errorf("environment ID is required")
 	}
@@ -254,7 +254,7 @@ func (s *EnvironmentManager) Revive(id string) error {
 	}
 
 	reviveURI := uri.CreateResourceURI("revive", "environment", id, "", params)
-	_, err := s.client.Requester.Do(http.MethodPost, reviveURI, "application/json", nil)
+	_, err := s.client.Requester.Do(ctx, http.MethodPost, reviveURI, "application/json", nil)
 	if err != nil {
 		context := map[string]interface{}{
 			"operation":      "revive_environment",
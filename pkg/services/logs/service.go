@@ -68,13 +68,13 @@ func (s *LogsManager) GetLogs(ctx context.Context, req GetLogsRequest) (*LogsRes
 	}
 
 	// Find the service
-	svc, err := s.client.FindService(req.ServiceName, req.EnvironmentID)
+	svc, err := s.client.FindService(ctx, req.ServiceName, req.EnvironmentID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find service %s: %w", req.ServiceName, err)
 	}
 
 	// Create k8s service for log access
-	k8sService, err := k8s.New(s.client, req.EnvironmentID, svc)
+	k8sService, err := k8s.New(ctx, s.client, req.EnvironmentID, svc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create k8s connection: %w", err)
 	}
@@ -102,7 +102,7 @@ func (s *LogsManager) GetLogs(ctx context.Context, req GetLogsRequest) (*LogsRes
 // getLogsFromK8s retrieves logs from kubernetes and returns them as LogLine slice
 func (s *LogsManager) getLogsFromK8s(ctx context.Context, k8sService *k8s.Service, follow bool, tailLines int64, serviceName string) ([]LogLine, error) {
 	// Get raw logs by calling the k8s service directly and capturing output
-	logText, err := s.getRawLogsFromK8sService(k8sService, tailLines)
+	logText, err := s.getRawLogsFromK8sService(ctx, k8sService, tailLines)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get raw logs: %w", err)
 	}
@@ -112,7 +112,7 @@ func (s *LogsManager) getLogsFromK8s(ctx context.Context, k8sService *k8s.Servic
 }
 
 // getRawLogsFromK8sService gets raw log text from the k8s service
-func (s *LogsManager) getRawLogsFromK8sService(k8sService *k8s.Service, tailLines int64) (string, error) {
+func (s *LogsManager) getRawLogsFromK8sService(ctx context.Context, k8sService *k8s.Service, tailLines int64) (string, error) {
 	// We need to replicate the k8s.Service.Logs functionality but capture the output
 	// Since we can't easily modify the existing k8s package, we'll create our own k8s client
 
@@ -121,16 +121,16 @@ func (s *LogsManager) getRawLogsFromK8sService(k8sService *k8s.Service, tailLine
 
 	// Since k8s.Service.Logs prints to stdout, we can't easily capture it
 	// We need to implement our own k8s logs fetching
-	return s.getLogsDirectlyFromK8sAPI(k8sService, tailLines)
+	return s.getLogsDirectlyFromK8sAPI(ctx, k8sService, tailLines)
 }
 
 // getLogsDirectlyFromK8sAPI directly calls the k8s API to get logs
-func (s *LogsManager) getLogsDirectlyFromK8sAPI(k8sService *k8s.Service, tailLines int64) (string, error) {
+func (s *LogsManager) getLogsDirectlyFromK8sAPI(ctx context.Context, k8sService *k8s.Service, tailLines int64) (string, error) {
 	if k8sService == nil {
 		return "", fmt.Errorf("k8s service is nil")
 	}
 	// Use the new GetLogsAsString method we added to k8s.Service
-	return k8sService.GetLogsAsString(false, tailLines)
+	return k8sService.GetLogsAsString(ctx, false, tailLines)
 }
 
 func (s *LogsManager) parseLogText(logText string) []LogLine {
@@ -1,9 +1,11 @@
 package org
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
+	"github.com/shipyard/shipyard-cli/config"
 	"github.com/shipyard/shipyard-cli/pkg/client"
 	"github.com/shipyard/shipyard-cli/pkg/requests/uri"
 	"github.com/shipyard/shipyard-cli/pkg/types"
@@ -21,8 +23,8 @@ func NewOrganizationManager(client client.Client) *OrganizationManager {
 }
 
 // List retrieves all organizations for the user
-func (s *OrganizationManager) List() ([]string, error) {
-	body, err := s.client.Requester.Do(http.MethodGet, uri.CreateResourceURI("", "org", "", "", nil), "application/json", nil)
+func (s *OrganizationManager) List(ctx context.Context) ([]string, error) {
+	body, err := s.client.Requester.Do(ctx, http.MethodGet, uri.CreateResourceURI("", "org", "", "", nil), "application/json", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get organizations: %w", err)
 	}
@@ -55,12 +57,7 @@ func (s *OrganizationManager) SetCurrent(name string) error {
 		return fmt.Errorf("organization name cannot be empty")
 	}
 
-	viper.Set("org", name)
-	if err := viper.MergeInConfig(); err != nil {
-		return fmt.Errorf("failed to merge config: %w", err)
-	}
-
-	if err := viper.WriteConfig(); err != nil {
+	if err := config.SaveOrg(name); err != nil {
 		return fmt.Errorf("failed to write config: %w", err)
 	}
 
@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/pkg/browser"
+
+	"github.com/shipyard/shipyard-cli/pkg/client"
+	"github.com/shipyard/shipyard-cli/pkg/k8s"
+	"github.com/shipyard/shipyard-cli/pkg/services/environment"
+	"github.com/shipyard/shipyard-cli/pkg/types"
+)
+
+// Backend is everything the dashboard does outside of the terminal.
+type Backend interface {
+	Environments(ctx context.Context) ([]types.Environment, error)
+	Services(ctx context.Context, envID string) ([]types.Service, error)
+	// Logs returns the last lines of the logs of a service.
+	Logs(ctx context.Context, envID string, svc types.Service, lines int64) (string, error)
+	// Action runs stop, restart or rebuild on an environment.
+	Action(ctx context.Context, verb, envID string) error
+	Visit(url string) error
+	// Exec runs an interactive shell in a service. It owns the terminal until the shell exits.
+	Exec(envID string, svc types.Service) error
+	// PortForward forwards the ports of a service until ctx is done.
+	PortForward(ctx context.Context, envID string, svc types.Service, ready func()) error
+}
+
+// NewBackend returns a backend that talks to the Shipyard API and to the clusters of the environments.
+// The dashboard lists the environments that match filter.
+func NewBackend(c client.Client, filter client.EnvironmentFilter) Backend {
+	return &clientBackend{
+		client:  c,
+		filter:  filter,
+		manager: environment.NewEnvironmentManager(c),
+		pods:    make(map[string]*k8s.Service),
+	}
+}
+
+type clientBackend struct {
+	client  client.Client
+	filter  client.EnvironmentFilter
+	manager *environment.EnvironmentManager
+
+	// pods caches the connection to the pod of each service, since logs are fetched on every poll.
+	mu   sync.Mutex
+	pods map[string]*k8s.Service
+}
+
+func (b *clientBackend) Environments(ctx context.Context) ([]types.Environment, error) {
+	var envs []types.Environment
+	for env, err := range b.client.ListEnvironments(ctx, b.filter) {
+		if err != nil {
+			return nil, err
+		}
+		envs = append(envs, env)
+	}
+	return envs, nil
+}
+
+func (b *clientBackend) Services(ctx context.Context, envID string) ([]types.Service, error) {
+	return b.client.AllServices(ctx, envID)
+}
+
+func (b *clientBackend) Logs(ctx context.Context, envID string, svc types.Service, lines int64) (string, error) {
+	pod, err := b.pod(ctx, envID, svc)
+	if err != nil {
+		return "", err
+	}
+	logs, err := pod.GetLogsAsString(ctx, false, lines)
+	if err != nil {
+		// The pod may have been replaced, look it up again next time.
+		b.forget(envID, svc)
+	}
+	return logs, err
+}
+
+func (b *clientBackend) Action(ctx context.Context, verb, envID string) error {
+	switch verb {
+	case "stop":
+		return b.manager.Stop(ctx, envID)
+	case "restart":
+		return b.manager.Restart(ctx, envID)
+	case "rebuild":
+		return b.manager.Rebuild(ctx, envID)
+	default:
+		return fmt.Errorf("unknown action %q", verb)
+	}
+}
+
+func (b *clientBackend) Visit(url string) error {
+	// The browser launcher would write over the dashboard.
+	browser.Stdout, browser.Stderr = io.Discard, io.Discard
+	return browser.OpenURL(url)
+}
+
+func (b *clientBackend) Exec(envID string, svc types.Service) error {
+	pod, err := b.pod(context.Background(), envID, svc)
+	if err != nil {
+		return err
+	}
+	return pod.Exec([]string{"sh"})
+}
+
+func (b *clientBackend) PortForward(ctx context.Context, envID string, svc types.Service, ready func()) error {
+	pod, err := b.pod(ctx, envID, svc)
+	if err != nil {
+		return err
+	}
+	return pod.PortForwardContext(ctx, svc.Ports, ready)
+}
+
+func (b *clientBackend) pod(ctx context.Context, envID string, svc types.Service) (*k8s.Service, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := envID + "/" + svc.Name
+	if pod, ok := b.pods[key]; ok {
+		return pod, nil
+	}
+	pod, err := k8s.New(ctx, b.client, envID, &svc)
+	if err != nil {
+		return nil, err
+	}
+	b.pods[key] = pod
+	return pod, nil
+}
+
+func (b *clientBackend) forget(envID string, svc types.Service) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.pods, envID+"/"+svc.Name)
+}
@@ -0,0 +1,88 @@
+package tui
+
+import "unicode/utf8"
+
+// Key is a key press: a printable character such as "s", or a named key such as "up" or "ctrl+c".
+type Key string
+
+const (
+	KeyUp        Key = "up"
+	KeyDown      Key = "down"
+	KeyLeft      Key = "left"
+	KeyRight     Key = "right"
+	KeyPageUp    Key = "pgup"
+	KeyPageDown  Key = "pgdown"
+	KeyEnter     Key = "enter"
+	KeyEscape    Key = "esc"
+	KeyBackspace Key = "backspace"
+	KeyTab       Key = "tab"
+	KeyCtrlC     Key = "ctrl+c"
+	KeyCtrlR     Key = "ctrl+r"
+)
+
+// escapeKeys maps the sequences terminals send for the arrow and paging keys.
+var escapeKeys = map[string]Key{
+	"\x1b[A":  KeyUp,
+	"\x1b[B":  KeyDown,
+	"\x1b[C":  KeyRight,
+	"\x1b[D":  KeyLeft,
+	"\x1bOA":  KeyUp,
+	"\x1bOB":  KeyDown,
+	"\x1bOC":  KeyRight,
+	"\x1bOD":  KeyLeft,
+	"\x1b[5~": KeyPageUp,
+	"\x1b[6~": KeyPageDown,
+}
+
+// ParseKeys splits what was read from a terminal in raw mode into key presses.
+// Unknown escape sequences are dropped.
+func ParseKeys(b []byte) []Key {
+	var keys []Key
+	for len(b) > 0 {
+		switch b[0] {
+		case '\r', '\n':
+			keys = append(keys, KeyEnter)
+		case '\t':
+			keys = append(keys, KeyTab)
+		case 0x7f, 0x08:
+			keys = append(keys, KeyBackspace)
+		case 0x03:
+			keys = append(keys, KeyCtrlC)
+		case 0x12:
+			keys = append(keys, KeyCtrlR)
+		case 0x1b:
+			n := escapeLength(b)
+			if n == 1 {
+				keys = append(keys, KeyEscape)
+			} else if k, ok := escapeKeys[string(b[:n])]; ok {
+				keys = append(keys, k)
+			}
+			b = b[n:]
+			continue
+		default:
+			r, size := utf8.DecodeRune(b)
+			if r >= ' ' && r != utf8.RuneError {
+				keys = append(keys, Key(string(r)))
+			}
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// escapeLength returns the length of the escape sequence at the start of b,
+// which is 1 for a lone escape key press.
+func escapeLength(b []byte) int {
+	if len(b) < 2 || (b[1] != '[' && b[1] != 'O') {
+		return 1
+	}
+	// CSI and SS3 sequences end with a byte in the range @ to ~.
+	for i := 2; i < len(b); i++ {
+		if b[i] >= '@' && b[i] <= '~' {
+			return i + 1
+		}
+	}
+	return len(b)
+}
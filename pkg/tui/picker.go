@@ -0,0 +1,251 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/term"
+
+	"github.com/shipyard/shipyard-cli/pkg/display"
+	"github.com/shipyard/shipyard-cli/pkg/types"
+)
+
+// ErrPickCanceled is returned by Pick when the user leaves the picker without choosing.
+var ErrPickCanceled = errors.New("selection canceled")
+
+// pickerHeight is the number of rows the picker shows at once.
+const pickerHeight = 10
+
+// Picker narrows a list down to the rows that fuzzily match what the user types,
+// so that one of them can be chosen.
+type Picker struct {
+	prompt string
+	header []string
+	rows   [][]string
+	query  string
+	// matches are the indexes of the rows that match the query, best first.
+	matches []int
+	cursor  int
+	chosen  int
+	done    bool
+	width   int
+}
+
+// NewPicker returns a picker of rows, shown as a table under header.
+func NewPicker(prompt string, header []string, rows [][]string) *Picker {
+	p := &Picker{prompt: prompt, header: header, rows: rows, chosen: -1, width: 120}
+	p.match()
+	return p
+}
+
+// Update handles a key press. It reports whether the picker is done, either with a choice or canceled.
+func (p *Picker) Update(k Key) bool {
+	switch k {
+	case KeyEnter:
+		if len(p.matches) > 0 {
+			p.chosen = p.matches[p.cursor]
+			p.done = true
+		}
+	case KeyEscape, KeyCtrlC:
+		p.done = true
+	case KeyUp, KeyDown, KeyPageUp, KeyPageDown:
+		p.cursor = move(p.cursor, len(p.matches), k)
+	case KeyBackspace:
+		if r := []rune(p.query); len(r) > 0 {
+			p.query = string(r[:len(r)-1])
+			p.match()
+		}
+	default:
+		if len([]rune(string(k))) == 1 {
+			p.query += string(k)
+			p.match()
+		}
+	}
+	return p.done
+}
+
+// Chosen returns the index of the chosen row, or false if there is none yet or the picker was canceled.
+func (p *Picker) Chosen() (int, bool) {
+	return p.chosen, p.chosen >= 0
+}
+
+// View draws the prompt, the header and the matching rows around the cursor.
+func (p *Picker) View() []string {
+	rows := make([][]string, len(p.matches))
+	for i, m := range p.matches {
+		rows[i] = p.rows[m]
+	}
+	header, lines := table(p.header, rows)
+
+	out := []string{
+		bold + p.prompt + reset + " " + p.query + "█  " +
+			dim + fmt.Sprintf("%d/%d  ↑/↓ move  enter select  esc cancel", len(p.matches), len(p.rows)) + reset,
+		"  " + header,
+	}
+	start, end := window(p.cursor, len(lines), pickerHeight)
+	for i := start; i < end; i++ {
+		if i == p.cursor {
+			out = append(out, reverse+"> "+display.StripEscapes(lines[i])+reset)
+		} else {
+			out = append(out, "  "+lines[i])
+		}
+	}
+	if len(lines) == 0 {
+		out = append(out, "  No matches.")
+	}
+	for i := range out {
+		out[i] = truncate(out[i], p.width)
+	}
+	return out
+}
+
+func (p *Picker) match() {
+	type scored struct{ index, score int }
+	var found []scored
+	for i, row := range p.rows {
+		if score, ok := fuzzyScore(p.query, display.StripEscapes(strings.Join(row, " "))); ok {
+			found = append(found, scored{i, score})
+		}
+	}
+	slices.SortStableFunc(found, func(a, b scored) int { return b.score - a.score })
+
+	p.matches = p.matches[:0]
+	for _, f := range found {
+		p.matches = append(p.matches, f.index)
+	}
+	p.cursor = 0
+}
+
+// fuzzyScore reports whether the characters of query appear in text in order, ignoring case.
+// Matches get a higher score when their characters are adjacent or start a word.
+func fuzzyScore(query, text string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(text))
+	score, qi, last := 0, 0, -2
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if unicode.IsSpace(q[qi]) {
+			qi++
+			continue
+		}
+		if t[ti] != q[qi] {
+			continue
+		}
+		score++
+		if ti == last+1 {
+			score += 2
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score++
+		}
+		last = ti
+		qi++
+	}
+	for qi < len(q) && unicode.IsSpace(q[qi]) {
+		qi++
+	}
+	return score, qi == len(q)
+}
+
+// Pick shows a picker of rows below the cursor in the terminal and returns the index of the chosen row.
+// It returns ErrPickCanceled if the user presses escape.
+func Pick(prompt string, header []string, rows [][]string) (int, error) {
+	in, closeIn := openTTY()
+	defer closeIn()
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = term.Restore(int(in.Fd()), state) }()
+
+	out := os.Stderr
+	p := NewPicker(prompt, header, rows)
+	if w, _, err := term.GetSize(int(out.Fd())); err == nil {
+		p.width = w
+	}
+
+	drawn := 0
+	draw := func(lines []string) {
+		var b strings.Builder
+		// Go back to the first line of the previous frame.
+		if drawn > 1 {
+			fmt.Fprintf(&b, "\x1b[%dA", drawn-1)
+		}
+		b.WriteString("\r")
+		for i, line := range lines {
+			b.WriteString(line)
+			b.WriteString(clearLine)
+			if i < len(lines)-1 {
+				b.WriteString("\r\n")
+			}
+		}
+		b.WriteString("\x1b[J")
+		_, _ = io.WriteString(out, b.String())
+		drawn = len(lines)
+	}
+
+	buf := make([]byte, 256)
+	for {
+		draw(p.View())
+		n, err := in.Read(buf)
+		if err != nil {
+			draw([]string{""})
+			return 0, err
+		}
+		for _, k := range ParseKeys(buf[:n]) {
+			if !p.Update(k) {
+				continue
+			}
+			draw([]string{""})
+			if i, ok := p.Chosen(); ok {
+				return i, nil
+			}
+			return 0, ErrPickCanceled
+		}
+	}
+}
+
+// PickEnvironment lets the user choose one of envs by their app name, repo, branch or PR and state.
+func PickEnvironment(prompt string, envs []types.Environment) (types.Environment, error) {
+	rows := make([][]string, 0, len(envs))
+	for _, env := range envs {
+		var repos, refs []string
+		for _, p := range env.Attributes.Projects {
+			repos = append(repos, p.RepoName)
+			ref := p.Branch
+			if p.PullRequestNumber != 0 {
+				ref = fmt.Sprintf("#%d %s", p.PullRequestNumber, p.Branch)
+			}
+			refs = append(refs, ref)
+		}
+		rows = append(rows, []string{
+			display.FormatColoredAppName(env.Attributes.Name),
+			env.ID,
+			strings.Join(repos, ","),
+			strings.Join(refs, ","),
+			formatState(env),
+		})
+	}
+	i, err := Pick(prompt, []string{"App", "UUID", "Repo", "Branch/PR", "Ready"}, rows)
+	if err != nil {
+		return types.Environment{}, err
+	}
+	return envs[i], nil
+}
+
+// PickService lets the user choose one of svcs by their name, ports and URL.
+func PickService(prompt string, svcs []types.Service) (types.Service, error) {
+	rows := make([][]string, 0, len(svcs))
+	for _, svc := range svcs {
+		rows = append(rows, []string{display.FormatColoredAppName(svc.Name), strings.Join(svc.Ports, ","), svc.URL})
+	}
+	i, err := Pick(prompt, []string{"Service", "Ports", "URL"}, rows)
+	if err != nil {
+		return types.Service{}, err
+	}
+	return svcs[i], nil
+}
@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shipyard/shipyard-cli/pkg/display"
+)
+
+func TestPicker(t *testing.T) {
+	rows := [][]string{
+		{"checkout", "fleet-1", "shop", "main"},
+		{"checkout", "fleet-2", "shop", "#7 feature"},
+		{"search", "fleet-3", "shop", "main"},
+	}
+
+	p := NewPicker("Select an environment:", []string{"App", "UUID", "Repo", "Branch/PR"}, rows)
+	for _, k := range []Key{"f", "e", "a", "t"} {
+		if p.Update(k) {
+			t.Fatalf("expected the picker to stay open after %q", k)
+		}
+	}
+	screen := display.StripEscapes(strings.Join(p.View(), "\n"))
+	if !strings.Contains(screen, "fleet-2") || strings.Contains(screen, "fleet-1") || strings.Contains(screen, "fleet-3") {
+		t.Errorf("expected only fleet-2 to match \"feat\":\n%s", screen)
+	}
+
+	for range 4 {
+		p.Update(KeyBackspace)
+	}
+	p.Update(KeyDown)
+	if !p.Update(KeyEnter) {
+		t.Fatal("expected enter to choose")
+	}
+	if i, ok := p.Chosen(); !ok || i != 1 {
+		t.Errorf("expected the second checkout to be chosen, but got %d, %t", i, ok)
+	}
+
+	p = NewPicker("Select an environment:", []string{"App"}, rows)
+	if !p.Update(KeyEscape) {
+		t.Fatal("expected escape to cancel")
+	}
+	if _, ok := p.Chosen(); ok {
+		t.Error("expected nothing to be chosen after escape")
+	}
+
+	p = NewPicker("Select an environment:", []string{"App"}, rows)
+	p.Update("z")
+	if p.Update(KeyEnter) {
+		t.Error("expected enter to do nothing without matches")
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := fuzzyScore("srch", "search fleet-3"); !ok {
+		t.Error("expected srch to match search")
+	}
+	if _, ok := fuzzyScore("hcs", "search"); ok {
+		t.Error("expected the order of the characters to matter")
+	}
+	adjacent, _ := fuzzyScore("main", "checkout main")
+	scattered, _ := fuzzyScore("main", "my api info n")
+	if adjacent <= scattered {
+		t.Errorf("expected adjacent characters to score higher, but got %d and %d", adjacent, scattered)
+	}
+}
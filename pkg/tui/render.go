@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/shipyard/shipyard-cli/pkg/display"
+)
+
+const (
+	reverse = "\x1b[7m"
+	bold    = "\x1b[1m"
+	dim     = "\x1b[2m"
+	reset   = "\x1b[0m"
+)
+
+// width returns the number of cells s takes up on screen.
+func width(s string) int {
+	return utf8.RuneCountInString(display.StripEscapes(s))
+}
+
+// truncate cuts s down to n visible cells, keeping its escape sequences intact.
+func truncate(s string, n int) string {
+	if width(s) <= n {
+		return s
+	}
+	var b strings.Builder
+	visible := 0
+	for len(s) > 0 && visible < n {
+		if loc := display.EscapeSequence.FindStringIndex(s); loc != nil && loc[0] == 0 {
+			b.WriteString(s[:loc[1]])
+			s = s[loc[1]:]
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s)
+		b.WriteString(s[:size])
+		s = s[size:]
+		visible++
+	}
+	b.WriteString(reset)
+	return b.String()
+}
+
+// pad fills s with spaces up to n visible cells.
+func pad(s string, n int) string {
+	if w := width(s); w < n {
+		return s + strings.Repeat(" ", n-w)
+	}
+	return s
+}
+
+// table lays out rows in columns separated by two spaces. The header is bold.
+// Cells may be colored, the widths only count what is visible.
+func table(header []string, rows [][]string) (string, []string) {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = width(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) && width(cell) > widths[i] {
+				widths[i] = width(cell)
+			}
+		}
+	}
+
+	line := func(cells []string) string {
+		parts := make([]string, len(widths))
+		for i := range widths {
+			if i < len(cells) {
+				parts[i] = pad(cells[i], widths[i])
+			} else {
+				parts[i] = pad("", widths[i])
+			}
+		}
+		return strings.TrimRight(strings.Join(parts, "  "), " ")
+	}
+
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		lines = append(lines, line(row))
+	}
+	return bold + line(header) + reset, lines
+}
@@ -0,0 +1,210 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l"
+	leaveAltScreen = "\x1b[?25h\x1b[?1049l"
+	clearLine      = "\x1b[K"
+)
+
+// Run shows the dashboard in the terminal until the user quits or ctx is done.
+// The environments are polled every interval.
+func Run(ctx context.Context, app *App, interval time.Duration) error {
+	in, closeIn := openTTY()
+	defer closeIn()
+
+	p := &program{
+		app:    app,
+		in:     in,
+		out:    os.Stdout,
+		msgs:   make(chan Msg, 64),
+		pause:  make(chan chan struct{}),
+		resume: make(chan struct{}),
+		reader: make(chan struct{}),
+		// Without read deadlines, a pending read cannot be paused.
+		pausable: in.SetReadDeadline(time.Time{}) == nil,
+	}
+	return p.run(ctx, interval)
+}
+
+type program struct {
+	app *App
+	in  *os.File
+	out io.Writer
+	// msgs carries key presses and the results of commands to the loop in run.
+	msgs chan Msg
+	// pause stops the key reader while another program uses the terminal, until resume.
+	pause  chan chan struct{}
+	resume chan struct{}
+	// reader is closed when the key reader stops.
+	reader   chan struct{}
+	pausable bool
+	state    *term.State
+}
+
+func (p *program) run(ctx context.Context, interval time.Duration) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer p.leave()
+
+	go p.readKeys(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	p.resize()
+	p.dispatch(ctx, p.app.Init())
+	for !p.app.Quitting() {
+		p.render()
+
+		var msg Msg
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			p.resize()
+			msg = TickMsg{}
+		case msg = <-p.msgs:
+		}
+
+		if s, ok := msg.(suspendMsg); ok {
+			msg = s.done(p.suspend(s.run))
+		}
+		p.dispatch(ctx, p.app.Update(msg))
+	}
+	return nil
+}
+
+// dispatch runs the commands in the background. Their results come back through msgs.
+func (p *program) dispatch(ctx context.Context, cmds []Cmd) {
+	for _, cmd := range cmds {
+		go func() {
+			msg := cmd(ctx)
+			select {
+			case p.msgs <- msg:
+			case <-ctx.Done():
+			}
+		}()
+	}
+}
+
+func (p *program) render() {
+	lines := p.app.View()
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i, line := range lines {
+		b.WriteString(line)
+		b.WriteString(clearLine)
+		if i < len(lines)-1 {
+			b.WriteString("\r\n")
+		}
+	}
+	b.WriteString("\x1b[J")
+	_, _ = io.WriteString(p.out, b.String())
+}
+
+func (p *program) resize() {
+	if w, h, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		p.app.Update(ResizeMsg{Width: w, Height: h})
+	}
+}
+
+// enter switches to the alternate screen and reads keys as they are pressed.
+func (p *program) enter() error {
+	state, err := term.MakeRaw(int(p.in.Fd()))
+	if err != nil {
+		return err
+	}
+	p.state = state
+	_, _ = io.WriteString(p.out, enterAltScreen)
+	return nil
+}
+
+func (p *program) leave() {
+	_, _ = io.WriteString(p.out, leaveAltScreen)
+	if p.state != nil {
+		_ = term.Restore(int(p.in.Fd()), p.state)
+	}
+}
+
+// suspend gives the terminal back to its normal state while run, e.g. a shell, uses it.
+func (p *program) suspend(run func() error) error {
+	if p.pausable {
+		ack := make(chan struct{})
+		select {
+		case p.pause <- ack:
+			<-ack
+			defer func() {
+				select {
+				case p.resume <- struct{}{}:
+				case <-p.reader:
+				}
+			}()
+		case <-p.reader:
+		}
+	}
+
+	p.leave()
+	err := run()
+	if enterErr := p.enter(); enterErr != nil && err == nil {
+		err = enterErr
+	}
+	p.resize()
+	return err
+}
+
+func (p *program) readKeys(ctx context.Context) {
+	defer close(p.reader)
+	buf := make([]byte, 256)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ack := <-p.pause:
+			close(ack)
+			select {
+			case <-p.resume:
+			case <-ctx.Done():
+				return
+			}
+		default:
+		}
+
+		// The deadline lets the loop notice a pause without keeping a read pending,
+		// which would take the next key press away from the program that suspended us.
+		_ = p.in.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		n, err := p.in.Read(buf)
+		for _, k := range ParseKeys(buf[:n]) {
+			select {
+			case p.msgs <- k:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+			return
+		}
+	}
+}
+
+// openTTY opens the controlling terminal, which supports read deadlines unlike stdin.
+// Where there is none to open, stdin is used.
+func openTTY() (*os.File, func()) {
+	if f, err := os.Open("/dev/tty"); err == nil {
+		return f, func() { _ = f.Close() }
+	}
+	return os.Stdin, func() {}
+}
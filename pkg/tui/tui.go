@@ -0,0 +1,726 @@
+// Package tui is the full-screen dashboard started by 'shipyard ui'.
+//
+// The App holds the state of the dashboard. It changes only in Update, in response to
+// key presses, timer ticks and the results of the commands it asked for, and View draws it.
+// Run connects an App to a terminal, while tests drive it directly.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/shipyard/shipyard-cli/pkg/display"
+	"github.com/shipyard/shipyard-cli/pkg/types"
+)
+
+// logLines is how much of a service's logs the log pane fetches.
+const logLines = 200
+
+// Msg is anything the App reacts to: a Key, a TickMsg, a ResizeMsg or the result of a Cmd.
+type Msg any
+
+// Cmd does the slow work for the App, away from the screen, and reports back with a Msg.
+type Cmd func(ctx context.Context) Msg
+
+// TickMsg asks the App to poll for changes.
+type TickMsg struct{}
+
+// ResizeMsg reports the size of the terminal.
+type ResizeMsg struct {
+	Width, Height int
+}
+
+type environmentsMsg struct {
+	envs []types.Environment
+	err  error
+}
+
+type servicesMsg struct {
+	envID    string
+	services []types.Service
+	err      error
+}
+
+type logsMsg struct {
+	envID, service string
+	logs           string
+	err            error
+}
+
+type actionMsg struct {
+	verb string
+	env  types.Environment
+	err  error
+}
+
+type statusMsg struct {
+	text string
+	err  error
+}
+
+// forwardReadyMsg reports that a port forward accepts connections. wait reports when it ends.
+type forwardReadyMsg struct {
+	key  string
+	wait Cmd
+}
+
+type forwardEndedMsg struct {
+	key string
+	err error
+}
+
+// suspendMsg asks Run to hand the terminal over to run, e.g. for a shell, and to pass the outcome to done.
+type suspendMsg struct {
+	run  func() error
+	done func(error) Msg
+}
+
+type screen int
+
+const (
+	screenEnvironments screen = iota
+	screenServices
+)
+
+type confirmation struct {
+	verb string
+	env  types.Environment
+}
+
+type forward struct {
+	service types.Service
+	cancel  context.CancelFunc
+	ready   bool
+}
+
+// App is the state of the dashboard.
+type App struct {
+	backend Backend
+	title   string
+	now     func() time.Time
+	width   int
+	height  int
+
+	screen  screen
+	envs    []types.Environment
+	loaded  bool
+	updated time.Time
+	// changed holds the environments whose state changed in the last poll.
+	changed   map[string]bool
+	filter    string
+	filtering bool
+	cursor    int
+
+	env        types.Environment
+	services   []types.Service
+	svcCursor  int
+	logService string
+	logs       []string
+
+	forwards map[string]*forward
+	confirm  *confirmation
+	status   string
+	failed   bool
+	quit     bool
+}
+
+// New returns a dashboard of the environments the backend lists. The title describes them, e.g. the org.
+func New(backend Backend, title string) *App {
+	return &App{
+		backend:  backend,
+		title:    title,
+		now:      time.Now,
+		width:    120,
+		height:   40,
+		changed:  make(map[string]bool),
+		forwards: make(map[string]*forward),
+	}
+}
+
+// Init returns the commands that load the first screen.
+func (a *App) Init() []Cmd {
+	return []Cmd{a.loadEnvironments()}
+}
+
+// Quitting reports whether the user asked to leave.
+func (a *App) Quitting() bool {
+	return a.quit
+}
+
+// Update changes the state in response to msg and returns the commands to run next.
+func (a *App) Update(msg Msg) []Cmd {
+	switch m := msg.(type) {
+	case Key:
+		return a.handleKey(m)
+	case ResizeMsg:
+		a.width, a.height = m.Width, m.Height
+	case TickMsg:
+		return a.refresh()
+	case environmentsMsg:
+		a.setEnvironments(m)
+	case servicesMsg:
+		if m.envID != a.env.ID {
+			return nil
+		}
+		if m.err != nil {
+			a.setError(fmt.Errorf("failed to get services: %w", m.err))
+			return nil
+		}
+		a.services = m.services
+		a.svcCursor = clamp(a.svcCursor, len(a.services))
+	case logsMsg:
+		if m.envID != a.env.ID || m.service != a.logService {
+			return nil
+		}
+		if m.err != nil {
+			a.setError(fmt.Errorf("failed to get the logs of %s: %w", m.service, m.err))
+			return nil
+		}
+		a.logs = strings.Split(strings.TrimRight(m.logs, "\n"), "\n")
+	case actionMsg:
+		if m.err != nil {
+			a.setError(fmt.Errorf("failed to %s %s: %w", m.verb, describe(m.env), m.err))
+			return nil
+		}
+		a.setStatus(fmt.Sprintf("%s: %s accepted.", describe(m.env), m.verb))
+		return []Cmd{a.loadEnvironments()}
+	case statusMsg:
+		if m.err != nil {
+			a.setError(m.err)
+		} else {
+			a.setStatus(m.text)
+		}
+	case forwardReadyMsg:
+		f, ok := a.forwards[m.key]
+		if !ok {
+			return nil
+		}
+		f.ready = true
+		a.setStatus(fmt.Sprintf("Forwarding localhost:%s to %s.", strings.Join(f.service.Ports, ", localhost:"), f.service.Name))
+		return []Cmd{m.wait}
+	case forwardEndedMsg:
+		f, ok := a.forwards[m.key]
+		if !ok {
+			return nil
+		}
+		delete(a.forwards, m.key)
+		if m.err != nil {
+			a.setError(fmt.Errorf("port forward to %s failed: %w", f.service.Name, m.err))
+		} else {
+			a.setStatus(fmt.Sprintf("Stopped forwarding to %s.", f.service.Name))
+		}
+	}
+	return nil
+}
+
+func (a *App) handleKey(k Key) []Cmd {
+	if a.confirm != nil {
+		c := a.confirm
+		a.confirm = nil
+		if k != "y" && k != "Y" {
+			a.setStatus("Canceled.")
+			return nil
+		}
+		a.setStatus(fmt.Sprintf("%s: %s requested...", describe(c.env), c.verb))
+		return []Cmd{a.runAction(c.verb, c.env)}
+	}
+
+	if a.filtering {
+		switch k {
+		case KeyEnter:
+			a.filtering = false
+		case KeyEscape:
+			a.filtering, a.filter = false, ""
+		case KeyBackspace:
+			if r := []rune(a.filter); len(r) > 0 {
+				a.filter = string(r[:len(r)-1])
+			}
+		default:
+			if len([]rune(string(k))) == 1 {
+				a.filter += string(k)
+			}
+		}
+		a.cursor = 0
+		return nil
+	}
+
+	switch k {
+	case "q", KeyCtrlC:
+		a.quit = true
+		for _, f := range a.forwards {
+			f.cancel()
+		}
+		return nil
+	case KeyCtrlR:
+		return a.refresh()
+	}
+
+	if a.screen == screenServices {
+		return a.handleServicesKey(k)
+	}
+	return a.handleEnvironmentsKey(k)
+}
+
+func (a *App) handleEnvironmentsKey(k Key) []Cmd {
+	envs := a.visibleEnvironments()
+	a.cursor = move(a.cursor, len(envs), k)
+
+	switch k {
+	case "/":
+		a.filtering = true
+	case KeyEscape:
+		a.filter = ""
+	}
+	if len(envs) == 0 {
+		return nil
+	}
+
+	env := envs[a.cursor]
+	switch k {
+	case KeyEnter, KeyRight:
+		a.screen = screenServices
+		a.env = env
+		a.services = env.Attributes.Services
+		a.svcCursor = 0
+		a.logService, a.logs = "", nil
+		return []Cmd{a.loadServices()}
+	case "s", "r", "b", "v":
+		return a.environmentAction(k, env)
+	}
+	return nil
+}
+
+func (a *App) handleServicesKey(k Key) []Cmd {
+	a.svcCursor = move(a.svcCursor, len(a.services), k)
+
+	switch k {
+	case KeyEscape, KeyLeft, KeyBackspace:
+		a.screen = screenEnvironments
+		a.logService, a.logs = "", nil
+		return nil
+	case "s", "r", "b", "v":
+		return a.environmentAction(k, a.env)
+	}
+	if len(a.services) == 0 {
+		return nil
+	}
+
+	svc := a.services[a.svcCursor]
+	switch k {
+	case "l":
+		if a.logService == svc.Name {
+			a.logService, a.logs = "", nil
+			return nil
+		}
+		a.logService, a.logs = svc.Name, nil
+		a.setStatus(fmt.Sprintf("Loading the logs of %s...", svc.Name))
+		return []Cmd{a.loadLogs()}
+	case "e":
+		backend, envID := a.backend, a.env.ID
+		return []Cmd{func(context.Context) Msg {
+			return suspendMsg{
+				run: func() error { return backend.Exec(envID, svc) },
+				done: func(err error) Msg {
+					if err != nil {
+						return statusMsg{err: fmt.Errorf("exec in %s failed: %w", svc.Name, err)}
+					}
+					return statusMsg{text: fmt.Sprintf("Left the shell in %s.", svc.Name)}
+				},
+			}
+		}}
+	case "p":
+		return a.togglePortForward(svc)
+	}
+	return nil
+}
+
+func (a *App) environmentAction(k Key, env types.Environment) []Cmd {
+	switch k {
+	case "s":
+		a.confirm = &confirmation{verb: "stop", env: env}
+	case "r":
+		a.confirm = &confirmation{verb: "restart", env: env}
+	case "b":
+		a.confirm = &confirmation{verb: "rebuild", env: env}
+	case "v":
+		if env.Attributes.URL == "" {
+			a.setError(fmt.Errorf("%s has no URL", describe(env)))
+			return nil
+		}
+		backend, url := a.backend, env.Attributes.URL
+		return []Cmd{func(context.Context) Msg {
+			if err := backend.Visit(url); err != nil {
+				return statusMsg{err: fmt.Errorf("unable to open a web browser, visit the environment at %s", url)}
+			}
+			return statusMsg{text: "Opened " + url + "."}
+		}}
+	}
+	return nil
+}
+
+func (a *App) togglePortForward(svc types.Service) []Cmd {
+	key := a.env.ID + "/" + svc.Name
+	if f, ok := a.forwards[key]; ok {
+		f.cancel()
+		return nil
+	}
+	if len(svc.Ports) == 0 {
+		a.setError(fmt.Errorf("%s exposes no ports", svc.Name))
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.forwards[key] = &forward{service: svc, cancel: cancel}
+	a.setStatus(fmt.Sprintf("Starting to forward the ports of %s...", svc.Name))
+
+	backend, envID := a.backend, a.env.ID
+	return []Cmd{func(context.Context) Msg {
+		ready, ended := make(chan struct{}), make(chan error, 1)
+		go func() {
+			ended <- backend.PortForward(ctx, envID, svc, func() { close(ready) })
+		}()
+		wait := func(context.Context) Msg {
+			err := <-ended
+			if ctx.Err() != nil {
+				err = nil
+			}
+			return forwardEndedMsg{key: key, err: err}
+		}
+		select {
+		case <-ready:
+			return forwardReadyMsg{key: key, wait: wait}
+		case err := <-ended:
+			if err == nil && ctx.Err() == nil {
+				err = errors.New("the connection closed")
+			}
+			return forwardEndedMsg{key: key, err: err}
+		}
+	}}
+}
+
+func (a *App) refresh() []Cmd {
+	cmds := []Cmd{a.loadEnvironments()}
+	if a.screen == screenServices {
+		cmds = append(cmds, a.loadServices())
+		if a.logService != "" {
+			cmds = append(cmds, a.loadLogs())
+		}
+	}
+	return cmds
+}
+
+func (a *App) loadEnvironments() Cmd {
+	backend := a.backend
+	return func(ctx context.Context) Msg {
+		envs, err := backend.Environments(ctx)
+		return environmentsMsg{envs: envs, err: err}
+	}
+}
+
+func (a *App) loadServices() Cmd {
+	backend, envID := a.backend, a.env.ID
+	return func(ctx context.Context) Msg {
+		svcs, err := backend.Services(ctx, envID)
+		return servicesMsg{envID: envID, services: svcs, err: err}
+	}
+}
+
+func (a *App) loadLogs() Cmd {
+	backend, envID := a.backend, a.env.ID
+	var svc types.Service
+	for _, s := range a.services {
+		if s.Name == a.logService {
+			svc = s
+		}
+	}
+	return func(ctx context.Context) Msg {
+		logs, err := backend.Logs(ctx, envID, svc, logLines)
+		return logsMsg{envID: envID, service: svc.Name, logs: logs, err: err}
+	}
+}
+
+func (a *App) runAction(verb string, env types.Environment) Cmd {
+	backend := a.backend
+	return func(ctx context.Context) Msg {
+		return actionMsg{verb: verb, env: env, err: backend.Action(ctx, verb, env.ID)}
+	}
+}
+
+func (a *App) setEnvironments(m environmentsMsg) {
+	if m.err != nil {
+		a.setError(fmt.Errorf("failed to list environments: %w", m.err))
+		return
+	}
+
+	// Keep the cursor on the same environment when the list changes.
+	var selected string
+	if envs := a.visibleEnvironments(); a.cursor < len(envs) {
+		selected = envs[a.cursor].ID
+	}
+
+	previous := make(map[string]string, len(a.envs))
+	for _, env := range a.envs {
+		previous[env.ID] = state(env)
+	}
+	a.changed = make(map[string]bool)
+	for _, env := range m.envs {
+		if s, ok := previous[env.ID]; a.loaded && (!ok || s != state(env)) {
+			a.changed[env.ID] = true
+		}
+		if env.ID == a.env.ID {
+			a.env = env
+		}
+	}
+
+	a.envs, a.loaded, a.updated = m.envs, true, a.now()
+	envs := a.visibleEnvironments()
+	a.cursor = clamp(a.cursor, len(envs))
+	for i := range envs {
+		if envs[i].ID == selected {
+			a.cursor = i
+		}
+	}
+}
+
+// visibleEnvironments returns the environments that match the filter.
+func (a *App) visibleEnvironments() []types.Environment {
+	if a.filter == "" {
+		return a.envs
+	}
+	filter := strings.ToLower(a.filter)
+	var envs []types.Environment
+	for _, env := range a.envs {
+		fields := []string{env.ID, env.Attributes.Name}
+		for _, p := range env.Attributes.Projects {
+			fields = append(fields, p.RepoName, p.Branch, strconv.Itoa(p.PullRequestNumber))
+		}
+		if strings.Contains(strings.ToLower(strings.Join(fields, " ")), filter) {
+			envs = append(envs, env)
+		}
+	}
+	return envs
+}
+
+func (a *App) setStatus(s string) {
+	a.status, a.failed = s, false
+}
+
+func (a *App) setError(err error) {
+	a.status, a.failed = "Error: "+err.Error(), true
+}
+
+// View draws the screen, one string per line, fitted to the width of the terminal.
+func (a *App) View() []string {
+	lines := []string{a.header(), a.filterLine()}
+
+	bodyHeight := a.height - len(lines) - 2
+	if a.screen == screenServices {
+		lines = append(lines, a.servicesView(bodyHeight)...)
+	} else {
+		lines = append(lines, a.environmentsView(bodyHeight)...)
+	}
+	for len(lines) < a.height-2 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, a.statusLine(), a.helpLine())
+
+	for i := range lines {
+		lines[i] = truncate(lines[i], a.width)
+	}
+	return lines
+}
+
+func (a *App) header() string {
+	s := bold + "Shipyard" + reset
+	if a.title != "" {
+		s += "  " + a.title
+	}
+	if a.loaded {
+		s += fmt.Sprintf("  %d environments  updated %s", len(a.envs), a.updated.Format(time.TimeOnly))
+	}
+	return s
+}
+
+func (a *App) filterLine() string {
+	switch {
+	case a.filtering:
+		return "Filter: " + a.filter + "█"
+	case a.filter != "":
+		return "Filter: " + a.filter + "  (esc to clear)"
+	default:
+		return ""
+	}
+}
+
+func (a *App) environmentsView(height int) []string {
+	if !a.loaded {
+		return []string{"Loading environments..."}
+	}
+	envs := a.visibleEnvironments()
+	if len(envs) == 0 {
+		return []string{"No environments found."}
+	}
+
+	rows := make([][]string, 0, len(envs))
+	for _, env := range envs {
+		var repos []string
+		var pr string
+		for _, p := range env.Attributes.Projects {
+			repos = append(repos, p.RepoName)
+			if pr == "" {
+				pr = display.FormatPRNumber(strconv.Itoa(p.PullRequestNumber), p.Branch)
+			}
+		}
+		rows = append(rows, []string{
+			display.FormatColoredAppName(env.Attributes.Name),
+			env.ID,
+			formatState(env),
+			strings.Join(repos, ","),
+			pr,
+			display.FormatClickableURL(env.Attributes.URL),
+		})
+	}
+
+	header, lines := table([]string{"App", "UUID", "Ready", "Repo", "PR#", "URL"}, rows)
+	out := []string{"  " + header}
+	start, end := window(a.cursor, len(lines), height-1)
+	for i := start; i < end; i++ {
+		out = append(out, a.row(lines[i], i == a.cursor, a.changed[envs[i].ID]))
+	}
+	return out
+}
+
+func (a *App) servicesView(height int) []string {
+	out := []string{
+		fmt.Sprintf("%s  %s  %s  %s", display.FormatColoredAppName(a.env.Attributes.Name), a.env.ID, formatState(a.env), display.FormatClickableURL(a.env.Attributes.URL)),
+		"",
+	}
+	if len(a.services) == 0 {
+		return append(out, "No services found.")
+	}
+
+	rows := make([][]string, 0, len(a.services))
+	for _, svc := range a.services {
+		var forwarding string
+		if f, ok := a.forwards[a.env.ID+"/"+svc.Name]; ok {
+			forwarding = "starting"
+			if f.ready {
+				forwarding = "localhost:" + strings.Join(svc.Ports, ",")
+			}
+		}
+		rows = append(rows, []string{
+			display.FormatColoredAppName(svc.Name),
+			strings.Join(svc.Ports, ","),
+			display.FormatClickableURL(svc.URL),
+			forwarding,
+		})
+	}
+
+	// The log pane takes the lower two thirds of the screen.
+	tableHeight := height - len(out)
+	if a.logService != "" {
+		tableHeight = min(tableHeight, max(4, tableHeight/3))
+	}
+	header, lines := table([]string{"Service", "Ports", "URL", "Forwarding"}, rows)
+	out = append(out, "  "+header)
+	start, end := window(a.svcCursor, len(lines), tableHeight-1)
+	for i := start; i < end; i++ {
+		out = append(out, a.row(lines[i], i == a.svcCursor, false))
+	}
+
+	if a.logService != "" {
+		out = append(out, "", bold+"Logs of "+a.logService+reset)
+		logHeight := height - len(out)
+		logs := a.logs
+		if len(logs) > logHeight {
+			logs = logs[len(logs)-max(logHeight, 0):]
+		}
+		out = append(out, logs...)
+	}
+	return out
+}
+
+// row adds the gutter to a table line: a marker for the cursor and for a change in the last poll.
+// The line under the cursor is shown in reverse video.
+func (a *App) row(line string, selected, changed bool) string {
+	gutter := "  "
+	if changed {
+		gutter = color.New(color.FgYellow, color.Bold).Sprint("*") + " "
+	}
+	if selected {
+		return reverse + display.StripEscapes(gutter+line) + reset
+	}
+	return gutter + line
+}
+
+func (a *App) statusLine() string {
+	if a.confirm != nil {
+		return bold + fmt.Sprintf("%s %s? [y/N]", display.Capitalize(a.confirm.verb), describe(a.confirm.env)) + reset
+	}
+	if a.failed {
+		return color.New(color.FgRed).Sprint(a.status)
+	}
+	return a.status
+}
+
+func (a *App) helpLine() string {
+	if a.filtering {
+		return "type to filter  enter done  esc clear"
+	}
+	if a.screen == screenServices {
+		return "↑/↓ move  l logs  e exec  p port-forward  s stop  r restart  b rebuild  v visit  esc back  q quit"
+	}
+	return "↑/↓ move  enter services  / filter  s stop  r restart  b rebuild  v visit  ctrl+r refresh  q quit"
+}
+
+// state is what a change is highlighted for.
+func state(env types.Environment) string {
+	return fmt.Sprintf("ready=%t stopped=%t retired=%t", env.Attributes.Ready, env.Attributes.Stopped, env.Attributes.Retired)
+}
+
+func formatState(env types.Environment) string {
+	switch {
+	case env.Attributes.Retired:
+		return color.New(color.FgYellow).Sprint("Retired")
+	case env.Attributes.Stopped:
+		return color.New(color.FgYellow).Sprint("Stopped")
+	default:
+		return display.FormatReadyStatus(env.Attributes.Ready)
+	}
+}
+
+func describe(env types.Environment) string {
+	return fmt.Sprintf("%s (%s)", env.Attributes.Name, env.ID)
+}
+
+// move returns the cursor after an arrow or paging key, within n rows.
+func move(cursor, n int, k Key) int {
+	switch k {
+	case KeyUp, "k":
+		cursor--
+	case KeyDown, "j":
+		cursor++
+	case KeyPageUp:
+		cursor -= 10
+	case KeyPageDown:
+		cursor += 10
+	}
+	return clamp(cursor, n)
+}
+
+func clamp(cursor, n int) int {
+	return max(0, min(cursor, n-1))
+}
+
+// window returns the range of rows to show in height lines, so that the cursor is visible.
+func window(cursor, n, height int) (start, end int) {
+	height = max(height, 1)
+	start = max(0, cursor-height+1)
+	return start, min(n, start+height)
+}
@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/viper"
+
+	"github.com/shipyard/shipyard-cli/pkg/client"
+	"github.com/shipyard/shipyard-cli/pkg/display"
+	"github.com/shipyard/shipyard-cli/pkg/requests"
+	"github.com/shipyard/shipyard-cli/tests/server"
+)
+
+func TestDashboard(t *testing.T) {
+	app := setup(t)
+
+	screen := view(app)
+	for _, want := range []string{"fleet-1", "fleet-2", "fleet-3", "fleet-4", "Stopped"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("expected the environments list to contain %q:\n%s", want, screen)
+		}
+	}
+
+	send(app, "/", "f", "e", "a", "t", KeyEnter)
+	screen = view(app)
+	if !strings.Contains(screen, "fleet-2") || strings.Contains(screen, "fleet-1") {
+		t.Errorf("expected the filter to only keep fleet-2:\n%s", screen)
+	}
+
+	send(app, KeyEnter)
+	screen = view(app)
+	for _, want := range []string{"Service", "web", "worker", "https://web.checkout-pr.example.com"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("expected the services of fleet-2 to contain %q:\n%s", want, screen)
+		}
+	}
+
+	send(app, "s")
+	if got, want := lastLines(app)[0], "Stop checkout (fleet-2)? [y/N]"; got != want {
+		t.Errorf("expected a confirmation prompt %q, but got %q", want, got)
+	}
+	send(app, "y")
+	if got, want := lastLines(app)[0], "checkout (fleet-2): stop accepted."; got != want {
+		t.Errorf("expected status %q, but got %q", want, got)
+	}
+
+	send(app, KeyEscape, KeyEscape)
+	screen = view(app)
+	if !strings.Contains(screen, "fleet-1") || strings.Contains(screen, "Filter:") {
+		t.Errorf("expected the full list after clearing the filter:\n%s", screen)
+	}
+
+	send(app, "q")
+	if !app.Quitting() {
+		t.Error("expected q to quit")
+	}
+}
+
+func TestDashboardErrors(t *testing.T) {
+	app := setup(t)
+
+	// fleet-4 is the last environment, the server refuses its actions.
+	send(app, KeyDown, KeyDown, KeyDown, "r", "y")
+	if got, want := lastLines(app)[0], "Error: failed to restart broken (fleet-4): cannot restart this environment"; got != want {
+		t.Errorf("expected status %q, but got %q", want, got)
+	}
+
+	send(app, "b", "n")
+	if got, want := lastLines(app)[0], "Canceled."; got != want {
+		t.Errorf("expected status %q, but got %q", want, got)
+	}
+
+	// The server has no kubeconfig to hand out.
+	send(app, KeyUp, KeyUp, KeyEnter, "l")
+	if got := lastLines(app)[0]; !strings.HasPrefix(got, "Error: failed to get the logs of web:") {
+		t.Errorf("expected the logs to fail, but got status %q", got)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := ParseKeys([]byte("q\x1b[A\x1b[6~\r\x1b\x7fé\x1b[1;5C\x03"))
+	want := []Key{"q", KeyUp, KeyPageDown, KeyEnter, KeyEscape, KeyBackspace, "é", KeyCtrlC}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	s := bold + "Shipyard" + reset + " dashboard"
+	got := truncate(s, 10)
+	if want := bold + "Shipyard" + reset + " d" + reset; got != want {
+		t.Errorf("expected %q, but got %q", want, got)
+	}
+	if got := truncate(s, 20); got != s {
+		t.Errorf("expected %q to fit, but got %q", s, got)
+	}
+}
+
+// setup returns a dashboard of the fleet org of the fake API, with its first screen loaded.
+func setup(t *testing.T) *App {
+	t.Helper()
+	srv := httptest.NewServer(server.NewHandler())
+	t.Cleanup(srv.Close)
+	viper.Set("api_url", srv.URL)
+	viper.Set("api_token", "fake-token")
+
+	c := client.New(requests.New(), func() string { return "fleet" })
+	app := New(NewBackend(c, client.EnvironmentFilter{}), "org fleet")
+	run(app, app.Init())
+	return app
+}
+
+// send presses the keys and waits for the commands they start, like Run does in the background.
+func send(app *App, keys ...Key) {
+	for _, k := range keys {
+		run(app, app.Update(k))
+	}
+}
+
+func run(app *App, cmds []Cmd) {
+	for _, cmd := range cmds {
+		run(app, app.Update(cmd(context.Background())))
+	}
+}
+
+func view(app *App) string {
+	lines := app.View()
+	for i := range lines {
+		lines[i] = display.StripEscapes(lines[i])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lastLines returns the status and help lines.
+func lastLines(app *App) []string {
+	lines := app.View()
+	return []string{display.StripEscapes(lines[len(lines)-2]), display.StripEscapes(lines[len(lines)-1])}
+}
@@ -40,7 +40,7 @@ type Response struct {
 
 type RespManyEnvs struct {
 	Data  []Environment `json:"data"`
-	Links Links         `json:"links"`
+	Links Links         `json:"links,omitzero"`
 }
 
 type UUIDResponse struct {
@@ -55,13 +55,44 @@ type OrgsResponse struct {
 	} `json:"data"`
 }
 
+// UserResponse describes who the API token belongs to.
+type UserResponse struct {
+	Data struct {
+		Attributes UserAttributes `json:"attributes"`
+	} `json:"data"`
+}
+
+type UserAttributes struct {
+	Name       string `json:"name"`
+	Email      string `json:"email"`
+	DefaultOrg string `json:"default_org"`
+	// TokenType is "user" for a personal token, or "org" for an org-level one.
+	TokenType string `json:"token_type"`
+}
+
 type VolumesResponse struct {
 	Data []Volume `json:"data"`
 }
 
 type SnapshotsResponse struct {
 	Data  []Snapshot `json:"data"`
-	Links Links      `json:"links"`
+	Links Links      `json:"links,omitzero"`
+}
+
+type ServicesResponse struct {
+	Data []Service `json:"data"`
+}
+
+// ActionResult is the outcome of an action taken on a resource.
+type ActionResult struct {
+	ID     string `json:"id"`
+	Action string `json:"action"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ActionResultsResponse struct {
+	Data []ActionResult `json:"data"`
 }
 
 type Links struct {
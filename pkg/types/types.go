@@ -15,15 +15,22 @@ type Environment struct {
 type Project struct {
 	PullRequestNumber int    `json:"pull_request_number"`
 	RepoName          string `json:"repo_name"`
+	RepoOwner         string `json:"repo_owner"`
 	Branch            string `json:"branch"`
+	CommitHash        string `json:"commit_hash"`
 }
 
 type EnvironmentAttributes struct {
-	Name     string    `json:"name"`
-	URL      string    `json:"url"`
-	Ready    bool      `json:"ready"`
-	Projects []Project `json:"projects"`
-	Services []Service `json:"services"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Ready   bool   `json:"ready"`
+	Stopped bool   `json:"stopped"`
+	Retired bool   `json:"retired"`
+	// SinceLastVisit is the number of minutes since the environment was last visited,
+	// or nil if it never was.
+	SinceLastVisit *int      `json:"since_last_visit"`
+	Projects       []Project `json:"projects"`
+	Services       []Service `json:"services"`
 }
 
 type Volume struct {
@@ -4,24 +4,53 @@ import (
 	"archive/tar"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/dsnet/compress/bzip2"
 )
 
 const archiveExtension = ".tar.bz2"
 
-func CreateArchiveFromDir(source string) error {
+// CreateArchiveFromDir archives the files in source, except those matching one of the ignore patterns.
+// A pattern, in the syntax of filepath.Match, is matched against both the path relative to source
+// and the base name, so "node_modules" skips every directory of that name and "logs/*.log" only the logs in logs.
+func CreateArchiveFromDir(source string, ignore ...string) error {
 	return createArchive(source, func(tarWriter *tar.Writer) error {
 		return filepath.Walk(source, func(file string, fi os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
+			if ignored(source, file, fi, ignore) {
+				if fi.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
 			return writeToArchive(tarWriter, file, fi)
 		})
 	})
 }
 
+func ignored(source, file string, fi os.FileInfo, patterns []string) bool {
+	rel, err := filepath.Rel(source, file)
+	if err != nil || rel == "." {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(pattern, "/")
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, fi.Name()); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func CreateArchiveFromFile(name string) error {
 	fi, err := os.Stat(name)
 	if err != nil {
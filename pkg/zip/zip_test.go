@@ -1,8 +1,15 @@
 package zip
 
 import (
+	"archive/tar"
+	"errors"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"testing"
+
+	"github.com/dsnet/compress/bzip2"
 )
 
 func TestCreateArchiveFromDir(t *testing.T) {
@@ -40,3 +47,48 @@ func TestCreateArchiveFromFile(t *testing.T) {
 		t.Fatalf("failed to locate the archive file: %v", err)
 	}
 }
+
+func TestCreateArchiveFromDirIgnore(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"keep.txt", "debug.log", "logs/app.log", "logs/notes.txt", "node_modules/lib/index.js"} {
+		p := filepath.Join(dir, "src", name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source := filepath.Join(dir, "src")
+	if err := CreateArchiveFromDir(source, "*.log", "node_modules/"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f, err := os.Open(source + archiveExtension)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	bz2Reader, err := bzip2.NewReader(f, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	tarReader := tar.NewReader(bz2Reader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rel, _ := filepath.Rel(filepath.ToSlash(source), header.Name)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	want := []string{"keep.txt", "logs/notes.txt"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v in the archive, but got %v", want, got)
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -64,6 +67,21 @@ func TestGetAllEnvironments(t *testing.T) {
 			args: []string{"get", "envs", "--org", "pugs", "--json"},
 			ids:  []string{"pug-1", "pug-2"},
 		},
+		{
+			name: "single page",
+			args: []string{"get", "envs", "--org", "fleet", "--page-size", "2", "--json"},
+			ids:  []string{"fleet-1", "fleet-2"},
+		},
+		{
+			name: "all pages",
+			args: []string{"get", "envs", "--org", "fleet", "--page-size", "2", "--all", "--json"},
+			ids:  []string{"fleet-1", "fleet-2", "fleet-3", "fleet-4"},
+		},
+		{
+			name: "all pages with filters",
+			args: []string{"get", "envs", "--org", "fleet", "--repo-name", "shop", "--page-size", "1", "--all", "--json"},
+			ids:  []string{"fleet-1", "fleet-2", "fleet-3"},
+		},
 		{
 			name:   "non existent org",
 			args:   []string{"get", "envs", "--org", "cats"},
@@ -92,13 +110,22 @@ func TestGetAllEnvironments(t *testing.T) {
 				return
 			}
 
-			var resp types.RespManyEnvs
-			if err := json.Unmarshal(c.stdOut.Bytes(), &resp); err != nil {
-				t.Fatal(err)
+			// Every page together is a plain array, a single page has the shape of the API response
+			var envs []types.Environment
+			if slices.Contains(test.args, "--all") {
+				if err := json.Unmarshal(c.stdOut.Bytes(), &envs); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				var resp types.RespManyEnvs
+				if err := json.Unmarshal(c.stdOut.Bytes(), &resp); err != nil {
+					t.Fatal(err)
+				}
+				envs = resp.Data
 			}
 			var ids []string
-			for i := range resp.Data {
-				ids = append(ids, resp.Data[i].ID)
+			for i := range envs {
+				ids = append(ids, envs[i].ID)
 			}
 			want := test.ids
 			if !cmp.Equal(ids, want) {
@@ -225,6 +252,1107 @@ func TestRebuildEnvironment(t *testing.T) {
 	}
 }
 
+func TestOutputFormats(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		args   []string
+		output string
+		err    string
+	}{
+		{
+			name:   "name",
+			args:   []string{"get", "envs", "--org", "fleet", "-o", "name"},
+			output: "fleet-1\nfleet-2\nfleet-3\nfleet-4\n",
+		},
+		{
+			name:   "jsonpath",
+			args:   []string{"get", "env", "pug-1", "--org", "pugs", "-o", "jsonpath={.data.id}"},
+			output: "pug-1\n",
+		},
+		{
+			name:   "csv",
+			args:   []string{"get", "envs", "--org", "fleet", "--repo-name", "legacy", "-o", "csv"},
+			output: "App,UUID,Ready,Repo,PR#,URL,Branch,Services\nbroken,fleet-4,No,legacy,main,https://broken.example.com,main,\n",
+		},
+		{
+			name:   "action result",
+			args:   []string{"stop", "env", "default-1", "-o", "go-template={{.action}}: {{.result}}"},
+			output: "stop: stopped\n",
+		},
+		{
+			name:   "bulk results",
+			args:   []string{"restart", "env", "--org", "fleet", "--branch", "feature", "--yes", "-o", "name"},
+			output: "fleet-2\n",
+		},
+		{
+			name: "bulk without confirmation",
+			args: []string{"restart", "env", "--org", "fleet", "--branch", "feature", "-o", "name"},
+			err:  "Command error: pass --yes to restart environments with -o name, the confirmation prompt would mix with the output\n",
+		},
+		{
+			name: "unknown format",
+			args: []string{"stop", "env", "default-1", "-o", "xml"},
+			err:  "Command error: unknown output format \"xml\", expected one of: table, wide, json, yaml, name, csv, jsonpath=, go-template=\n",
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			c := newCmd(test.args)
+			if err := c.cmd.Run(); err != nil {
+				if test.err == "" {
+					t.Fatalf("command unexpectedly failed: %v\nstderr: %s", err, c.stdErr.String())
+				}
+				if diff := cmp.Diff(c.stdErr.String(), test.err); diff != "" {
+					t.Error(diff)
+				}
+				return
+			}
+			if test.err != "" {
+				t.Fatalf("expected error %q but command succeeded", test.err)
+			}
+			if diff := cmp.Diff(c.stdOut.String(), test.output); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestBulkEnvironmentAction(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		contains []string
+		excludes []string
+		output   string
+	}{
+		{
+			name:     "dry run lists the matched environments",
+			args:     []string{"stop", "env", "--org", "fleet", "--repo-name", "shop", "--dry-run"},
+			contains: []string{"fleet-1", "fleet-2", "fleet-3", "Dry run: 3 environment(s) would be affected by stop."},
+			excludes: []string{"fleet-4"},
+		},
+		{
+			name:   "no terminal to confirm",
+			args:   []string{"restart", "env", "--org", "fleet", "--name", "search"},
+			stdin:  "y\n",
+			output: "Command error: cannot ask for a confirmation without a terminal or with --no-input, pass --yes to restart environments\n",
+		},
+		{
+			name:   "no input",
+			args:   []string{"restart", "env", "--org", "fleet", "--name", "search", "--no-input"},
+			output: "Command error: cannot ask for a confirmation without a terminal or with --no-input, pass --yes to restart environments\n",
+		},
+		{
+			name:     "confirmed with --yes",
+			args:     []string{"rebuild", "env", "--org", "fleet", "--pull-request-number", "7", "--yes"},
+			contains: []string{"fleet-2", "queued for a rebuild"},
+		},
+		{
+			name:     "no matches",
+			args:     []string{"stop", "env", "--org", "fleet", "--branch", "nope"},
+			contains: []string{"No environments matched the filters."},
+		},
+		{
+			name:   "partial failure",
+			args:   []string{"stop", "env", "--org", "fleet", "--branch", "main", "--yes"},
+			output: "Command error: failed to stop 1 of 3 environment(s)\n",
+		},
+		{
+			name:   "ID combined with filters",
+			args:   []string{"stop", "env", "fleet-1", "--org", "fleet", "--branch", "main"},
+			output: "Command error: an environment ID cannot be combined with the --name, --repo-name, --branch or --pull-request-number filters\n",
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			c := newCmd(test.args)
+			c.cmd.Stdin = strings.NewReader(test.stdin)
+			if err := c.cmd.Run(); err != nil {
+				if test.output == "" {
+					t.Fatalf("command unexpectedly failed: %v\nstderr: %s", err, c.stdErr.String())
+				}
+				if diff := cmp.Diff(c.stdErr.String(), test.output); diff != "" {
+					t.Error(diff)
+				}
+				return
+			}
+			if test.output != "" {
+				t.Fatalf("Expected error %q but command succeeded", test.output)
+			}
+
+			out := c.stdOut.String()
+			for _, want := range test.contains {
+				if !strings.Contains(out, want) {
+					t.Errorf("output does not contain %q:\n%s", want, out)
+				}
+			}
+			for _, unwanted := range test.excludes {
+				if strings.Contains(out, unwanted) {
+					t.Errorf("output unexpectedly contains %q:\n%s", unwanted, out)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveEnvironmentFromGit(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		branch string
+		args   []string
+		output string
+		stderr string
+		err    bool
+	}{
+		{
+			name:   "single match",
+			branch: "feature",
+			args:   []string{"get", "env", "--org", "fleet", "-o", "name"},
+			output: "fleet-2\n",
+			stderr: "Using environment checkout (fleet-2) for repo shop, branch feature.\n",
+		},
+		{
+			name:   "flag commands",
+			branch: "feature",
+			args:   []string{"stop", "env", "--org", "fleet", "--yes"},
+			output: "Environment stopped.\n",
+			stderr: "Using environment checkout (fleet-2) for repo shop, branch feature.\n",
+		},
+		{
+			name:   "flag commands ask to confirm",
+			branch: "feature",
+			args:   []string{"stop", "env", "--org", "fleet"},
+			stderr: "Using environment checkout (fleet-2) for repo shop, branch feature.\n" +
+				"Command error: cannot ask for a confirmation without a terminal or with --no-input, pass --yes to stop environments\n",
+			err: true,
+		},
+		{
+			name:   "revive picks the deleted environment",
+			branch: "feature",
+			args:   []string{"revive", "env", "--org", "fleet", "-o", "name", "--yes"},
+			output: "fleet-5\n",
+			stderr: "Using environment checkout (fleet-5) for repo shop, branch feature.\n",
+		},
+		{
+			name:   "revive without a deleted environment",
+			branch: "main",
+			args:   []string{"revive", "env", "--org", "fleet"},
+			stderr: "Command error: no deleted environment found for repo shop, branch main, pass an environment ID\n",
+			err:    true,
+		},
+		{
+			name:   "several matches",
+			branch: "main",
+			args:   []string{"get", "env", "--org", "fleet"},
+			stderr: "Command error: 2 environments found for repo shop, branch main: checkout (fleet-1), search (fleet-3); pass an environment ID\n",
+			err:    true,
+		},
+		{
+			name:   "no match",
+			branch: "nothing-here",
+			args:   []string{"get", "env", "--org", "fleet"},
+			stderr: "Command error: no environment found for repo shop, branch nothing-here, pass an environment ID\n",
+			err:    true,
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			for _, args := range [][]string{
+				{"init", "-q"},
+				{"checkout", "-q", "-b", test.branch},
+				{"remote", "add", "origin", "git@github.com:acme/shop.git"},
+			} {
+				if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+					t.Fatalf("git %v: %v: %s", args, err, out)
+				}
+			}
+
+			c := newCmd(test.args)
+			c.cmd.Dir = dir
+			c.cmd.Path = absPath(t, "shipyard")
+			c.cmd.Args = append([]string{c.cmd.Path, "--config", absPath(t, "config.yaml")}, test.args...)
+			// Keep the PR number of the CI run, if any, out of the filters.
+			c.cmd.Env = append(c.cmd.Env, "GITHUB_REF=", "CI_MERGE_REQUEST_IID=", "CIRCLE_PR_NUMBER=", "CHANGE_ID=")
+
+			err := c.cmd.Run()
+			if test.err != (err != nil) {
+				t.Fatalf("unexpected result: %v\nstderr: %s", err, c.stdErr.String())
+			}
+			if diff := cmp.Diff(c.stdOut.String(), test.output); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(c.stdErr.String(), test.stderr); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestDescribeEnvironment(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		args     []string
+		output   string
+		contains []string
+		stderr   string
+	}{
+		{
+			name: "table without a cluster",
+			args: []string{"describe", "env", "fleet-2", "--org", "fleet"},
+			contains: []string{
+				"Name:       checkout\n",
+				"Stopped:    No\n",
+				"Last visit: 3h ago\n",
+				"acme",
+				"4f2a9c1",
+				"https://web.checkout-pr.example.com",
+			},
+			stderr: "Pod health is unavailable: failed to retrieve kubeconfig",
+		},
+		{
+			name:   "csv",
+			args:   []string{"describe", "env", "fleet-2", "--org", "fleet", "--skip-pods", "-o", "csv"},
+			output: "Service,Ports,URL,Pod,Phase,Restarts,Last Termination\nweb,8080,https://web.checkout-pr.example.com,,,,\nworker,,,,,,\n",
+		},
+		{
+			name:   "stopped environment",
+			args:   []string{"describe", "env", "fleet-3", "--org", "fleet", "-o", "jsonpath={.data.attributes.stopped}"},
+			output: "true\n",
+		},
+		{
+			name:   "project fields",
+			args:   []string{"describe", "env", "fleet-2", "--org", "fleet", "--skip-pods", "-o", "jsonpath={.data.attributes.projects[0].repo_owner} {.data.attributes.since_last_visit}"},
+			output: "acme 180\n",
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			c := newCmd(test.args)
+			if err := c.cmd.Run(); err != nil {
+				t.Fatalf("command unexpectedly failed: %v\nstderr: %s", err, c.stdErr.String())
+			}
+			if test.output != "" {
+				if diff := cmp.Diff(c.stdOut.String(), test.output); diff != "" {
+					t.Error(diff)
+				}
+			}
+			for _, s := range test.contains {
+				if !strings.Contains(c.stdOut.String(), s) {
+					t.Errorf("expected output to contain %q, got:\n%s", s, c.stdOut.String())
+				}
+			}
+			if test.stderr == "" && c.stdErr.Len() > 0 {
+				t.Errorf("unexpected stderr: %s", c.stdErr.String())
+			}
+			if !strings.Contains(c.stdErr.String(), test.stderr) {
+				t.Errorf("expected stderr to contain %q, got: %s", test.stderr, c.stdErr.String())
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+	c := newCmd([]string{"get", "envs", "--org", "fleet", "--branch", "feature", "--watch", "--interval", "1s"})
+	if err := c.cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	// Let it poll twice, the second poll finds no changes.
+	time.Sleep(1500 * time.Millisecond)
+	if err := c.cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.cmd.Wait(); err != nil {
+		t.Fatalf("command unexpectedly failed: %v\nstderr: %s", err, c.stdErr.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(c.stdOut.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected a single event, but got %q", c.stdOut.String())
+	}
+	var event struct {
+		Type   string            `json:"type"`
+		ID     string            `json:"id"`
+		Object types.Environment `json:"object"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != "added" || event.ID != "fleet-2" || event.Object.Attributes.Name != "checkout" {
+		t.Errorf("unexpected event %s", lines[0])
+	}
+}
+
+func TestWatchErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{
+			args: []string{"get", "envs", "--watch", "-o", "yaml"},
+			err:  "Command error: --watch prints a table or JSON events, it cannot be used with -o yaml\n",
+		},
+		{
+			args: []string{"get", "services", "--env", "default-1", "--watch", "--interval", "10ms"},
+			err:  "Command error: --interval must be at least 1s\n",
+		},
+		{
+			args: []string{"get", "env", "sharpei-1", "--org", "pugs", "--watch"},
+			err:  "Command error: environment not found\n",
+		},
+	}
+	for _, test := range tests {
+		c := newCmd(test.args)
+		if err := c.cmd.Run(); err == nil {
+			t.Fatalf("%v: expected error %q but command succeeded", test.args, test.err)
+		}
+		if diff := cmp.Diff(c.stdErr.String(), test.err); diff != "" {
+			t.Errorf("%v: %s", test.args, diff)
+		}
+	}
+}
+
+func TestMissingServiceWithoutTerminal(t *testing.T) {
+	t.Parallel()
+	for _, args := range [][]string{
+		{"logs", "--env", "fleet-2", "--org", "fleet"},
+		{"exec", "--env", "fleet-2", "--org", "fleet", "--no-input", "--", "ls"},
+	} {
+		c := newCmd(args)
+		if err := c.cmd.Run(); err == nil {
+			t.Fatalf("%v: expected an error but command succeeded", args)
+		}
+		if diff := cmp.Diff(c.stdErr.String(), "Command error: no service given, pass one with --service\n"); diff != "" {
+			t.Errorf("%v: %s", args, diff)
+		}
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	t.Parallel()
+	// Shells run completions without --config, which reads the config in $HOME.
+	home, cache := t.TempDir(), t.TempDir()
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{
+			args: []string{"__complete", "--org", "fleet", "get", "env", ""},
+			want: "fleet-1\nfleet-2\nfleet-3\nfleet-4\n:4\n",
+		},
+		{
+			args: []string{"__complete", "--org", "fleet", "stop", "env", "fleet-1", ""},
+			want: ":4\n",
+		},
+		{
+			args: []string{"__complete", "--org", "fleet", "logs", "--env", "fleet-2", "--service", ""},
+			want: "web\tports 8080\nworker\n:4\n",
+		},
+		{
+			args: []string{"__complete", "mode", ""},
+			want: "local\nqa\nprod\n:4\n",
+		},
+	}
+	for _, test := range tests {
+		c := newCmd(test.args)
+		c.cmd.Env = append(c.cmd.Env, "HOME="+home, "XDG_CACHE_HOME="+cache)
+		if err := c.cmd.Run(); err != nil {
+			t.Fatalf("%v: %v: %s", test.args, err, c.stdErr.String())
+		}
+		got, _, _ := strings.Cut(c.stdOut.String(), "Completion ended")
+		if diff := cmp.Diff(got, test.want); diff != "" {
+			t.Errorf("%v: %s", test.args, diff)
+		}
+	}
+}
+
+func TestEnvironmentAliases(t *testing.T) {
+	t.Parallel()
+	b, err := os.ReadFile("config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(cfg, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		args   []string
+		output string
+		err    string
+	}{
+		{
+			args:   []string{"alias", "list"},
+			output: "No aliases found. Create one with 'shipyard alias set [alias] [environment ID]'.\n",
+		},
+		{
+			args:   []string{"alias", "set", "Search", "fleet-3"},
+			output: "Alias search now points to environment fleet-3.\n",
+		},
+		{
+			args:   []string{"alias", "set", "checkout-pr", "fleet-2"},
+			output: "Alias checkout-pr now points to environment fleet-2.\n",
+		},
+		{
+			args: []string{"alias", "set", "bad.name", "fleet-2"},
+			err:  "Command error: invalid alias \"bad.name\": use letters, digits, '-' and '_', starting with a letter or digit\n",
+		},
+		{
+			args:   []string{"alias", "list", "-o", "csv"},
+			output: "Alias,Environment\ncheckout-pr,fleet-2\nsearch,fleet-3\n",
+		},
+		{
+			args:   []string{"get", "env", "search", "--org", "fleet", "-o", "name"},
+			output: "fleet-3\n",
+		},
+		{
+			args:   []string{"stop", "env", "CHECKOUT-PR", "--org", "fleet", "-o", "jsonpath={.id}"},
+			output: "fleet-2\n",
+		},
+		{
+			args:   []string{"alias", "rm", "search"},
+			output: "Alias search removed.\n",
+		},
+		{
+			args: []string{"alias", "rm", "search"},
+			err:  "Command error: alias \"search\" not found\n",
+		},
+		{
+			args:   []string{"alias", "list", "-o", "name"},
+			output: "checkout-pr\n",
+		},
+	}
+	for _, step := range steps {
+		c := newCmd(append([]string{"--config", cfg}, step.args...))
+		if err := c.cmd.Run(); err != nil {
+			if step.err == "" {
+				t.Fatalf("%v: command unexpectedly failed: %v\nstderr: %s", step.args, err, c.stdErr.String())
+			}
+			if diff := cmp.Diff(c.stdErr.String(), step.err); diff != "" {
+				t.Errorf("%v: %s", step.args, diff)
+			}
+			continue
+		}
+		if step.err != "" {
+			t.Fatalf("%v: expected error %q but command succeeded", step.args, step.err)
+		}
+		if diff := cmp.Diff(c.stdOut.String(), step.output); diff != "" {
+			t.Errorf("%v: %s", step.args, diff)
+		}
+	}
+}
+
+func TestWhoAmI(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		token  string
+		args   []string
+		output string
+		err    string
+	}{
+		{
+			name:  "user token",
+			token: "test",
+			args:  []string{"whoami"},
+			output: `User:        Jane Doe <jane@example.com>
+Token type:  user
+Default org: default
+Org in use:  default
+API URL:     http://localhost:8000
+`,
+		},
+		{
+			name:  "org-level token",
+			token: "org-test",
+			args:  []string{"whoami", "--org", "fleet", "--json"},
+			output: `{
+  "token_type": "org",
+  "default_org": "fleet",
+  "org": "fleet",
+  "api_url": "http://localhost:8000"
+}
+`,
+		},
+		{
+			name:  "invalid token",
+			token: "expired",
+			args:  []string{"whoami"},
+			err:   "Command error: the API token is not valid: invalid API token, run 'shipyard login' to get a new one\n",
+		},
+		{
+			name:  "token without access",
+			token: "forbidden",
+			args:  []string{"whoami"},
+			err:   "Command error: access denied\n",
+		},
+		{
+			name:   "login with a valid token",
+			token:  "test",
+			args:   []string{"login"},
+			output: "You are already logged in as jane@example.com.\n",
+		},
+		{
+			name:  "login with an invalid token in the environment",
+			token: "expired",
+			args:  []string{"login"},
+			err:   "Command error: the token in SHIPYARD_API_TOKEN is not valid, unset it or replace it\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newCmd(test.args)
+			c.cmd.Env = append(c.cmd.Env, "SHIPYARD_API_TOKEN="+test.token)
+			if err := c.cmd.Run(); err != nil {
+				if test.err == "" {
+					t.Fatalf("command unexpectedly failed: %v\nstderr: %s", err, c.stdErr.String())
+				}
+				if diff := cmp.Diff(c.stdErr.String(), test.err); diff != "" {
+					t.Error(diff)
+				}
+				return
+			}
+			if test.err != "" {
+				t.Fatalf("expected error %q but command succeeded", test.err)
+			}
+			if diff := cmp.Diff(c.stdOut.String(), test.output); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestLoginKeepsForbiddenToken(t *testing.T) {
+	t.Parallel()
+	cfg := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(cfg, []byte("api_token: forbidden\norg: default\ncredential_store: config\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A token without access to a resource is still a valid one, so login does not replace it.
+	c := newCmd([]string{"--config", cfg, "login"})
+	c.cmd.Env = append(c.cmd.Env, "SHIPYARD_API_TOKEN=")
+	if err := c.cmd.Run(); err == nil {
+		t.Fatal("expected an error but command succeeded")
+	}
+	if diff := cmp.Diff(c.stdErr.String(), "Command error: failed to check the saved token: access denied\n"); diff != "" {
+		t.Error(diff)
+	}
+	b, err := os.ReadFile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "api_token: forbidden") {
+		t.Errorf("expected the token to be kept in the config, but got:\n%s", b)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	t.Parallel()
+	cfg := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(cfg, []byte("api_token: test\norg: default\ncredential_store: config\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		env    string
+		output string
+		stderr string
+	}{
+		{
+			output: "Logged out of http://localhost:8000.\n",
+		},
+		{
+			output: "You are not logged in.\n",
+		},
+		{
+			env:    "test",
+			output: "You are not logged in.\n",
+			stderr: "SHIPYARD_API_TOKEN is still set, so its token keeps being used until you unset it.\n",
+		},
+	}
+	for i, step := range steps {
+		c := newCmd([]string{"--config", cfg, "logout"})
+		c.cmd.Env = append(c.cmd.Env, "SHIPYARD_API_TOKEN="+step.env)
+		if err := c.cmd.Run(); err != nil {
+			t.Fatalf("step %d: command unexpectedly failed: %v\nstderr: %s", i, err, c.stdErr.String())
+		}
+		if diff := cmp.Diff(c.stdOut.String(), step.output); diff != "" {
+			t.Errorf("step %d: %s", i, diff)
+		}
+		if diff := cmp.Diff(c.stdErr.String(), step.stderr); diff != "" {
+			t.Errorf("step %d: %s", i, diff)
+		}
+	}
+
+	b, err := os.ReadFile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "api_token") {
+		t.Errorf("expected the token to be removed from the config, but got:\n%s", b)
+	}
+}
+
+func TestDeviceLogin(t *testing.T) {
+	t.Parallel()
+	cfg := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(cfg, []byte("org: default\ncredential_store: config\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newCmd([]string{"--config", cfg, "login", "--device"})
+	c.cmd.Env = append(c.cmd.Env, "SHIPYARD_API_TOKEN=")
+	if err := c.cmd.Run(); err != nil {
+		t.Fatalf("command unexpectedly failed: %v\nstderr: %s", err, c.stdErr.String())
+	}
+	out := c.stdOut.String()
+	for _, want := range []string{
+		"To log in, open http://localhost:8000/device in a browser and enter the code WXYZ-",
+		"Waiting for the login to be approved...\n",
+		"Token saved in the config file.\nLogin succeeded!\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected the output to contain %q, but got:\n%s", want, out)
+		}
+	}
+
+	b, err := os.ReadFile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "api_token: test") {
+		t.Errorf("expected the token to be saved in the config, but got:\n%s", b)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	t.Parallel()
+	cfg := filepath.Join(t.TempDir(), "config.yaml")
+	b := "api_token: test\norg: default\napi_url: http://localhost:8000\ncredential_store: config\n"
+	if err := os.WriteFile(cfg, []byte(b), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		args   []string
+		output string
+		err    string
+	}{
+		{
+			args:   []string{"profile", "add", "fleet", "--api-url", "http://localhost:8000", "--org", "fleet"},
+			output: "Profile fleet added. Switch to it with 'shipyard profile use fleet', then log in with 'shipyard login'.\n",
+		},
+		{
+			args: []string{"profile", "add", "fleet", "--api-url", "http://localhost:8000"},
+			err:  "Command error: profile \"fleet\" already exists\n",
+		},
+		{
+			args: []string{"profile", "add", "onprem", "--api-url", "localhost"},
+			err:  "Command error: invalid API URL \"localhost\", it should look like https://shipyard.example.com/api/v1\n",
+		},
+		{
+			args: []string{"profile", "list", "-o", "csv"},
+			output: `Active,Name,API URL,Org
+*,default,http://localhost:8000,default
+,fleet,http://localhost:8000,fleet
+,local,http://localhost:8080/api/v1,
+,prod,https://shipyard.build/api/v1,
+,qa,https://qa.shipyard.build/api/v1,
+`,
+		},
+		{
+			args: []string{"--profile", "fleet", "get", "env", "fleet-1", "-o", "name"},
+			err:  "Command error: no token is saved for profile fleet, run 'shipyard login' or set the 'SHIPYARD_API_TOKEN' environment variable\n",
+		},
+		{
+			args:   []string{"--profile", "fleet", "set", "token", "test"},
+			output: "Token saved in the config file.\n",
+		},
+		{
+			args:   []string{"--profile", "fleet", "get", "env", "fleet-1", "-o", "name"},
+			output: "fleet-1\n",
+		},
+		{
+			args:   []string{"profile", "use", "fleet"},
+			output: "Switched to profile fleet.\n",
+		},
+		{
+			args:   []string{"get", "env", "fleet-2", "-o", "name"},
+			output: "fleet-2\n",
+		},
+		{
+			args: []string{"profile", "show"},
+			output: `Name:    fleet
+API URL: http://localhost:8000
+Org:     fleet
+Verbose: No
+Token:   saved
+Active:  Yes
+`,
+		},
+		{
+			args:   []string{"mode", "default", "get", "env", "--org", "fleet", "fleet-3", "-o", "name"},
+			output: "fleet-3\n",
+		},
+		{
+			args:   []string{"mode", "local"},
+			output: "Switched to profile local.\n",
+		},
+		{
+			args:   []string{"mode", "fleet", "get", "env", "fleet-4", "-o", "name"},
+			output: "fleet-4\n",
+		},
+		{
+			args:   []string{"profile", "rm", "fleet"},
+			output: "Profile fleet removed.\n",
+		},
+		{
+			args: []string{"profile", "rm", "qa"},
+			err:  "Command error: profile \"qa\" is built in and cannot be removed\n",
+		},
+		{
+			args:   []string{"profile", "use", "default"},
+			output: "Switched to profile default.\n",
+		},
+	}
+	for _, step := range steps {
+		c := newCmd(append([]string{"--config", cfg}, step.args...))
+		// Profiles hold the API URL and the token, which the environment variables would override.
+		c.cmd.Env = append(c.cmd.Env, "SHIPYARD_API_URL=", "SHIPYARD_API_TOKEN=")
+		if err := c.cmd.Run(); err != nil {
+			if step.err == "" {
+				t.Fatalf("%v: command unexpectedly failed: %v\nstderr: %s", step.args, err, c.stdErr.String())
+			}
+			if diff := cmp.Diff(c.stdErr.String(), step.err); diff != "" {
+				t.Errorf("%v: %s", step.args, diff)
+			}
+			continue
+		}
+		if step.err != "" {
+			t.Fatalf("%v: expected error %q but command succeeded", step.args, step.err)
+		}
+		if diff := cmp.Diff(c.stdOut.String(), step.output); diff != "" {
+			t.Errorf("%v: %s", step.args, diff)
+		}
+	}
+
+	got, err := os.ReadFile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(got), "fleet") {
+		t.Errorf("expected the fleet profile to be removed from the config, but got:\n%s", got)
+	}
+}
+
+func TestModeWithTokenSavedBeforeProfiles(t *testing.T) {
+	t.Parallel()
+	cfg := filepath.Join(t.TempDir(), "config.yaml")
+	b := "api_token: test\norg: default\ncredential_store: config\n"
+	if err := os.WriteFile(cfg, []byte(b), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		args   []string
+		output string
+	}{
+		{
+			args:   []string{"mode", "prod"},
+			output: "Switched to profile prod.\n",
+		},
+		{
+			args:   []string{"get", "env", "--org", "fleet", "fleet-1", "-o", "name"},
+			output: "fleet-1\n",
+		},
+		{
+			args: []string{"profile", "show"},
+			output: `Name:    prod
+API URL: https://shipyard.build/api/v1
+Verbose: No
+Token:   saved
+Active:  Yes
+`,
+		},
+	}
+	for _, step := range steps {
+		c := newCmd(append([]string{"--config", cfg}, step.args...))
+		// The token comes from the top level of the config, which was the only place for it before profiles.
+		c.cmd.Env = append(c.cmd.Env, "SHIPYARD_API_TOKEN=")
+		if err := c.cmd.Run(); err != nil {
+			t.Fatalf("%v: command unexpectedly failed: %v\nstderr: %s", step.args, err, c.stdErr.String())
+		}
+		if diff := cmp.Diff(c.stdOut.String(), step.output); diff != "" {
+			t.Errorf("%v: %s", step.args, diff)
+		}
+	}
+}
+
+func TestProjectConfig(t *testing.T) {
+	t.Parallel()
+	project := t.TempDir()
+	projectFile := filepath.Join(project, ".shipyard.yaml")
+	b := "org: fleet\naliases:\n  co: fleet-2\nservice: web\n"
+	if err := os.WriteFile(projectFile, []byte(b), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	app := filepath.Join(project, "apps", "checkout")
+	if err := os.MkdirAll(app, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := absPath(t, "config.yaml")
+
+	run := func(dir string, args ...string) *cmdWrapper {
+		c := newCmd(append([]string{"--config", cfg}, args...))
+		c.cmd.Path = absPath(t, "shipyard")
+		c.cmd.Dir = dir
+		return c
+	}
+
+	c := run(app, "get", "env", "co", "-o", "name")
+	if err := c.cmd.Run(); err != nil {
+		t.Fatalf("command unexpectedly failed: %v\nstderr: %s", err, c.stdErr.String())
+	}
+	if diff := cmp.Diff(c.stdOut.String(), "fleet-2\n"); diff != "" {
+		t.Error(diff)
+	}
+
+	c = run(app, "config", "view", "--show-origin", "-o", "csv")
+	if err := c.cmd.Run(); err != nil {
+		t.Fatalf("command unexpectedly failed: %v\nstderr: %s", err, c.stdErr.String())
+	}
+	want := "Key,Value,Origin\n" +
+		"aliases.co,fleet-2," + projectFile + "\n" +
+		"api_token,********,env SHIPYARD_API_TOKEN\n" +
+		"api_url,http://localhost:8000,env SHIPYARD_API_URL\n" +
+		"org,fleet," + projectFile + "\n" +
+		"output,csv,flag --output\n" +
+		"service,web," + projectFile + "\n"
+	if diff := cmp.Diff(c.stdOut.String(), want); diff != "" {
+		t.Error(diff)
+	}
+
+	// A project cannot send the token elsewhere.
+	if err := os.WriteFile(projectFile, []byte("api_url: https://example.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c = run(app, "get", "org")
+	if err := c.cmd.Run(); err == nil {
+		t.Fatal("expected an error but command succeeded")
+	}
+	wantErr := fmt.Sprintf("Init error: %s: \"api_url\" cannot be set in a project config, only org, repo_name, aliases, service, upload_ignore\n", projectFile)
+	if diff := cmp.Diff(c.stdErr.String(), wantErr); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestConfigCommands(t *testing.T) {
+	t.Parallel()
+	cfg := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(cfg, []byte("org: fleet\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name    string
+		args    []string
+		output  string
+		wantErr string
+	}{
+		{
+			name:   "set an int",
+			args:   []string{"config", "set", "mcp.port", "9090"},
+			output: "Set mcp.port to 9090 in " + cfg + ".\n",
+		},
+		{
+			name:   "get it back",
+			args:   []string{"config", "get", "mcp.port"},
+			output: "9090\n",
+		},
+		{
+			name:   "get a default",
+			args:   []string{"config", "get", "mcp.transport"},
+			output: "stdio\n",
+		},
+		{
+			name:    "wrong type",
+			args:    []string{"config", "set", "profiles.staging.verbose", "maybe"},
+			wantErr: "Command error: profiles.staging.verbose must be true or false, not \"maybe\"\n",
+		},
+		{
+			name:    "unknown key",
+			args:    []string{"config", "set", "profiles.staging.orgg", "acme"},
+			wantErr: "Command error: unknown config key \"profiles.staging.orgg\", did you mean \"profiles.staging.org\"?\n",
+		},
+		{
+			name:    "token",
+			args:    []string{"config", "set", "api_token", "secret"},
+			wantErr: "Command error: api_token holds a token, save it with 'shipyard login' or 'shipyard set token'\n",
+		},
+		{
+			name:   "set a list",
+			args:   []string{"config", "set", "upload_ignore", "node_modules,.git"},
+			output: "Set upload_ignore to node_modules,.git in " + cfg + ".\n",
+		},
+		{
+			name:   "set an MCP server token",
+			args:   []string{"config", "set", "mcp.auth.tokens", "first,second"},
+			output: "Set mcp.auth.tokens to ******** in " + cfg + ".\n",
+		},
+		{
+			name:   "get it masked",
+			args:   []string{"config", "get", "mcp.auth.tokens"},
+			output: "********\n",
+		},
+		{
+			name: "view it masked",
+			args: []string{"config", "view", "--show-origin", "-o", "csv"},
+			output: "Key,Value,Origin\n" +
+				"mcp.auth.tokens,********," + cfg + "\n" +
+				"mcp.port,9090," + cfg + "\n" +
+				"org,fleet," + cfg + "\n" +
+				"output,csv,flag --output\n" +
+				"upload_ignore,\"node_modules,.git\"," + cfg + "\n",
+		},
+		{
+			name:   "unset",
+			args:   []string{"config", "unset", "mcp.port"},
+			output: "Unset mcp.port in " + cfg + ".\n",
+		},
+		{
+			name:    "unset again",
+			args:    []string{"config", "unset", "mcp.port"},
+			wantErr: "Command error: mcp.port is not set in " + cfg + "\n",
+		},
+		{
+			name: "list",
+			args: []string{"config", "list", "-o", "csv"},
+			output: "Key,Type,Value\n" +
+				"api_token,string,********\n" +
+				"org,string,fleet\n" +
+				"verbose,bool,false\n" +
+				"api_url,string,http://localhost:8000\n" +
+				"profiles.<name>.api_url,string,\n" +
+				"profiles.<name>.org,string,\n" +
+				"profiles.<name>.verbose,bool,\n" +
+				"profiles.<name>.auth_token,string,\n" +
+				"profile,string,\n" +
+				"credential_store,auto|keyring|file|config,\n" +
+				"aliases.<name>,string,\n" +
+				"repo_name,string,\n" +
+				"service,string,\n" +
+				"upload_ignore,list,\"node_modules,.git\"\n" +
+				"mcp.transport,stdio|http,stdio\n" +
+				"mcp.host,string,127.0.0.1\n" +
+				"mcp.port,int,8080\n" +
+				"mcp.audit_logging,bool,true\n" +
+				"mcp.audit_log,string,\n" +
+				"mcp.audit_log_max_size,int,10\n" +
+				"mcp.audit_log_max_files,int,5\n" +
+				"mcp.allowed_origins,list,\n" +
+				"mcp.max_concurrency,int,8\n" +
+				"mcp.session_timeout,int,30\n" +
+				"mcp.max_sessions,int,100\n" +
+				"mcp.auth.tokens,list,********\n" +
+				"mcp.auth.users.<name>.token,string,\n" +
+				"mcp.auth.users.<name>.api_token,string,\n" +
+				"mcp.auth.users.<name>.org,string,\n" +
+				"mcp.read_only,bool,\n" +
+				"mcp.tools.allow,list,\n" +
+				"mcp.tools.deny,list,\n" +
+				"mcp.tools.confirm,bool,\n" +
+				"mcp.tools.scopes.<name>.orgs,list,\n" +
+				"mcp.tools.scopes.<name>.environments,list,\n",
+		},
+		{
+			name:   "validate",
+			args:   []string{"config", "validate"},
+			output: cfg + " is valid.\n",
+		},
+	}
+	for _, step := range steps {
+		c := newCmd(append(step.args, "--config", cfg))
+		err := c.cmd.Run()
+		if step.wantErr != "" {
+			if err == nil {
+				t.Fatalf("%s: expected an error but command succeeded", step.name)
+			}
+			if diff := cmp.Diff(c.stdErr.String(), step.wantErr); diff != "" {
+				t.Errorf("%s: %s", step.name, diff)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: command unexpectedly failed: %v\nstderr: %s", step.name, err, c.stdErr.String())
+		}
+		if diff := cmp.Diff(c.stdOut.String(), step.output); diff != "" {
+			t.Errorf("%s: %s", step.name, diff)
+		}
+	}
+
+	// A config that cannot be parsed stops other commands, but validate tells where the mistake is.
+	if err := os.WriteFile(cfg, []byte("org: fleet\nverbose: maybe\n  mcp: [\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := newCmd([]string{"get", "environments", "--config", cfg})
+	if err := c.cmd.Run(); err == nil {
+		t.Fatal("expected an error but command succeeded")
+	}
+	wantErr := "Init error: failed to parse the config file " + cfg + ": line 3: mapping values are not allowed in this context\n"
+	if diff := cmp.Diff(c.stdErr.String(), wantErr); diff != "" {
+		t.Error(diff)
+	}
+	c = newCmd([]string{"config", "validate", "--config", cfg})
+	if err := c.cmd.Run(); err == nil {
+		t.Fatal("expected an error but command succeeded")
+	}
+	if diff := cmp.Diff(c.stdOut.String(), cfg+": line 3: mapping values are not allowed in this context\n"); diff != "" {
+		t.Error(diff)
+	}
+
+	if err := os.WriteFile(cfg, []byte("org: fleet\nverbose: maybe\nmcp:\n  prot: 9090\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c = newCmd([]string{"config", "validate", "--config", cfg})
+	if err := c.cmd.Run(); err == nil {
+		t.Fatal("expected an error but command succeeded")
+	}
+	want := cfg + ": line 2, column 1: verbose must be true or false, not \"maybe\"\n" +
+		cfg + ": line 4, column 3: unknown config key \"mcp.prot\", did you mean \"mcp.port\"?\n"
+	if diff := cmp.Diff(c.stdOut.String(), want); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(c.stdErr.String(), "Command error: found 2 problem(s) in "+cfg+"\n"); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func absPath(t *testing.T, name string) string {
+	t.Helper()
+	p, err := filepath.Abs(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
 // nolint:gosec // Bad arguments can't be passed in.
 func newCmd(args []string) *cmdWrapper {
 	c := cmdWrapper{
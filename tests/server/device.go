@@ -0,0 +1,57 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// devices tracks the device logins in progress: each is approved on its second poll.
+var devices = struct {
+	sync.Mutex
+	count int
+	polls map[string]int
+}{polls: make(map[string]int)}
+
+func (handler) createDeviceCode(w http.ResponseWriter, _ *http.Request) {
+	devices.Lock()
+	devices.count++
+	code := fmt.Sprintf("device-%d", devices.count)
+	devices.polls[code] = 0
+	userCode := fmt.Sprintf("WXYZ-%04d", devices.count)
+	devices.Unlock()
+
+	_, _ = fmt.Fprintf(w, `{"data":{"attributes":{"device_code":%q,"user_code":%q,"verification_uri":"http://localhost:8000/device","verification_uri_complete":"http://localhost:8000/device?code=%s","expires_in":60,"interval":1}}}`,
+		code, userCode, userCode)
+}
+
+func (handler) createDeviceToken(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		DeviceCode string `json:"device_code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+
+	devices.Lock()
+	polls, ok := devices.polls[body.DeviceCode]
+	devices.polls[body.DeviceCode]++
+	devices.Unlock()
+
+	switch {
+	case !ok:
+		deviceError(w, "expired_token")
+	case polls == 0:
+		deviceError(w, "authorization_pending")
+	default:
+		_, _ = fmt.Fprint(w, `{"data":{"attributes":{"token":"test"}}}`)
+	}
+}
+
+func deviceError(w http.ResponseWriter, title string) {
+	w.WriteHeader(http.StatusBadRequest)
+	_, _ = fmt.Fprintf(w, `{"errors":[{"status":400,"title":%q}]}`, title)
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/shipyard/shipyard-cli/pkg/types"
 )
@@ -15,13 +17,105 @@ func (handler) getAllEnvironments(w http.ResponseWriter, r *http.Request) {
 		orgNotFound(w)
 		return
 	}
-	resp := types.RespManyEnvs{Data: envs}
+	envs = filterEnvironments(envs, r.URL.Query())
+	data, links := paginate(envs, r.URL)
+	resp := types.RespManyEnvs{Data: data, Links: links}
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		_, _ = w.Write([]byte(err.Error()))
 	}
 }
 
+func (handler) getEnvironmentUUIDs(w http.ResponseWriter, r *http.Request) {
+	envs, ok := store[r.URL.Query().Get("org")]
+	if !ok {
+		orgNotFound(w)
+		return
+	}
+	resp := types.UUIDResponse{Data: []string{}}
+	for i := range envs {
+		if envs[i].Attributes.Retired {
+			continue
+		}
+		resp.Data = append(resp.Data, envs[i].ID)
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(err.Error()))
+	}
+}
+
+// filterEnvironments applies the same query filters as the real API.
+func filterEnvironments(envs []types.Environment, query url.Values) []types.Environment {
+	var out []types.Environment
+	deleted := query.Get("deleted") == "true"
+	for i := range envs {
+		// Deleted environments are only listed on their own
+		if envs[i].Attributes.Retired != deleted {
+			continue
+		}
+		if name := query.Get("name"); name != "" && envs[i].Attributes.Name != name {
+			continue
+		}
+		if !matchesProject(envs[i].Attributes.Projects, query) {
+			continue
+		}
+		out = append(out, envs[i])
+	}
+	return out
+}
+
+func matchesProject(projects []types.Project, query url.Values) bool {
+	repo, branch, pr := query.Get("repo_name"), query.Get("branch"), query.Get("pull_request_number")
+	if repo == "" && branch == "" && pr == "" {
+		return true
+	}
+	for _, p := range projects {
+		if repo != "" && p.RepoName != repo {
+			continue
+		}
+		if branch != "" && p.Branch != branch {
+			continue
+		}
+		if pr != "" && strconv.Itoa(p.PullRequestNumber) != pr {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// paginate returns a single page of environments along with the links to other pages.
+func paginate(envs []types.Environment, u *url.URL) ([]types.Environment, types.Links) {
+	page, _ := strconv.Atoi(u.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(u.Query().Get("page_size"))
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	start := (page - 1) * pageSize
+	if start > len(envs) {
+		start = len(envs)
+	}
+	end := start + pageSize
+	if end > len(envs) {
+		end = len(envs)
+	}
+
+	var links types.Links
+	if end < len(envs) {
+		next := *u
+		q := next.Query()
+		q.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = q.Encode()
+		links.Next = next.RequestURI()
+	}
+	return envs[start:end], links
+}
+
 func (handler) getEnvironmentByID(w http.ResponseWriter, r *http.Request) {
 	env := findEnvByID(w, r)
 	if env != nil {
@@ -40,6 +134,44 @@ func (handler) rebuildEnvironment(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// environmentAction handles the stop, restart, cancel and revive endpoints.
+func (handler) environmentAction(w http.ResponseWriter, r *http.Request) {
+	env := findEnvByID(w, r)
+	if env == nil {
+		return
+	}
+	if env.Attributes.Name == "broken" {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = fmt.Fprintf(w, "cannot %s this environment", r.PathValue("action"))
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Environment %s accepted.", r.PathValue("action"))
+}
+
+// getCurrentUser knows two tokens: "test", a user token, and "org-test", an org-level token.
+// "forbidden" is a valid token without access to the user.
+func (handler) getCurrentUser(w http.ResponseWriter, r *http.Request) {
+	var resp types.UserResponse
+	switch r.Header.Get("x-api-token") {
+	case "test":
+		resp.Data.Attributes = types.UserAttributes{Name: "Jane Doe", Email: "jane@example.com", DefaultOrg: "default", TokenType: "user"}
+	case "org-test":
+		resp.Data.Attributes = types.UserAttributes{DefaultOrg: "fleet", TokenType: "org"}
+	case "forbidden":
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprint(w, `{"errors":[{"status":403,"title":"Access denied"}]}`)
+		return
+	default:
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = fmt.Fprint(w, `{"errors":[{"status":401,"title":"Invalid API token"}]}`)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(err.Error()))
+	}
+}
+
 func findEnvByID(w http.ResponseWriter, r *http.Request) *types.Environment {
 	org := r.URL.Query().Get("org")
 	envs, ok := store[org]
@@ -66,4 +66,71 @@ var store = map[string][]types.Environment{
 			ID: "pug-2",
 		},
 	},
+	"fleet": {
+		{
+			Attributes: types.EnvironmentAttributes{
+				Name:  "checkout",
+				URL:   "https://checkout.example.com",
+				Ready: true,
+				Projects: []types.Project{
+					{RepoName: "shop", Branch: "main"},
+				},
+			},
+			ID: "fleet-1",
+		},
+		{
+			Attributes: types.EnvironmentAttributes{
+				Name:           "checkout",
+				URL:            "https://checkout-pr.example.com",
+				Ready:          true,
+				SinceLastVisit: minutes(180),
+				Projects: []types.Project{
+					{PullRequestNumber: 7, RepoName: "shop", RepoOwner: "acme", Branch: "feature", CommitHash: "4f2a9c1e7b3d5a6c8e0f1a2b3c4d5e6f7a8b9c0d"},
+				},
+				Services: []types.Service{
+					{Name: "web", SanitizedName: "web", Ports: []string{"8080"}, URL: "https://web.checkout-pr.example.com"},
+					{Name: "worker", SanitizedName: "worker"},
+				},
+			},
+			ID: "fleet-2",
+		},
+		{
+			Attributes: types.EnvironmentAttributes{
+				Name:    "search",
+				URL:     "https://search.example.com",
+				Ready:   false,
+				Stopped: true,
+				Projects: []types.Project{
+					{RepoName: "shop", Branch: "main"},
+				},
+			},
+			ID: "fleet-3",
+		},
+		{
+			Attributes: types.EnvironmentAttributes{
+				Name:  "broken",
+				URL:   "https://broken.example.com",
+				Ready: false,
+				Projects: []types.Project{
+					{RepoName: "legacy", Branch: "main"},
+				},
+			},
+			ID: "fleet-4",
+		},
+		{
+			Attributes: types.EnvironmentAttributes{
+				Name:    "checkout",
+				URL:     "https://checkout-old.example.com",
+				Retired: true,
+				Projects: []types.Project{
+					{RepoName: "shop", Branch: "feature"},
+				},
+			},
+			ID: "fleet-5",
+		},
+	},
+}
+
+func minutes(n int) *int {
+	return &n
 }
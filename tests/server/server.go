@@ -5,9 +5,14 @@ import "net/http"
 func NewHandler() http.Handler {
 	var h handler
 	mux := http.NewServeMux()
+	mux.HandleFunc("GET /me", h.getCurrentUser)
+	mux.HandleFunc("POST /cli/device-code", h.createDeviceCode)
+	mux.HandleFunc("POST /cli/device-token", h.createDeviceToken)
 	mux.HandleFunc("GET /environment", h.getAllEnvironments)
+	mux.HandleFunc("GET /environment/uuid", h.getEnvironmentUUIDs)
 	mux.HandleFunc("GET /environment/{id}", h.getEnvironmentByID)
 	mux.HandleFunc("POST /environment/{id}/rebuild", h.rebuildEnvironment)
+	mux.HandleFunc("POST /environment/{id}/{action}", h.environmentAction)
 	return mux
 }
 